@@ -29,17 +29,37 @@ type Mall interface {
 
 // AdminAPI is an interface for admin
 type AdminAPI interface {
-	AddAdminUser(ctx context.Context, userID, nickName string) error                                             //perm:admin
-	GetAdminSignCode(ctx context.Context, userID string) (string, error)                                         //perm:default
-	LoginAdmin(ctx context.Context, user *types.UserReq) (*types.LoginResponse, error)                           //perm:default
-	GetWithdrawalRecords(ctx context.Context, req *types.GetWithdrawRequest) (*types.GetWithdrawResponse, error) //perm:default
-	ApproveUserWithdrawal(ctx context.Context, orderID, withdrawHash string) error                               //perm:admin
-	RejectUserWithdrawal(ctx context.Context, orderID string) error                                              //perm:admin
-	GetRechargeAddresses(ctx context.Context, limit, page int64) (*types.GetRechargeAddressResponse, error)      //perm:admin
-	SupplementRechargeOrder(ctx context.Context, hash string) error                                              //perm:admin,user
-	RefundInstance(ctx context.Context, instanceID string) (int64, error)                                        //perm:admin
-	InquiryPriceRefundInstance(ctx context.Context, instanceID string) (float32, error)                          //perm:admin
-	GetInstanceRecords(ctx context.Context, limit, page int64) (*types.GetInstanceResponse, error)               //perm:default
+	AddAdminUser(ctx context.Context, userID, nickName string) error                                                                                     //perm:admin
+	GetAdminSignCode(ctx context.Context, userID string) (string, error)                                                                                 //perm:default
+	LoginAdmin(ctx context.Context, user *types.UserReq) (*types.LoginResponse, error)                                                                   //perm:default
+	GetWithdrawalRecords(ctx context.Context, req *types.GetWithdrawRequest) (*types.GetWithdrawResponse, error)                                         //perm:default
+	CreateUserWithdrawal(ctx context.Context, userID, withdrawAddr, value string) (string, error)                                                        //perm:admin
+	ApproveUserWithdrawal(ctx context.Context, orderID, withdrawHash string) error                                                                       //perm:admin
+	RejectUserWithdrawal(ctx context.Context, orderID string) error                                                                                      //perm:admin
+	RetryUserWithdrawal(ctx context.Context, orderID string) error                                                                                       //perm:admin
+	GetWithdrawApprovals(ctx context.Context, orderID string) ([]*types.WithdrawApproval, error)                                                         //perm:admin
+	GetWithdrawPolicy(ctx context.Context) (*types.WithdrawPolicy, error)                                                                                //perm:admin
+	SetWithdrawPolicy(ctx context.Context, policy *types.WithdrawPolicy) error                                                                           //perm:admin
+	GetRechargeAddresses(ctx context.Context, limit, page int64) (*types.GetRechargeAddressResponse, error)                                              //perm:admin
+	SupplementRechargeOrder(ctx context.Context, hash string) error                                                                                      //perm:admin
+	GetDepositClaims(ctx context.Context, state types.DepositClaimState, limit, page int64) (*types.DepositClaimResponse, error)                         //perm:admin
+	RecheckDepositClaim(ctx context.Context, claimID string) (*types.DepositClaim, error)                                                                //perm:admin
+	AcceptDepositClaim(ctx context.Context, claimID, value, msg string) error                                                                            //perm:admin
+	RejectDepositClaim(ctx context.Context, claimID, msg string) error                                                                                   //perm:admin
+	GetReviewRecharges(ctx context.Context) ([]*types.RechargeRecord, error)                                                                             //perm:admin
+	ApproveRechargeReview(ctx context.Context, orderID string) error                                                                                     //perm:admin
+	RejectRechargeReview(ctx context.Context, orderID string) error                                                                                      //perm:admin
+	GetSweepRecords(ctx context.Context, limit, page int64) (*types.SweepRecordResponse, error)                                                          //perm:admin
+	SweepDepositAddresses(ctx context.Context) error                                                                                                     //perm:admin
+	RefundInstance(ctx context.Context, instanceID string) (int64, error)                                                                                //perm:admin
+	InquiryPriceRefundInstance(ctx context.Context, instanceID string) (float32, error)                                                                  //perm:admin
+	GetRefundRecords(ctx context.Context, limit, page int64) (*types.GetRefundResponse, error)                                                           //perm:admin
+	ApproveInstanceRefund(ctx context.Context, refundID string) error                                                                                    //perm:admin
+	RejectInstanceRefund(ctx context.Context, refundID, msg string) error                                                                                //perm:admin
+	GetOutboxEvents(ctx context.Context, topic string, from, limit int64) (*types.OutboxEventsResponse, error)                                           //perm:admin
+	GetOutboxDeliveries(ctx context.Context, consumer string, state types.OutboxDeliveryState, limit, page int64) (*types.OutboxDeliveryResponse, error) //perm:admin
+	RedriveOutboxDelivery(ctx context.Context, consumer string, eventID int64) error                                                                     //perm:admin
+	GetInstanceRecords(ctx context.Context, limit, page int64) (*types.GetInstanceResponse, error)                                                       //perm:default
 }
 
 // OrderAPI is an interface for order
@@ -52,23 +72,48 @@ type OrderAPI interface {
 	GetUserOrderRecords(ctx context.Context, limit, page int64) (*types.OrderRecordResponse, error)        //perm:user
 	CancelUserOrder(ctx context.Context, orderID string) error                                             //perm:user
 	PaymentUserOrder(ctx context.Context, orderID string) error                                            //perm:user
+	GetUserOrderItems(ctx context.Context, orderID string) ([]*types.OrderItem, error)                     //perm:user
+	GetOrderTimeline(ctx context.Context, orderID string) ([]*types.OrderEvent, error)                     //perm:user,admin
 }
 
 // UserAPI is an interface for user
 type UserAPI interface {
 	// user
-	GetBalance(ctx context.Context) (*types.UserInfo, error)                                             //perm:user
-	RebootInstance(ctx context.Context, regionID, instanceID string) error                               //perm:user
-	GetSignCode(ctx context.Context, userID string) (string, error)                                      //perm:default
-	Login(ctx context.Context, user *types.UserReq) (*types.LoginResponse, error)                        //perm:default
-	Logout(ctx context.Context, user *types.UserReq) error                                               //perm:user
-	GetRechargeAddress(ctx context.Context) (string, error)                                              //perm:user
-	Withdraw(ctx context.Context, withdrawAddr, value string) error                                      //perm:user
-	GetUserRechargeRecords(ctx context.Context, limit, page int64) (*types.RechargeResponse, error)      //perm:user
-	GetUserWithdrawalRecords(ctx context.Context, limit, page int64) (*types.GetWithdrawResponse, error) //perm:user
-	GetUserInstanceRecords(ctx context.Context, limit, page int64) (*types.GetInstanceResponse, error)   //perm:user
-	GetInstanceDetailsInfo(ctx context.Context, instanceID string) (*types.InstanceDetails, error)       //perm:user
-	UpdateInstanceName(ctx context.Context, instanceID, instanceName string) error                       //perm:user
+	GetBalance(ctx context.Context) (*types.UserInfo, error)                                                  //perm:user
+	RebootInstance(ctx context.Context, regionID, instanceID string) error                                    //perm:user
+	GetSignCode(ctx context.Context, userID string) (string, error)                                           //perm:default
+	Login(ctx context.Context, user *types.UserReq) (*types.LoginResponse, error)                             //perm:default
+	Logout(ctx context.Context, user *types.UserReq) error                                                    //perm:user
+	GetRechargeAddress(ctx context.Context, chain string) (string, error)                                     //perm:user
+	ClaimDeposit(ctx context.Context, chain, hash string) (*types.DepositClaim, error)                        //perm:user
+	GetUserDepositClaims(ctx context.Context, limit, page int64) (*types.DepositClaimResponse, error)         //perm:user
+	Withdraw(ctx context.Context, withdrawAddr, value string) (string, error)                                 //perm:user
+	PreviewWithdraw(ctx context.Context, value string) (*types.WithdrawPreview, error)                        //perm:user
+	AddWithdrawAddress(ctx context.Context, addr, label string) error                                         //perm:user
+	ConfirmWithdrawAddress(ctx context.Context, addr, code string) error                                      //perm:user
+	RemoveWithdrawAddress(ctx context.Context, addr string) error                                             //perm:user
+	GetWithdrawAddresses(ctx context.Context) ([]*types.WithdrawAddress, error)                               //perm:user
+	GetWithdrawAddressLogs(ctx context.Context, limit, page int64) (*types.WithdrawAddressLogResponse, error) //perm:user
+	GetWithdrawWhitelistOnly(ctx context.Context) (bool, error)                                               //perm:user
+	SendWithdrawWhitelistCode(ctx context.Context) error                                                      //perm:user
+	SetWithdrawWhitelistOnly(ctx context.Context, enabled bool, code string) error                            //perm:user
+	GetAccountEmail(ctx context.Context) (string, error)                                                      //perm:user
+	SendAccountEmailCode(ctx context.Context, email string) error                                             //perm:user
+	SetAccountEmail(ctx context.Context, email, code, oldCode string) error                                   //perm:user
+	GetUserRechargeRecords(ctx context.Context, limit, page int64) (*types.RechargeResponse, error)           //perm:user
+	GetUserWithdrawalRecords(ctx context.Context, limit, page int64) (*types.GetWithdrawResponse, error)      //perm:user
+	GetUserInstanceRecords(ctx context.Context, limit, page int64) (*types.GetInstanceResponse, error)        //perm:user
+	GetInstanceDetailsInfo(ctx context.Context, instanceID string) (*types.InstanceDetails, error)            //perm:user
+	UpdateInstanceName(ctx context.Context, instanceID, instanceName string) error                            //perm:user
+	ReinstallInstance(ctx context.Context, req *types.ReinstallInstanceReq) error                             //perm:user
+	RequestInstanceRefund(ctx context.Context, instanceID, reason string) (string, error)                     //perm:user
+	GetUserRefundRecords(ctx context.Context, limit, page int64) (*types.GetRefundResponse, error)            //perm:user
+	SetBillingInfo(ctx context.Context, info *types.BillingInfo) error                                        //perm:user
+	GetBillingInfo(ctx context.Context) (*types.BillingInfo, error)                                           //perm:user
+	GetUserInvoices(ctx context.Context, limit, page int64) (*types.InvoiceResponse, error)                   //perm:user
+	GetInvoice(ctx context.Context, invoiceNo int64) (*types.Invoice, error)                                  //perm:user,admin
+	RenderInvoice(ctx context.Context, invoiceNo int64, format string) ([]byte, error)                        //perm:user,admin
+	GetAccountStatement(ctx context.Context, from, to string, cursor int64) (*types.AccountStatement, error)  //perm:user
 }
 
 type AccountAPI interface {
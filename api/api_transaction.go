@@ -2,11 +2,42 @@ package api
 
 import (
 	"context"
+
+	"github.com/LMF709268224/titan-vps/api/types"
 )
 
-// Transaction is an interface for transaction
+// Transaction is an interface for transaction, it watches the chains for deposits and pays out the approved withdrawals
 type Transaction interface {
 	Common
 
 	Hello(ctx context.Context) error //perm:read
+
+	// MethodGroup: Chain
+
+	// GetChainInfo describes the served chains
+	GetChainInfo(ctx context.Context) (*types.ChainInfo, error) //perm:admin
+	// GetChainEvents returns the deposit and payout events of the outbox from an id
+	GetChainEvents(ctx context.Context, from, limit int64) (*types.OutboxEventsResponse, error) //perm:admin
+	// AllocateTronAddress allocates a tron deposit address for a user
+	AllocateTronAddress(ctx context.Context, userID string) (string, error) //perm:admin
+	// AllocateFvmAddress allocates a FEVM deposit address for a user
+	AllocateFvmAddress(ctx context.Context, userID string) (string, error) //perm:admin
+	// AllocateEvmAddress allocates a deposit address of an EVM chain for a user
+	AllocateEvmAddress(ctx context.Context, chain, userID string) (string, error) //perm:admin
+	// CheckTronAddress checks if a tron address can be paid to
+	CheckTronAddress(ctx context.Context, addr string) error //perm:admin
+	// SupplementTronOrder publishes the deposits of a tron transaction missed by the watcher
+	SupplementTronOrder(ctx context.Context, hash string) error //perm:admin
+	// GetTronDeposits returns the transfers of a tron transaction to the deposit addresses without publishing them
+	GetTronDeposits(ctx context.Context, hash string) (*types.TronDeposits, error) //perm:admin
+	// TronPayoutFailed checks if a tron payout transaction failed or was dropped, so it paid nothing
+	TronPayoutFailed(ctx context.Context, hash string, expiration int64) (bool, error) //perm:admin
+	// SubmitPayout queues the payout of an approved withdrawal, a payout submitted before is kept
+	SubmitPayout(ctx context.Context, orderID, withdrawAddr, value string) error //perm:admin
+	// RetryPayout pays a failed payout again
+	RetryPayout(ctx context.Context, orderID string) error //perm:admin
+	// SweepDepositAddresses starts sweeping the deposit addresses
+	SweepDepositAddresses(ctx context.Context) error //perm:admin
+	// SimulateDeposit publishes a confirmed deposit of a user on a stand-in chain and returns its transaction hash
+	SimulateDeposit(ctx context.Context, userID, value string) (string, error) //perm:admin
 }
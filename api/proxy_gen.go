@@ -28,27 +28,67 @@ type AccountAPIStub struct {
 
 type AdminAPIStruct struct {
 	Internal struct {
+		AcceptDepositClaim func(p0 context.Context, p1 string, p2 string, p3 string) error `perm:"admin"`
+
 		AddAdminUser func(p0 context.Context, p1 string, p2 string) error `perm:"admin"`
 
+		ApproveInstanceRefund func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		ApproveRechargeReview func(p0 context.Context, p1 string) error `perm:"admin"`
+
 		ApproveUserWithdrawal func(p0 context.Context, p1 string, p2 string) error `perm:"admin"`
 
+		CreateUserWithdrawal func(p0 context.Context, p1 string, p2 string, p3 string) (string, error) `perm:"admin"`
+
 		GetAdminSignCode func(p0 context.Context, p1 string) (string, error) `perm:"default"`
 
+		GetDepositClaims func(p0 context.Context, p1 types.DepositClaimState, p2 int64, p3 int64) (*types.DepositClaimResponse, error) `perm:"admin"`
+
 		GetInstanceRecords func(p0 context.Context, p1 int64, p2 int64) (*types.GetInstanceResponse, error) `perm:"default"`
 
+		GetOutboxDeliveries func(p0 context.Context, p1 string, p2 types.OutboxDeliveryState, p3 int64, p4 int64) (*types.OutboxDeliveryResponse, error) `perm:"admin"`
+
+		GetOutboxEvents func(p0 context.Context, p1 string, p2 int64, p3 int64) (*types.OutboxEventsResponse, error) `perm:"admin"`
+
 		GetRechargeAddresses func(p0 context.Context, p1 int64, p2 int64) (*types.GetRechargeAddressResponse, error) `perm:"admin"`
 
+		GetRefundRecords func(p0 context.Context, p1 int64, p2 int64) (*types.GetRefundResponse, error) `perm:"admin"`
+
+		GetReviewRecharges func(p0 context.Context) ([]*types.RechargeRecord, error) `perm:"admin"`
+
+		GetSweepRecords func(p0 context.Context, p1 int64, p2 int64) (*types.SweepRecordResponse, error) `perm:"admin"`
+
+		GetWithdrawApprovals func(p0 context.Context, p1 string) ([]*types.WithdrawApproval, error) `perm:"admin"`
+
+		GetWithdrawPolicy func(p0 context.Context) (*types.WithdrawPolicy, error) `perm:"admin"`
+
 		GetWithdrawalRecords func(p0 context.Context, p1 *types.GetWithdrawRequest) (*types.GetWithdrawResponse, error) `perm:"default"`
 
 		InquiryPriceRefundInstance func(p0 context.Context, p1 string) (float32, error) `perm:"admin"`
 
 		LoginAdmin func(p0 context.Context, p1 *types.UserReq) (*types.LoginResponse, error) `perm:"default"`
 
+		RecheckDepositClaim func(p0 context.Context, p1 string) (*types.DepositClaim, error) `perm:"admin"`
+
+		RedriveOutboxDelivery func(p0 context.Context, p1 string, p2 int64) error `perm:"admin"`
+
 		RefundInstance func(p0 context.Context, p1 string) (int64, error) `perm:"admin"`
 
+		RejectDepositClaim func(p0 context.Context, p1 string, p2 string) error `perm:"admin"`
+
+		RejectInstanceRefund func(p0 context.Context, p1 string, p2 string) error `perm:"admin"`
+
+		RejectRechargeReview func(p0 context.Context, p1 string) error `perm:"admin"`
+
 		RejectUserWithdrawal func(p0 context.Context, p1 string) error `perm:"admin"`
 
-		SupplementRechargeOrder func(p0 context.Context, p1 string) error `perm:"admin,user"`
+		RetryUserWithdrawal func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		SetWithdrawPolicy func(p0 context.Context, p1 *types.WithdrawPolicy) error `perm:"admin"`
+
+		SupplementRechargeOrder func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		SweepDepositAddresses func(p0 context.Context) error `perm:"admin"`
 	}
 }
 
@@ -138,8 +178,12 @@ type OrderAPIStruct struct {
 
 		CreateOrder func(p0 context.Context, p1 types.CreateOrderReq) (string, error) `perm:"user"`
 
+		GetOrderTimeline func(p0 context.Context, p1 string) ([]*types.OrderEvent, error) `perm:"user,admin"`
+
 		GetUseWaitingPaymentOrders func(p0 context.Context, p1 int64, p2 int64) (*types.OrderRecordResponse, error) `perm:"user"`
 
+		GetUserOrderItems func(p0 context.Context, p1 string) ([]*types.OrderItem, error) `perm:"user"`
+
 		GetUserOrderRecords func(p0 context.Context, p1 int64, p2 int64) (*types.OrderRecordResponse, error) `perm:"user"`
 
 		PaymentUserOrder func(p0 context.Context, p1 string) error `perm:"user"`
@@ -157,7 +201,33 @@ type TransactionStruct struct {
 	CommonStruct
 
 	Internal struct {
+		AllocateEvmAddress func(p0 context.Context, p1 string, p2 string) (string, error) `perm:"admin"`
+
+		AllocateFvmAddress func(p0 context.Context, p1 string) (string, error) `perm:"admin"`
+
+		AllocateTronAddress func(p0 context.Context, p1 string) (string, error) `perm:"admin"`
+
+		CheckTronAddress func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		GetChainEvents func(p0 context.Context, p1 int64, p2 int64) (*types.OutboxEventsResponse, error) `perm:"admin"`
+
+		GetChainInfo func(p0 context.Context) (*types.ChainInfo, error) `perm:"admin"`
+
+		GetTronDeposits func(p0 context.Context, p1 string) (*types.TronDeposits, error) `perm:"admin"`
+
 		Hello func(p0 context.Context) error `perm:"read"`
+
+		RetryPayout func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		SimulateDeposit func(p0 context.Context, p1 string, p2 string) (string, error) `perm:"admin"`
+
+		SubmitPayout func(p0 context.Context, p1 string, p2 string, p3 string) error `perm:"admin"`
+
+		SupplementTronOrder func(p0 context.Context, p1 string) error `perm:"admin"`
+
+		SweepDepositAddresses func(p0 context.Context) error `perm:"admin"`
+
+		TronPayoutFailed func(p0 context.Context, p1 string, p2 int64) (bool, error) `perm:"admin"`
 	}
 }
 
@@ -167,29 +237,75 @@ type TransactionStub struct {
 
 type UserAPIStruct struct {
 	Internal struct {
+		AddWithdrawAddress func(p0 context.Context, p1 string, p2 string) error `perm:"user"`
+
+		ClaimDeposit func(p0 context.Context, p1 string, p2 string) (*types.DepositClaim, error) `perm:"user"`
+
+		ConfirmWithdrawAddress func(p0 context.Context, p1 string, p2 string) error `perm:"user"`
+
+		GetAccountEmail func(p0 context.Context) (string, error) `perm:"user"`
+
+		GetAccountStatement func(p0 context.Context, p1 string, p2 string, p3 int64) (*types.AccountStatement, error) `perm:"user"`
+
 		GetBalance func(p0 context.Context) (*types.UserInfo, error) `perm:"user"`
 
+		GetBillingInfo func(p0 context.Context) (*types.BillingInfo, error) `perm:"user"`
+
 		GetInstanceDetailsInfo func(p0 context.Context, p1 string) (*types.InstanceDetails, error) `perm:"user"`
 
-		GetRechargeAddress func(p0 context.Context) (string, error) `perm:"user"`
+		GetInvoice func(p0 context.Context, p1 int64) (*types.Invoice, error) `perm:"user,admin"`
+
+		GetRechargeAddress func(p0 context.Context, p1 string) (string, error) `perm:"user"`
 
 		GetSignCode func(p0 context.Context, p1 string) (string, error) `perm:"default"`
 
+		GetUserDepositClaims func(p0 context.Context, p1 int64, p2 int64) (*types.DepositClaimResponse, error) `perm:"user"`
+
 		GetUserInstanceRecords func(p0 context.Context, p1 int64, p2 int64) (*types.GetInstanceResponse, error) `perm:"user"`
 
+		GetUserInvoices func(p0 context.Context, p1 int64, p2 int64) (*types.InvoiceResponse, error) `perm:"user"`
+
 		GetUserRechargeRecords func(p0 context.Context, p1 int64, p2 int64) (*types.RechargeResponse, error) `perm:"user"`
 
+		GetUserRefundRecords func(p0 context.Context, p1 int64, p2 int64) (*types.GetRefundResponse, error) `perm:"user"`
+
 		GetUserWithdrawalRecords func(p0 context.Context, p1 int64, p2 int64) (*types.GetWithdrawResponse, error) `perm:"user"`
 
+		GetWithdrawAddressLogs func(p0 context.Context, p1 int64, p2 int64) (*types.WithdrawAddressLogResponse, error) `perm:"user"`
+
+		GetWithdrawAddresses func(p0 context.Context) ([]*types.WithdrawAddress, error) `perm:"user"`
+
+		GetWithdrawWhitelistOnly func(p0 context.Context) (bool, error) `perm:"user"`
+
 		Login func(p0 context.Context, p1 *types.UserReq) (*types.LoginResponse, error) `perm:"default"`
 
 		Logout func(p0 context.Context, p1 *types.UserReq) error `perm:"user"`
 
+		PreviewWithdraw func(p0 context.Context, p1 string) (*types.WithdrawPreview, error) `perm:"user"`
+
 		RebootInstance func(p0 context.Context, p1 string, p2 string) error `perm:"user"`
 
+		ReinstallInstance func(p0 context.Context, p1 *types.ReinstallInstanceReq) error `perm:"user"`
+
+		RemoveWithdrawAddress func(p0 context.Context, p1 string) error `perm:"user"`
+
+		RenderInvoice func(p0 context.Context, p1 int64, p2 string) ([]byte, error) `perm:"user,admin"`
+
+		RequestInstanceRefund func(p0 context.Context, p1 string, p2 string) (string, error) `perm:"user"`
+
+		SendAccountEmailCode func(p0 context.Context, p1 string) error `perm:"user"`
+
+		SendWithdrawWhitelistCode func(p0 context.Context) error `perm:"user"`
+
+		SetAccountEmail func(p0 context.Context, p1 string, p2 string, p3 string) error `perm:"user"`
+
+		SetBillingInfo func(p0 context.Context, p1 *types.BillingInfo) error `perm:"user"`
+
+		SetWithdrawWhitelistOnly func(p0 context.Context, p1 bool, p2 string) error `perm:"user"`
+
 		UpdateInstanceName func(p0 context.Context, p1 string, p2 string) error `perm:"user"`
 
-		Withdraw func(p0 context.Context, p1 string, p2 string) error `perm:"user"`
+		Withdraw func(p0 context.Context, p1 string, p2 string) (string, error) `perm:"user"`
 	}
 }
 
@@ -229,6 +345,17 @@ func (s *AccountAPIStub) SetInvitationCode(p0 context.Context, p1 string) error
 	return ErrNotSupported
 }
 
+func (s *AdminAPIStruct) AcceptDepositClaim(p0 context.Context, p1 string, p2 string, p3 string) error {
+	if s.Internal.AcceptDepositClaim == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.AcceptDepositClaim(p0, p1, p2, p3)
+}
+
+func (s *AdminAPIStub) AcceptDepositClaim(p0 context.Context, p1 string, p2 string, p3 string) error {
+	return ErrNotSupported
+}
+
 func (s *AdminAPIStruct) AddAdminUser(p0 context.Context, p1 string, p2 string) error {
 	if s.Internal.AddAdminUser == nil {
 		return ErrNotSupported
@@ -240,6 +367,28 @@ func (s *AdminAPIStub) AddAdminUser(p0 context.Context, p1 string, p2 string) er
 	return ErrNotSupported
 }
 
+func (s *AdminAPIStruct) ApproveInstanceRefund(p0 context.Context, p1 string) error {
+	if s.Internal.ApproveInstanceRefund == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ApproveInstanceRefund(p0, p1)
+}
+
+func (s *AdminAPIStub) ApproveInstanceRefund(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *AdminAPIStruct) ApproveRechargeReview(p0 context.Context, p1 string) error {
+	if s.Internal.ApproveRechargeReview == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ApproveRechargeReview(p0, p1)
+}
+
+func (s *AdminAPIStub) ApproveRechargeReview(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *AdminAPIStruct) ApproveUserWithdrawal(p0 context.Context, p1 string, p2 string) error {
 	if s.Internal.ApproveUserWithdrawal == nil {
 		return ErrNotSupported
@@ -251,6 +400,17 @@ func (s *AdminAPIStub) ApproveUserWithdrawal(p0 context.Context, p1 string, p2 s
 	return ErrNotSupported
 }
 
+func (s *AdminAPIStruct) CreateUserWithdrawal(p0 context.Context, p1 string, p2 string, p3 string) (string, error) {
+	if s.Internal.CreateUserWithdrawal == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.CreateUserWithdrawal(p0, p1, p2, p3)
+}
+
+func (s *AdminAPIStub) CreateUserWithdrawal(p0 context.Context, p1 string, p2 string, p3 string) (string, error) {
+	return "", ErrNotSupported
+}
+
 func (s *AdminAPIStruct) GetAdminSignCode(p0 context.Context, p1 string) (string, error) {
 	if s.Internal.GetAdminSignCode == nil {
 		return "", ErrNotSupported
@@ -262,6 +422,17 @@ func (s *AdminAPIStub) GetAdminSignCode(p0 context.Context, p1 string) (string,
 	return "", ErrNotSupported
 }
 
+func (s *AdminAPIStruct) GetDepositClaims(p0 context.Context, p1 types.DepositClaimState, p2 int64, p3 int64) (*types.DepositClaimResponse, error) {
+	if s.Internal.GetDepositClaims == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetDepositClaims(p0, p1, p2, p3)
+}
+
+func (s *AdminAPIStub) GetDepositClaims(p0 context.Context, p1 types.DepositClaimState, p2 int64, p3 int64) (*types.DepositClaimResponse, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *AdminAPIStruct) GetInstanceRecords(p0 context.Context, p1 int64, p2 int64) (*types.GetInstanceResponse, error) {
 	if s.Internal.GetInstanceRecords == nil {
 		return nil, ErrNotSupported
@@ -273,6 +444,28 @@ func (s *AdminAPIStub) GetInstanceRecords(p0 context.Context, p1 int64, p2 int64
 	return nil, ErrNotSupported
 }
 
+func (s *AdminAPIStruct) GetOutboxDeliveries(p0 context.Context, p1 string, p2 types.OutboxDeliveryState, p3 int64, p4 int64) (*types.OutboxDeliveryResponse, error) {
+	if s.Internal.GetOutboxDeliveries == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetOutboxDeliveries(p0, p1, p2, p3, p4)
+}
+
+func (s *AdminAPIStub) GetOutboxDeliveries(p0 context.Context, p1 string, p2 types.OutboxDeliveryState, p3 int64, p4 int64) (*types.OutboxDeliveryResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *AdminAPIStruct) GetOutboxEvents(p0 context.Context, p1 string, p2 int64, p3 int64) (*types.OutboxEventsResponse, error) {
+	if s.Internal.GetOutboxEvents == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetOutboxEvents(p0, p1, p2, p3)
+}
+
+func (s *AdminAPIStub) GetOutboxEvents(p0 context.Context, p1 string, p2 int64, p3 int64) (*types.OutboxEventsResponse, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *AdminAPIStruct) GetRechargeAddresses(p0 context.Context, p1 int64, p2 int64) (*types.GetRechargeAddressResponse, error) {
 	if s.Internal.GetRechargeAddresses == nil {
 		return nil, ErrNotSupported
@@ -284,6 +477,61 @@ func (s *AdminAPIStub) GetRechargeAddresses(p0 context.Context, p1 int64, p2 int
 	return nil, ErrNotSupported
 }
 
+func (s *AdminAPIStruct) GetRefundRecords(p0 context.Context, p1 int64, p2 int64) (*types.GetRefundResponse, error) {
+	if s.Internal.GetRefundRecords == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetRefundRecords(p0, p1, p2)
+}
+
+func (s *AdminAPIStub) GetRefundRecords(p0 context.Context, p1 int64, p2 int64) (*types.GetRefundResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *AdminAPIStruct) GetReviewRecharges(p0 context.Context) ([]*types.RechargeRecord, error) {
+	if s.Internal.GetReviewRecharges == nil {
+		return *new([]*types.RechargeRecord), ErrNotSupported
+	}
+	return s.Internal.GetReviewRecharges(p0)
+}
+
+func (s *AdminAPIStub) GetReviewRecharges(p0 context.Context) ([]*types.RechargeRecord, error) {
+	return *new([]*types.RechargeRecord), ErrNotSupported
+}
+
+func (s *AdminAPIStruct) GetSweepRecords(p0 context.Context, p1 int64, p2 int64) (*types.SweepRecordResponse, error) {
+	if s.Internal.GetSweepRecords == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetSweepRecords(p0, p1, p2)
+}
+
+func (s *AdminAPIStub) GetSweepRecords(p0 context.Context, p1 int64, p2 int64) (*types.SweepRecordResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *AdminAPIStruct) GetWithdrawApprovals(p0 context.Context, p1 string) ([]*types.WithdrawApproval, error) {
+	if s.Internal.GetWithdrawApprovals == nil {
+		return *new([]*types.WithdrawApproval), ErrNotSupported
+	}
+	return s.Internal.GetWithdrawApprovals(p0, p1)
+}
+
+func (s *AdminAPIStub) GetWithdrawApprovals(p0 context.Context, p1 string) ([]*types.WithdrawApproval, error) {
+	return *new([]*types.WithdrawApproval), ErrNotSupported
+}
+
+func (s *AdminAPIStruct) GetWithdrawPolicy(p0 context.Context) (*types.WithdrawPolicy, error) {
+	if s.Internal.GetWithdrawPolicy == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetWithdrawPolicy(p0)
+}
+
+func (s *AdminAPIStub) GetWithdrawPolicy(p0 context.Context) (*types.WithdrawPolicy, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *AdminAPIStruct) GetWithdrawalRecords(p0 context.Context, p1 *types.GetWithdrawRequest) (*types.GetWithdrawResponse, error) {
 	if s.Internal.GetWithdrawalRecords == nil {
 		return nil, ErrNotSupported
@@ -317,6 +565,28 @@ func (s *AdminAPIStub) LoginAdmin(p0 context.Context, p1 *types.UserReq) (*types
 	return nil, ErrNotSupported
 }
 
+func (s *AdminAPIStruct) RecheckDepositClaim(p0 context.Context, p1 string) (*types.DepositClaim, error) {
+	if s.Internal.RecheckDepositClaim == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.RecheckDepositClaim(p0, p1)
+}
+
+func (s *AdminAPIStub) RecheckDepositClaim(p0 context.Context, p1 string) (*types.DepositClaim, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *AdminAPIStruct) RedriveOutboxDelivery(p0 context.Context, p1 string, p2 int64) error {
+	if s.Internal.RedriveOutboxDelivery == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RedriveOutboxDelivery(p0, p1, p2)
+}
+
+func (s *AdminAPIStub) RedriveOutboxDelivery(p0 context.Context, p1 string, p2 int64) error {
+	return ErrNotSupported
+}
+
 func (s *AdminAPIStruct) RefundInstance(p0 context.Context, p1 string) (int64, error) {
 	if s.Internal.RefundInstance == nil {
 		return 0, ErrNotSupported
@@ -328,6 +598,39 @@ func (s *AdminAPIStub) RefundInstance(p0 context.Context, p1 string) (int64, err
 	return 0, ErrNotSupported
 }
 
+func (s *AdminAPIStruct) RejectDepositClaim(p0 context.Context, p1 string, p2 string) error {
+	if s.Internal.RejectDepositClaim == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RejectDepositClaim(p0, p1, p2)
+}
+
+func (s *AdminAPIStub) RejectDepositClaim(p0 context.Context, p1 string, p2 string) error {
+	return ErrNotSupported
+}
+
+func (s *AdminAPIStruct) RejectInstanceRefund(p0 context.Context, p1 string, p2 string) error {
+	if s.Internal.RejectInstanceRefund == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RejectInstanceRefund(p0, p1, p2)
+}
+
+func (s *AdminAPIStub) RejectInstanceRefund(p0 context.Context, p1 string, p2 string) error {
+	return ErrNotSupported
+}
+
+func (s *AdminAPIStruct) RejectRechargeReview(p0 context.Context, p1 string) error {
+	if s.Internal.RejectRechargeReview == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RejectRechargeReview(p0, p1)
+}
+
+func (s *AdminAPIStub) RejectRechargeReview(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
 func (s *AdminAPIStruct) RejectUserWithdrawal(p0 context.Context, p1 string) error {
 	if s.Internal.RejectUserWithdrawal == nil {
 		return ErrNotSupported
@@ -339,6 +642,28 @@ func (s *AdminAPIStub) RejectUserWithdrawal(p0 context.Context, p1 string) error
 	return ErrNotSupported
 }
 
+func (s *AdminAPIStruct) RetryUserWithdrawal(p0 context.Context, p1 string) error {
+	if s.Internal.RetryUserWithdrawal == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RetryUserWithdrawal(p0, p1)
+}
+
+func (s *AdminAPIStub) RetryUserWithdrawal(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *AdminAPIStruct) SetWithdrawPolicy(p0 context.Context, p1 *types.WithdrawPolicy) error {
+	if s.Internal.SetWithdrawPolicy == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SetWithdrawPolicy(p0, p1)
+}
+
+func (s *AdminAPIStub) SetWithdrawPolicy(p0 context.Context, p1 *types.WithdrawPolicy) error {
+	return ErrNotSupported
+}
+
 func (s *AdminAPIStruct) SupplementRechargeOrder(p0 context.Context, p1 string) error {
 	if s.Internal.SupplementRechargeOrder == nil {
 		return ErrNotSupported
@@ -350,6 +675,17 @@ func (s *AdminAPIStub) SupplementRechargeOrder(p0 context.Context, p1 string) er
 	return ErrNotSupported
 }
 
+func (s *AdminAPIStruct) SweepDepositAddresses(p0 context.Context) error {
+	if s.Internal.SweepDepositAddresses == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SweepDepositAddresses(p0)
+}
+
+func (s *AdminAPIStub) SweepDepositAddresses(p0 context.Context) error {
+	return ErrNotSupported
+}
+
 func (s *CommonStruct) AuthNew(p0 context.Context, p1 *types.JWTPayload) (string, error) {
 	if s.Internal.AuthNew == nil {
 		return "", ErrNotSupported
@@ -614,6 +950,17 @@ func (s *OrderAPIStub) CreateOrder(p0 context.Context, p1 types.CreateOrderReq)
 	return "", ErrNotSupported
 }
 
+func (s *OrderAPIStruct) GetOrderTimeline(p0 context.Context, p1 string) ([]*types.OrderEvent, error) {
+	if s.Internal.GetOrderTimeline == nil {
+		return *new([]*types.OrderEvent), ErrNotSupported
+	}
+	return s.Internal.GetOrderTimeline(p0, p1)
+}
+
+func (s *OrderAPIStub) GetOrderTimeline(p0 context.Context, p1 string) ([]*types.OrderEvent, error) {
+	return *new([]*types.OrderEvent), ErrNotSupported
+}
+
 func (s *OrderAPIStruct) GetUseWaitingPaymentOrders(p0 context.Context, p1 int64, p2 int64) (*types.OrderRecordResponse, error) {
 	if s.Internal.GetUseWaitingPaymentOrders == nil {
 		return nil, ErrNotSupported
@@ -625,6 +972,17 @@ func (s *OrderAPIStub) GetUseWaitingPaymentOrders(p0 context.Context, p1 int64,
 	return nil, ErrNotSupported
 }
 
+func (s *OrderAPIStruct) GetUserOrderItems(p0 context.Context, p1 string) ([]*types.OrderItem, error) {
+	if s.Internal.GetUserOrderItems == nil {
+		return *new([]*types.OrderItem), ErrNotSupported
+	}
+	return s.Internal.GetUserOrderItems(p0, p1)
+}
+
+func (s *OrderAPIStub) GetUserOrderItems(p0 context.Context, p1 string) ([]*types.OrderItem, error) {
+	return *new([]*types.OrderItem), ErrNotSupported
+}
+
 func (s *OrderAPIStruct) GetUserOrderRecords(p0 context.Context, p1 int64, p2 int64) (*types.OrderRecordResponse, error) {
 	if s.Internal.GetUserOrderRecords == nil {
 		return nil, ErrNotSupported
@@ -669,6 +1027,83 @@ func (s *OrderAPIStub) RenewOrder(p0 context.Context, p1 types.RenewOrderReq) (s
 	return "", ErrNotSupported
 }
 
+func (s *TransactionStruct) AllocateEvmAddress(p0 context.Context, p1 string, p2 string) (string, error) {
+	if s.Internal.AllocateEvmAddress == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.AllocateEvmAddress(p0, p1, p2)
+}
+
+func (s *TransactionStub) AllocateEvmAddress(p0 context.Context, p1 string, p2 string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *TransactionStruct) AllocateFvmAddress(p0 context.Context, p1 string) (string, error) {
+	if s.Internal.AllocateFvmAddress == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.AllocateFvmAddress(p0, p1)
+}
+
+func (s *TransactionStub) AllocateFvmAddress(p0 context.Context, p1 string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *TransactionStruct) AllocateTronAddress(p0 context.Context, p1 string) (string, error) {
+	if s.Internal.AllocateTronAddress == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.AllocateTronAddress(p0, p1)
+}
+
+func (s *TransactionStub) AllocateTronAddress(p0 context.Context, p1 string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *TransactionStruct) CheckTronAddress(p0 context.Context, p1 string) error {
+	if s.Internal.CheckTronAddress == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.CheckTronAddress(p0, p1)
+}
+
+func (s *TransactionStub) CheckTronAddress(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *TransactionStruct) GetChainEvents(p0 context.Context, p1 int64, p2 int64) (*types.OutboxEventsResponse, error) {
+	if s.Internal.GetChainEvents == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetChainEvents(p0, p1, p2)
+}
+
+func (s *TransactionStub) GetChainEvents(p0 context.Context, p1 int64, p2 int64) (*types.OutboxEventsResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *TransactionStruct) GetChainInfo(p0 context.Context) (*types.ChainInfo, error) {
+	if s.Internal.GetChainInfo == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetChainInfo(p0)
+}
+
+func (s *TransactionStub) GetChainInfo(p0 context.Context) (*types.ChainInfo, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *TransactionStruct) GetTronDeposits(p0 context.Context, p1 string) (*types.TronDeposits, error) {
+	if s.Internal.GetTronDeposits == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetTronDeposits(p0, p1)
+}
+
+func (s *TransactionStub) GetTronDeposits(p0 context.Context, p1 string) (*types.TronDeposits, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *TransactionStruct) Hello(p0 context.Context) error {
 	if s.Internal.Hello == nil {
 		return ErrNotSupported
@@ -680,6 +1115,127 @@ func (s *TransactionStub) Hello(p0 context.Context) error {
 	return ErrNotSupported
 }
 
+func (s *TransactionStruct) RetryPayout(p0 context.Context, p1 string) error {
+	if s.Internal.RetryPayout == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RetryPayout(p0, p1)
+}
+
+func (s *TransactionStub) RetryPayout(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *TransactionStruct) SimulateDeposit(p0 context.Context, p1 string, p2 string) (string, error) {
+	if s.Internal.SimulateDeposit == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.SimulateDeposit(p0, p1, p2)
+}
+
+func (s *TransactionStub) SimulateDeposit(p0 context.Context, p1 string, p2 string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *TransactionStruct) SubmitPayout(p0 context.Context, p1 string, p2 string, p3 string) error {
+	if s.Internal.SubmitPayout == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SubmitPayout(p0, p1, p2, p3)
+}
+
+func (s *TransactionStub) SubmitPayout(p0 context.Context, p1 string, p2 string, p3 string) error {
+	return ErrNotSupported
+}
+
+func (s *TransactionStruct) SupplementTronOrder(p0 context.Context, p1 string) error {
+	if s.Internal.SupplementTronOrder == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SupplementTronOrder(p0, p1)
+}
+
+func (s *TransactionStub) SupplementTronOrder(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *TransactionStruct) SweepDepositAddresses(p0 context.Context) error {
+	if s.Internal.SweepDepositAddresses == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SweepDepositAddresses(p0)
+}
+
+func (s *TransactionStub) SweepDepositAddresses(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *TransactionStruct) TronPayoutFailed(p0 context.Context, p1 string, p2 int64) (bool, error) {
+	if s.Internal.TronPayoutFailed == nil {
+		return false, ErrNotSupported
+	}
+	return s.Internal.TronPayoutFailed(p0, p1, p2)
+}
+
+func (s *TransactionStub) TronPayoutFailed(p0 context.Context, p1 string, p2 int64) (bool, error) {
+	return false, ErrNotSupported
+}
+
+func (s *UserAPIStruct) AddWithdrawAddress(p0 context.Context, p1 string, p2 string) error {
+	if s.Internal.AddWithdrawAddress == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.AddWithdrawAddress(p0, p1, p2)
+}
+
+func (s *UserAPIStub) AddWithdrawAddress(p0 context.Context, p1 string, p2 string) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) ClaimDeposit(p0 context.Context, p1 string, p2 string) (*types.DepositClaim, error) {
+	if s.Internal.ClaimDeposit == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.ClaimDeposit(p0, p1, p2)
+}
+
+func (s *UserAPIStub) ClaimDeposit(p0 context.Context, p1 string, p2 string) (*types.DepositClaim, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *UserAPIStruct) ConfirmWithdrawAddress(p0 context.Context, p1 string, p2 string) error {
+	if s.Internal.ConfirmWithdrawAddress == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ConfirmWithdrawAddress(p0, p1, p2)
+}
+
+func (s *UserAPIStub) ConfirmWithdrawAddress(p0 context.Context, p1 string, p2 string) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) GetAccountEmail(p0 context.Context) (string, error) {
+	if s.Internal.GetAccountEmail == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.GetAccountEmail(p0)
+}
+
+func (s *UserAPIStub) GetAccountEmail(p0 context.Context) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *UserAPIStruct) GetAccountStatement(p0 context.Context, p1 string, p2 string, p3 int64) (*types.AccountStatement, error) {
+	if s.Internal.GetAccountStatement == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetAccountStatement(p0, p1, p2, p3)
+}
+
+func (s *UserAPIStub) GetAccountStatement(p0 context.Context, p1 string, p2 string, p3 int64) (*types.AccountStatement, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *UserAPIStruct) GetBalance(p0 context.Context) (*types.UserInfo, error) {
 	if s.Internal.GetBalance == nil {
 		return nil, ErrNotSupported
@@ -691,6 +1247,17 @@ func (s *UserAPIStub) GetBalance(p0 context.Context) (*types.UserInfo, error) {
 	return nil, ErrNotSupported
 }
 
+func (s *UserAPIStruct) GetBillingInfo(p0 context.Context) (*types.BillingInfo, error) {
+	if s.Internal.GetBillingInfo == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetBillingInfo(p0)
+}
+
+func (s *UserAPIStub) GetBillingInfo(p0 context.Context) (*types.BillingInfo, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *UserAPIStruct) GetInstanceDetailsInfo(p0 context.Context, p1 string) (*types.InstanceDetails, error) {
 	if s.Internal.GetInstanceDetailsInfo == nil {
 		return nil, ErrNotSupported
@@ -702,14 +1269,25 @@ func (s *UserAPIStub) GetInstanceDetailsInfo(p0 context.Context, p1 string) (*ty
 	return nil, ErrNotSupported
 }
 
-func (s *UserAPIStruct) GetRechargeAddress(p0 context.Context) (string, error) {
+func (s *UserAPIStruct) GetInvoice(p0 context.Context, p1 int64) (*types.Invoice, error) {
+	if s.Internal.GetInvoice == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetInvoice(p0, p1)
+}
+
+func (s *UserAPIStub) GetInvoice(p0 context.Context, p1 int64) (*types.Invoice, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *UserAPIStruct) GetRechargeAddress(p0 context.Context, p1 string) (string, error) {
 	if s.Internal.GetRechargeAddress == nil {
 		return "", ErrNotSupported
 	}
-	return s.Internal.GetRechargeAddress(p0)
+	return s.Internal.GetRechargeAddress(p0, p1)
 }
 
-func (s *UserAPIStub) GetRechargeAddress(p0 context.Context) (string, error) {
+func (s *UserAPIStub) GetRechargeAddress(p0 context.Context, p1 string) (string, error) {
 	return "", ErrNotSupported
 }
 
@@ -724,6 +1302,17 @@ func (s *UserAPIStub) GetSignCode(p0 context.Context, p1 string) (string, error)
 	return "", ErrNotSupported
 }
 
+func (s *UserAPIStruct) GetUserDepositClaims(p0 context.Context, p1 int64, p2 int64) (*types.DepositClaimResponse, error) {
+	if s.Internal.GetUserDepositClaims == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetUserDepositClaims(p0, p1, p2)
+}
+
+func (s *UserAPIStub) GetUserDepositClaims(p0 context.Context, p1 int64, p2 int64) (*types.DepositClaimResponse, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *UserAPIStruct) GetUserInstanceRecords(p0 context.Context, p1 int64, p2 int64) (*types.GetInstanceResponse, error) {
 	if s.Internal.GetUserInstanceRecords == nil {
 		return nil, ErrNotSupported
@@ -735,6 +1324,17 @@ func (s *UserAPIStub) GetUserInstanceRecords(p0 context.Context, p1 int64, p2 in
 	return nil, ErrNotSupported
 }
 
+func (s *UserAPIStruct) GetUserInvoices(p0 context.Context, p1 int64, p2 int64) (*types.InvoiceResponse, error) {
+	if s.Internal.GetUserInvoices == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetUserInvoices(p0, p1, p2)
+}
+
+func (s *UserAPIStub) GetUserInvoices(p0 context.Context, p1 int64, p2 int64) (*types.InvoiceResponse, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *UserAPIStruct) GetUserRechargeRecords(p0 context.Context, p1 int64, p2 int64) (*types.RechargeResponse, error) {
 	if s.Internal.GetUserRechargeRecords == nil {
 		return nil, ErrNotSupported
@@ -746,6 +1346,17 @@ func (s *UserAPIStub) GetUserRechargeRecords(p0 context.Context, p1 int64, p2 in
 	return nil, ErrNotSupported
 }
 
+func (s *UserAPIStruct) GetUserRefundRecords(p0 context.Context, p1 int64, p2 int64) (*types.GetRefundResponse, error) {
+	if s.Internal.GetUserRefundRecords == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetUserRefundRecords(p0, p1, p2)
+}
+
+func (s *UserAPIStub) GetUserRefundRecords(p0 context.Context, p1 int64, p2 int64) (*types.GetRefundResponse, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *UserAPIStruct) GetUserWithdrawalRecords(p0 context.Context, p1 int64, p2 int64) (*types.GetWithdrawResponse, error) {
 	if s.Internal.GetUserWithdrawalRecords == nil {
 		return nil, ErrNotSupported
@@ -757,6 +1368,39 @@ func (s *UserAPIStub) GetUserWithdrawalRecords(p0 context.Context, p1 int64, p2
 	return nil, ErrNotSupported
 }
 
+func (s *UserAPIStruct) GetWithdrawAddressLogs(p0 context.Context, p1 int64, p2 int64) (*types.WithdrawAddressLogResponse, error) {
+	if s.Internal.GetWithdrawAddressLogs == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.GetWithdrawAddressLogs(p0, p1, p2)
+}
+
+func (s *UserAPIStub) GetWithdrawAddressLogs(p0 context.Context, p1 int64, p2 int64) (*types.WithdrawAddressLogResponse, error) {
+	return nil, ErrNotSupported
+}
+
+func (s *UserAPIStruct) GetWithdrawAddresses(p0 context.Context) ([]*types.WithdrawAddress, error) {
+	if s.Internal.GetWithdrawAddresses == nil {
+		return *new([]*types.WithdrawAddress), ErrNotSupported
+	}
+	return s.Internal.GetWithdrawAddresses(p0)
+}
+
+func (s *UserAPIStub) GetWithdrawAddresses(p0 context.Context) ([]*types.WithdrawAddress, error) {
+	return *new([]*types.WithdrawAddress), ErrNotSupported
+}
+
+func (s *UserAPIStruct) GetWithdrawWhitelistOnly(p0 context.Context) (bool, error) {
+	if s.Internal.GetWithdrawWhitelistOnly == nil {
+		return false, ErrNotSupported
+	}
+	return s.Internal.GetWithdrawWhitelistOnly(p0)
+}
+
+func (s *UserAPIStub) GetWithdrawWhitelistOnly(p0 context.Context) (bool, error) {
+	return false, ErrNotSupported
+}
+
 func (s *UserAPIStruct) Login(p0 context.Context, p1 *types.UserReq) (*types.LoginResponse, error) {
 	if s.Internal.Login == nil {
 		return nil, ErrNotSupported
@@ -779,6 +1423,17 @@ func (s *UserAPIStub) Logout(p0 context.Context, p1 *types.UserReq) error {
 	return ErrNotSupported
 }
 
+func (s *UserAPIStruct) PreviewWithdraw(p0 context.Context, p1 string) (*types.WithdrawPreview, error) {
+	if s.Internal.PreviewWithdraw == nil {
+		return nil, ErrNotSupported
+	}
+	return s.Internal.PreviewWithdraw(p0, p1)
+}
+
+func (s *UserAPIStub) PreviewWithdraw(p0 context.Context, p1 string) (*types.WithdrawPreview, error) {
+	return nil, ErrNotSupported
+}
+
 func (s *UserAPIStruct) RebootInstance(p0 context.Context, p1 string, p2 string) error {
 	if s.Internal.RebootInstance == nil {
 		return ErrNotSupported
@@ -790,6 +1445,105 @@ func (s *UserAPIStub) RebootInstance(p0 context.Context, p1 string, p2 string) e
 	return ErrNotSupported
 }
 
+func (s *UserAPIStruct) ReinstallInstance(p0 context.Context, p1 *types.ReinstallInstanceReq) error {
+	if s.Internal.ReinstallInstance == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.ReinstallInstance(p0, p1)
+}
+
+func (s *UserAPIStub) ReinstallInstance(p0 context.Context, p1 *types.ReinstallInstanceReq) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) RemoveWithdrawAddress(p0 context.Context, p1 string) error {
+	if s.Internal.RemoveWithdrawAddress == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.RemoveWithdrawAddress(p0, p1)
+}
+
+func (s *UserAPIStub) RemoveWithdrawAddress(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) RenderInvoice(p0 context.Context, p1 int64, p2 string) ([]byte, error) {
+	if s.Internal.RenderInvoice == nil {
+		return *new([]byte), ErrNotSupported
+	}
+	return s.Internal.RenderInvoice(p0, p1, p2)
+}
+
+func (s *UserAPIStub) RenderInvoice(p0 context.Context, p1 int64, p2 string) ([]byte, error) {
+	return *new([]byte), ErrNotSupported
+}
+
+func (s *UserAPIStruct) RequestInstanceRefund(p0 context.Context, p1 string, p2 string) (string, error) {
+	if s.Internal.RequestInstanceRefund == nil {
+		return "", ErrNotSupported
+	}
+	return s.Internal.RequestInstanceRefund(p0, p1, p2)
+}
+
+func (s *UserAPIStub) RequestInstanceRefund(p0 context.Context, p1 string, p2 string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (s *UserAPIStruct) SendAccountEmailCode(p0 context.Context, p1 string) error {
+	if s.Internal.SendAccountEmailCode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SendAccountEmailCode(p0, p1)
+}
+
+func (s *UserAPIStub) SendAccountEmailCode(p0 context.Context, p1 string) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) SendWithdrawWhitelistCode(p0 context.Context) error {
+	if s.Internal.SendWithdrawWhitelistCode == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SendWithdrawWhitelistCode(p0)
+}
+
+func (s *UserAPIStub) SendWithdrawWhitelistCode(p0 context.Context) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) SetAccountEmail(p0 context.Context, p1 string, p2 string, p3 string) error {
+	if s.Internal.SetAccountEmail == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SetAccountEmail(p0, p1, p2, p3)
+}
+
+func (s *UserAPIStub) SetAccountEmail(p0 context.Context, p1 string, p2 string, p3 string) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) SetBillingInfo(p0 context.Context, p1 *types.BillingInfo) error {
+	if s.Internal.SetBillingInfo == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SetBillingInfo(p0, p1)
+}
+
+func (s *UserAPIStub) SetBillingInfo(p0 context.Context, p1 *types.BillingInfo) error {
+	return ErrNotSupported
+}
+
+func (s *UserAPIStruct) SetWithdrawWhitelistOnly(p0 context.Context, p1 bool, p2 string) error {
+	if s.Internal.SetWithdrawWhitelistOnly == nil {
+		return ErrNotSupported
+	}
+	return s.Internal.SetWithdrawWhitelistOnly(p0, p1, p2)
+}
+
+func (s *UserAPIStub) SetWithdrawWhitelistOnly(p0 context.Context, p1 bool, p2 string) error {
+	return ErrNotSupported
+}
+
 func (s *UserAPIStruct) UpdateInstanceName(p0 context.Context, p1 string, p2 string) error {
 	if s.Internal.UpdateInstanceName == nil {
 		return ErrNotSupported
@@ -801,15 +1555,15 @@ func (s *UserAPIStub) UpdateInstanceName(p0 context.Context, p1 string, p2 strin
 	return ErrNotSupported
 }
 
-func (s *UserAPIStruct) Withdraw(p0 context.Context, p1 string, p2 string) error {
+func (s *UserAPIStruct) Withdraw(p0 context.Context, p1 string, p2 string) (string, error) {
 	if s.Internal.Withdraw == nil {
-		return ErrNotSupported
+		return "", ErrNotSupported
 	}
 	return s.Internal.Withdraw(p0, p1, p2)
 }
 
-func (s *UserAPIStub) Withdraw(p0 context.Context, p1 string, p2 string) error {
-	return ErrNotSupported
+func (s *UserAPIStub) Withdraw(p0 context.Context, p1 string, p2 string) (string, error) {
+	return "", ErrNotSupported
 }
 
 var _ AccountAPI = new(AccountAPIStruct)
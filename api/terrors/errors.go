@@ -25,6 +25,20 @@ const (
 	WithdrawAddrError                      // 提现地址不合法
 	AliApiGetFailed                        // 地区获取失败
 	ThisInstanceNotSupportOperation        // 地区获取失败
+	UserDataInvalid                        // 实例自定义数据不合法
+	RefundExists                           // 退款申请已存在
+	IdempotencyKeyConflict                 // 幂等键冲突
+	WithdrawBelowMinimum                   // 提现金额低于下限
+	WithdrawAboveMaximum                   // 提现金额超过上限
+	WithdrawDailyLimit                     // 超过24小时提现限额
+	WithdrawMonthlyLimit                   // 超过30天提现限额
+	WithdrawGlobalLimit                    // 超过平台每日提现限额
+	WithdrawCooldown                       // 安全冷却期内不能提现
+	WithdrawDepositHeld                    // 近期充值未消费不能提现
+	WithdrawSelfApproval                   // 不能审批自己的提现
+	WithdrawAddrNotWhitelisted             // 提现地址不在白名单或仍在锁定期
+	NotFoundEmail                          // 没有已验证的邮箱
+	DepositClaimLimit                      // 充值申诉过于频繁
 
 	Success = 0
 	Unknown = -1
@@ -76,6 +90,34 @@ func (e TError) String() string {
 		return "get info error,please retry"
 	case ThisInstanceNotSupportOperation:
 		return "this instance not support operation"
+	case UserDataInvalid:
+		return "user data is invalid"
+	case RefundExists:
+		return "refund request already exists"
+	case IdempotencyKeyConflict:
+		return "idempotency key is reused with different parameters"
+	case WithdrawBelowMinimum:
+		return "withdraw value is below the minimum"
+	case WithdrawAboveMaximum:
+		return "withdraw value is above the maximum"
+	case WithdrawDailyLimit:
+		return "withdraw exceeds the 24 hour limit"
+	case WithdrawMonthlyLimit:
+		return "withdraw exceeds the 30 day limit"
+	case WithdrawGlobalLimit:
+		return "withdraw exceeds the platform daily limit"
+	case WithdrawCooldown:
+		return "withdraw is not allowed during the security cooldown"
+	case WithdrawDepositHeld:
+		return "recent deposits can not be withdrawn before they are held long enough"
+	case WithdrawSelfApproval:
+		return "an admin can not approve their own withdrawal"
+	case WithdrawAddrNotWhitelisted:
+		return "withdraw address is not usable in the address book"
+	case NotFoundEmail:
+		return "no verified e-mail found, please set the account e-mail"
+	case DepositClaimLimit:
+		return "too many deposit claims, please try again later"
 	default:
 		return ""
 	}
@@ -73,6 +73,134 @@ const (
 	RenewVPS
 )
 
+// OrderItemState represents the fulfilment state of a single item of an order.
+type OrderItemState int64
+
+// Constants defining various states of an order item.
+const (
+	// OrderItemPending the item is waiting to be fulfilled
+	OrderItemPending OrderItemState = iota
+	// OrderItemSucceeded the item has been fulfilled
+	OrderItemSucceeded
+	// OrderItemFailed the item could not be fulfilled
+	OrderItemFailed
+)
+
+// String returns the string representation of the order item state.
+func (s OrderItemState) String() string {
+	switch s {
+	case OrderItemPending:
+		return "Pending"
+	case OrderItemSucceeded:
+		return "Succeeded"
+	case OrderItemFailed:
+		return "Failed"
+	}
+
+	return "Not found"
+}
+
+// OrderItem represents one instance bought or renewed by an order,
+// an order buying N instances has N items that are fulfilled and refunded separately.
+type OrderItem struct {
+	OrderID     string         `db:"order_id"`
+	ItemIndex   int64          `db:"item_index"`
+	VpsID       int64          `db:"vps_id"`
+	InstanceID  string         `db:"instance_id"`
+	Value       string         `db:"value"`
+	Refund      string         `db:"refund"`
+	USDRate     float32        `db:"usd_rate"`
+	State       OrderItemState `db:"state"`
+	Msg         string         `db:"msg"`
+	CreatedTime time.Time      `db:"created_time"`
+	DoneTime    time.Time      `db:"done_time"`
+}
+
+// OrderEvent represents a state transition of an order
+type OrderEvent struct {
+	ID          int64     `db:"id"`
+	OrderID     string    `db:"order_id"`
+	Event       string    `db:"event"`
+	FromState   string    `db:"from_state"`
+	ToState     string    `db:"to_state"`
+	Actor       string    `db:"actor"`
+	Msg         string    `db:"msg"`
+	CreatedTime time.Time `db:"created_time"`
+}
+
+// IdempotencyRecord represents the result of a request made with a client idempotency key
+type IdempotencyRecord struct {
+	UserID      string    `db:"user_id"`
+	IdemKey     string    `db:"idem_key"`
+	Method      string    `db:"method"`
+	RequestHash string    `db:"request_hash"`
+	Done        bool      `db:"done"`
+	Response    string    `db:"response"`
+	CreatedTime time.Time `db:"created_time"`
+}
+
+// BillingInfo company billing details printed on the invoices of a user
+type BillingInfo struct {
+	UserID      string `db:"user_id"`
+	CompanyName string `db:"company_name"`
+	TaxID       string `db:"tax_id"`
+	Address     string `db:"address"`
+	Country     string `db:"country"`
+	Email       string `db:"email"`
+}
+
+// InvoiceType invoice type
+type InvoiceType int64
+
+// Constants defining the types of invoice.
+const (
+	// InvoiceOrder invoice of a successful order
+	InvoiceOrder InvoiceType = iota
+	// InvoiceRefund credit note of a credited refund
+	InvoiceRefund
+)
+
+// String returns the string representation of the invoice type.
+func (t InvoiceType) String() string {
+	switch t {
+	case InvoiceOrder:
+		return "Invoice"
+	case InvoiceRefund:
+		return "Credit Note"
+	}
+
+	return "Not found"
+}
+
+// InvoiceLine represents a line of an invoice
+type InvoiceLine struct {
+	Description string
+	Quantity    int64
+	Amount      string
+}
+
+// Invoice represents an invoice, invoices are never modified once they are saved
+type Invoice struct {
+	InvoiceNo     int64          `db:"invoice_no"`
+	InvoiceType   InvoiceType    `db:"invoice_type"`
+	RefID         string         `db:"ref_id"`
+	UserID        string         `db:"user_id"`
+	Currency      string         `db:"currency"`
+	USDRate       float32        `db:"usd_rate"`
+	Total         string         `db:"total"`
+	BillingString string         `db:"billing_info"`
+	LinesString   string         `db:"line_items"`
+	CreatedTime   time.Time      `db:"created_time"`
+	Billing       *BillingInfo   `db:"-"`
+	Lines         []*InvoiceLine `db:"-"`
+}
+
+// InvoiceResponse invoices
+type InvoiceResponse struct {
+	Total int
+	List  []*Invoice
+}
+
 // User user info
 type User struct {
 	UUID      string    `db:"uuid" json:"uuid"`
@@ -110,6 +238,7 @@ type DescribePriceResponse struct {
 	OriginalPrice float32
 	TradePrice    float32
 	USDPrice      float32
+	USDRate       float32
 }
 
 type DescribeImageResponse struct {
@@ -275,6 +404,7 @@ type OrderRecord struct {
 	CycleTime   string         `db:"cycle_time"`
 	Expiration  time.Time      `db:"expiration"`
 	OrderType   OrderType      `db:"order_type"`
+	Amount      int64          `db:"amount"`
 }
 
 type OrderRecordResponse struct {
@@ -282,19 +412,53 @@ type OrderRecordResponse struct {
 	List  []*OrderRecord
 }
 
+// Chains of the deposit addresses
+const (
+	ChainTron = "tron"
+	ChainFvm  = "fvm"
+	// ChainStandIn is the chain of the simulated deposits of a stand-in chain
+	ChainStandIn = "standin"
+)
+
 // RechargeState Recharge order state
 type RechargeState int64
 
 // Constants defining various states of the recharge process.
 const (
-	// RechargeCreate Recharge create
+	// RechargeCreate Recharge create, the recharge is pending until its block is confirmed
 	RechargeCreate RechargeState = iota
 	// RechargeDone Recharge done
 	RechargeDone
 	// RechargeRefund Recharge Refund
 	RechargeRefund
+	// RechargeOrphaned the block of the recharge has been replaced and the transaction is no longer on chain
+	RechargeOrphaned
+	// RechargeReview the confirmed recharge is parked until an admin credits or rejects it
+	RechargeReview
+	// RechargeRejected the recharge has been rejected by an admin and is not credited
+	RechargeRejected
 )
 
+// String returns the string representation of the recharge state.
+func (s RechargeState) String() string {
+	switch s {
+	case RechargeCreate:
+		return "Pending"
+	case RechargeDone:
+		return "Done"
+	case RechargeRefund:
+		return "Refund"
+	case RechargeOrphaned:
+		return "Orphaned"
+	case RechargeReview:
+		return "Review"
+	case RechargeRejected:
+		return "Rejected"
+	}
+
+	return "Not found"
+}
+
 // WithdrawState Withdraw order state
 type WithdrawState int64
 
@@ -302,12 +466,37 @@ type WithdrawState int64
 const (
 	// WithdrawCreate Withdraw create
 	WithdrawCreate WithdrawState = iota
-	// WithdrawDone Withdraw done
+	// WithdrawDone Withdraw done, an executed payout is confirmed on chain
 	WithdrawDone
 	// WithdrawRefund Withdraw Refund
 	WithdrawRefund
+	// WithdrawApproved Withdraw approved and waiting for the payout executor
+	WithdrawApproved
+	// WithdrawBroadcast Withdraw payout broadcast and waiting for confirmation
+	WithdrawBroadcast
+	// WithdrawFailed Withdraw payout failed and needs manual handling
+	WithdrawFailed
 )
 
+func (w WithdrawState) String() string {
+	switch w {
+	case WithdrawCreate:
+		return "Create"
+	case WithdrawDone:
+		return "Done"
+	case WithdrawRefund:
+		return "Refund"
+	case WithdrawApproved:
+		return "Approved"
+	case WithdrawBroadcast:
+		return "Broadcast"
+	case WithdrawFailed:
+		return "Failed"
+	}
+
+	return "Not found"
+}
+
 type LoginType int64
 
 // Constants defining various states of the recharge process.
@@ -344,15 +533,89 @@ type RechargeResponse struct {
 // RechargeRecord represents information about an recharge record
 type RechargeRecord struct {
 	OrderID     string        `db:"order_id"`
+	Chain       string        `db:"chain"`
 	From        string        `db:"from_addr"`
 	UserID      string        `db:"user_id"`
 	To          string        `db:"to_addr"`
 	Value       string        `db:"value"`
 	State       RechargeState `db:"state"`
+	Token       string        `db:"token"`
+	RawValue    string        `db:"raw_value"`
+	Rate        string        `db:"rate"`
+	BlockNumber int64         `db:"block_number"`
+	BlockHash   string        `db:"block_hash"`
 	CreatedTime time.Time     `db:"created_time"`
 	DoneTime    time.Time     `db:"done_time"`
 }
 
+// DepositClaimState deposit claim state
+type DepositClaimState int64
+
+const (
+	// DepositClaimPending the claim is being verified on chain
+	DepositClaimPending DepositClaimState = iota
+	// DepositClaimAccepted the deposit is recorded and credited once its block is confirmed, or credited by an admin
+	DepositClaimAccepted
+	// DepositClaimReview the claim could not be verified and waits for an admin
+	DepositClaimReview
+	// DepositClaimRejected the claim is rejected by an admin
+	DepositClaimRejected
+)
+
+func (s DepositClaimState) String() string {
+	switch s {
+	case DepositClaimPending:
+		return "Pending"
+	case DepositClaimAccepted:
+		return "Accepted"
+	case DepositClaimReview:
+		return "Review"
+	case DepositClaimRejected:
+		return "Rejected"
+	}
+
+	return "Not found"
+}
+
+// DepositClaimReason is why a deposit claim is waiting for an admin
+type DepositClaimReason string
+
+const (
+	// ClaimReasonUnsupportedChain deposits of the chain are not verified on chain
+	ClaimReasonUnsupportedChain DepositClaimReason = "unsupported_chain"
+	// ClaimReasonVerifyError the claim could not be verified, e.g. the chain could not be queried
+	ClaimReasonVerifyError DepositClaimReason = "verify_error"
+	// ClaimReasonNotFound the transaction is not on chain
+	ClaimReasonNotFound DepositClaimReason = "not_found"
+	// ClaimReasonFailed the transaction did not succeed
+	ClaimReasonFailed DepositClaimReason = "tx_failed"
+	// ClaimReasonNotUserAddress the transaction pays none of the deposit addresses of the user,
+	// or pays less than the minimum deposit
+	ClaimReasonNotUserAddress DepositClaimReason = "not_user_address"
+	// ClaimReasonOtherUser the deposit is recorded for another user
+	ClaimReasonOtherUser DepositClaimReason = "other_user"
+)
+
+// DepositClaim is a deposit a user asks to be credited with its transaction hash
+type DepositClaim struct {
+	ClaimID     string             `db:"claim_id"`
+	UserID      string             `db:"user_id"`
+	Chain       string             `db:"chain"`
+	TxHash      string             `db:"tx_hash"`
+	State       DepositClaimState  `db:"state"`
+	Reason      DepositClaimReason `db:"reason"`
+	Msg         string             `db:"msg"`
+	AdminID     string             `db:"admin_id"`
+	CreatedTime time.Time          `db:"created_time"`
+	UpdatedTime time.Time          `db:"updated_time"`
+}
+
+// DepositClaimResponse deposit claims
+type DepositClaimResponse struct {
+	Total int
+	List  []*DepositClaim
+}
+
 type GetWithdrawRequest struct {
 	Limit     int64
 	Offset    int64
@@ -367,7 +630,9 @@ type GetWithdrawResponse struct {
 	List  []*WithdrawRecord
 }
 
-// WithdrawRecord represents information about an withdraw record
+// WithdrawRecord represents information about an withdraw record,
+// Value is held on the user balance, NetValue is paid out and Fee is charged.
+// It is paid out once it has the Required distinct admin approvals
 type WithdrawRecord struct {
 	OrderID      string        `db:"order_id"`
 	UserID       string        `db:"user_id"`
@@ -378,6 +643,266 @@ type WithdrawRecord struct {
 	WithdrawAddr string        `db:"withdraw_addr"`
 	WithdrawHash string        `db:"withdraw_hash"`
 	Executor     string        `db:"executor"`
+	Attempts     int64         `db:"attempts"`
+	TxExpiration int64         `db:"tx_expiration"`
+	Msg          string        `db:"msg"`
+	Fee          string        `db:"fee"`
+	NetValue     string        `db:"net_value"`
+	Approvals    int64         `db:"approvals"`
+	Required     int64         `db:"required_approvals"`
+	Creator      string        `db:"creator"`
+}
+
+// WithdrawAddressState withdraw address book entry state
+type WithdrawAddressState int64
+
+const (
+	// WithdrawAddressPending the address waits for the e-mail confirmation
+	WithdrawAddressPending WithdrawAddressState = iota
+	// WithdrawAddressConfirmed the address is confirmed and usable after its lock period
+	WithdrawAddressConfirmed
+)
+
+func (w WithdrawAddressState) String() string {
+	switch w {
+	case WithdrawAddressPending:
+		return "Pending"
+	case WithdrawAddressConfirmed:
+		return "Confirmed"
+	}
+
+	return "Not found"
+}
+
+// WithdrawAddress is an address in the withdrawal address book of a user,
+// it can be withdrawn to from UsableTime on
+type WithdrawAddress struct {
+	UserID      string               `db:"user_id"`
+	Addr        string               `db:"addr"`
+	Label       string               `db:"label"`
+	State       WithdrawAddressState `db:"state"`
+	CreatedTime time.Time            `db:"created_time"`
+	UsableTime  time.Time            `db:"usable_time"`
+}
+
+// WithdrawAddressLog is a change of the withdrawal address book of a user
+type WithdrawAddressLog struct {
+	ID          int64     `db:"id"`
+	UserID      string    `db:"user_id"`
+	Addr        string    `db:"addr"`
+	Action      string    `db:"action"`
+	Label       string    `db:"label"`
+	CreatedTime time.Time `db:"created_time"`
+}
+
+// WithdrawAddressLogResponse withdraw address log list
+type WithdrawAddressLogResponse struct {
+	Total int
+	List  []*WithdrawAddressLog
+}
+
+// Withdraw address book actions
+const (
+	WithdrawAddressAdd           = "add"
+	WithdrawAddressConfirm       = "confirm"
+	WithdrawAddressRemove        = "remove"
+	WithdrawWhitelistOnlyEnable  = "whitelist_on"
+	WithdrawWhitelistOnlyDisable = "whitelist_off"
+	WithdrawEmailChange          = "email"
+)
+
+// ApprovalDecision is the decision of an admin on a withdrawal
+type ApprovalDecision int64
+
+const (
+	// ApprovalApprove the admin approved the withdrawal
+	ApprovalApprove ApprovalDecision = iota
+	// ApprovalReject the admin rejected the withdrawal
+	ApprovalReject
+)
+
+func (a ApprovalDecision) String() string {
+	switch a {
+	case ApprovalApprove:
+		return "Approve"
+	case ApprovalReject:
+		return "Reject"
+	}
+
+	return "Not found"
+}
+
+// WithdrawApproval is the decision of an admin on a withdrawal
+type WithdrawApproval struct {
+	OrderID     string           `db:"order_id"`
+	AdminID     string           `db:"admin_id"`
+	Decision    ApprovalDecision `db:"decision"`
+	CreatedTime time.Time        `db:"created_time"`
+}
+
+// PayoutValue returns the value paid out, the records saved before fees existed pay out their whole value.
+func (w *WithdrawRecord) PayoutValue() string {
+	if w.NetValue == "" {
+		return w.Value
+	}
+
+	return w.NetValue
+}
+
+// WithdrawPreview is the fee and the paid out value of a withdrawal before it is created
+type WithdrawPreview struct {
+	Value    string
+	Fee      string
+	NetValue string
+}
+
+// WithdrawPolicy is the risk policy checked when a withdrawal is created,
+// an empty limit or a zero duration disables its rule
+type WithdrawPolicy struct {
+	MinValue string `db:"min_value"`
+	MaxValue string `db:"max_value"`
+	// rolling 24 hour and 30 day limits of a user
+	DailyLimit   string `db:"daily_limit"`
+	MonthlyLimit string `db:"monthly_limit"`
+	// rolling 24 hour limit of all users
+	GlobalDailyLimit string `db:"global_daily_limit"`
+	// seconds after a login and after an e-mail change during which a user can not withdraw
+	LoginCooldown      int64 `db:"login_cooldown"`
+	CredentialCooldown int64 `db:"credential_cooldown"`
+	// hours during which a deposit that was not spent can not be withdrawn
+	DepositHoldHours int64     `db:"deposit_hold_hours"`
+	UpdatedTime      time.Time `db:"updated_time"`
+}
+
+// SecurityEvent is a user event starting a withdrawal cooldown
+type SecurityEvent string
+
+const (
+	// SecurityEventLogin the user logged in
+	SecurityEventLogin SecurityEvent = "login"
+	// SecurityEventEmail the user changed the account e-mail the verify codes are mailed to, the users have no password
+	SecurityEventEmail SecurityEvent = "email"
+)
+
+// RefundState Instance refund state
+type RefundState int64
+
+// Constants defining various states of the instance refund process.
+const (
+	// RefundRequested the user has requested a refund
+	RefundRequested RefundState = iota
+	// RefundApproved the refund has been approved by an admin
+	RefundApproved
+	// RefundProviderRefunded the instance has been refunded by the cloud provider
+	RefundProviderRefunded
+	// RefundCredited the refund has been credited to the user balance
+	RefundCredited
+	// RefundRejected the refund has been rejected by an admin
+	RefundRejected
+	// RefundProviderRefunding the instance refund has been sent to the cloud provider,
+	// a refund left in this state is checked at the provider by an admin before it is resumed
+	RefundProviderRefunding
+)
+
+// String returns the string representation of the refund state.
+func (s RefundState) String() string {
+	switch s {
+	case RefundRequested:
+		return "Requested"
+	case RefundApproved:
+		return "Approved"
+	case RefundProviderRefunded:
+		return "ProviderRefunded"
+	case RefundCredited:
+		return "Credited"
+	case RefundRejected:
+		return "Rejected"
+	case RefundProviderRefunding:
+		return "ProviderRefunding"
+	}
+
+	return "Not found"
+}
+
+// RefundRecord represents information about an instance refund
+type RefundRecord struct {
+	RefundID        string      `db:"refund_id"`
+	UserID          string      `db:"user_id"`
+	InstanceID      string      `db:"instance_id"`
+	VpsID           int64       `db:"vps_id"`
+	Reason          string      `db:"reason"`
+	State           RefundState `db:"state"`
+	ProviderAmount  float64     `db:"provider_amount"`
+	ProviderOrderID int64       `db:"provider_order_id"`
+	USDRate         float32     `db:"usd_rate"`
+	Value           string      `db:"value"`
+	Executor        string      `db:"executor"`
+	Msg             string      `db:"msg"`
+	CreatedTime     time.Time   `db:"created_time"`
+	DoneTime        time.Time   `db:"done_time"`
+}
+
+// SweepState state of a deposit address sweep
+type SweepState int64
+
+// Constants defining the states of a deposit address sweep.
+const (
+	// SweepTopUp TRX is sent to the deposit address for the energy of the token transfer
+	SweepTopUp SweepState = iota
+	// SweepSent the token transfer to the collection address is sent
+	SweepSent
+	// SweepDone the token transfer is confirmed
+	SweepDone
+	// SweepFailed the top up or the token transfer failed
+	SweepFailed
+)
+
+// String returns the string representation of the sweep state.
+func (s SweepState) String() string {
+	switch s {
+	case SweepTopUp:
+		return "TopUp"
+	case SweepSent:
+		return "Sent"
+	case SweepDone:
+		return "Done"
+	case SweepFailed:
+		return "Failed"
+	}
+
+	return "Not found"
+}
+
+// SweepRecord is a transfer of the tokens of a deposit address to the collection address
+type SweepRecord struct {
+	ID           int64      `db:"id"`
+	Addr         string     `db:"addr"`
+	ContractAddr string     `db:"contract_addr"`
+	Amount       string     `db:"amount"`
+	TopUpHash    string     `db:"top_up_hash"`
+	TxHash       string     `db:"tx_hash"`
+	State        SweepState `db:"state"`
+	Msg          string     `db:"msg"`
+	CreatedTime  time.Time  `db:"created_time"`
+	DoneTime     time.Time  `db:"done_time"`
+}
+
+// SweepRecordResponse sweep records
+type SweepRecordResponse struct {
+	Total int
+	List  []*SweepRecord
+}
+
+// DerivedAddress is a deposit address derived at an index
+type DerivedAddress struct {
+	Addr  string `db:"addr"`
+	Index int64  `db:"derive_index"`
+}
+
+// GetRefundResponse refund records
+type GetRefundResponse struct {
+	Total int
+	List  []*RefundRecord
 }
 
 type PaymentCompletedReq struct {
@@ -416,6 +941,8 @@ const (
 	EventFvmTransferWatch EventTopics = "fvm_transfer_watch"
 	// EventTronTransferWatch node online event
 	EventTronTransferWatch EventTopics = "tron_transfer_watch"
+	// EventEvmTransferWatch ERC-20 transfer to a deposit address of an EVM chain
+	EventEvmTransferWatch EventTopics = "evm_transfer_watch"
 )
 
 func (t EventTopics) String() string {
@@ -423,19 +950,45 @@ func (t EventTopics) String() string {
 }
 
 type FvmTransferWatch struct {
-	TxHash string
-	From   string
-	To     string
-	Value  string
+	TxHash      string
+	LogIndex    uint
+	From        string
+	To          string
+	Value       string
+	UserID      string
+	Token       string
+	RawValue    string
+	BlockNumber int64
+	BlockHash   string
+}
+
+// EvmTransferWatch is a confirmed ERC-20 transfer to a deposit address of an EVM chain
+type EvmTransferWatch struct {
+	Chain       string
+	TxHash      string
+	LogIndex    uint
+	From        string
+	To          string
+	Value       string
+	UserID      string
+	Token       string
+	RawValue    string
+	BlockNumber int64
+	BlockHash   string
 }
 
 type TronTransferWatch struct {
-	TxHash string
-	From   string
-	To     string
-	Value  string
-	State  core.Transaction_ResultContractResult
-	UserID string
+	TxHash      string
+	From        string
+	To          string
+	Value       string
+	State       core.Transaction_ResultContractResult
+	UserID      string
+	Token       string
+	RawValue    string
+	Rate        string
+	BlockNumber int64
+	BlockHash   string
 }
 
 type RechargeAddress struct {
@@ -487,9 +1040,43 @@ type InstanceDetails struct {
 	State              string    `db:"state"`
 	Renew              string    `db:"renew"`
 	DataDisk           []DescribePriceRequestDataDisk
-	Executor           string    `db:"executor"`
-	RefundTime         string    `db:"refund_time"`
-	UpdateTime         time.Time `db:"update_time"`
+	Executor           string         `db:"executor"`
+	RefundTime         string         `db:"refund_time"`
+	UpdateTime         time.Time      `db:"update_time"`
+	UserData           string         `db:"user_data"`
+	ReinstallState     ReinstallState `db:"reinstall_state"`
+	ReinstallMsg       string         `db:"reinstall_msg"`
+}
+
+// ReinstallState state of the last reinstall of an instance
+type ReinstallState int64
+
+// Constants defining the states of an instance reinstall.
+const (
+	// ReinstallNone the instance has not been reinstalled
+	ReinstallNone ReinstallState = iota
+	// ReinstallRunning the system disk of the instance is being replaced
+	ReinstallRunning
+	// ReinstallDone the system disk has been replaced and the instance started
+	ReinstallDone
+	// ReinstallFailed the reinstall stopped on an error, the instance may be left stopped
+	ReinstallFailed
+)
+
+// String returns the string representation of the reinstall state.
+func (s ReinstallState) String() string {
+	switch s {
+	case ReinstallNone:
+		return "None"
+	case ReinstallRunning:
+		return "Running"
+	case ReinstallDone:
+		return "Done"
+	case ReinstallFailed:
+		return "Failed"
+	}
+
+	return "Not found"
 }
 
 type CreateInstanceReq struct {
@@ -506,10 +1093,21 @@ type CreateInstanceReq struct {
 	DataDisk                []DescribePriceRequestDataDisk
 	InstanceChargeType      string `db:"instance_charge_type"`
 	Renew                   int    `db:"renew"`
+	// UserData is a shell script or cloud-config document executed on first boot
+	UserData string `db:"user_data"`
 
 	SecurityGroupID string `db:"security_group_id"`
 }
 
+// ReinstallInstanceReq represents a request to reinstall the system disk of an instance
+type ReinstallInstanceReq struct {
+	InstanceID string
+	// ImageID is optional, the current image is used when it is empty
+	ImageID string
+	// UserData is optional, the user data stored with the instance is reused when it is empty
+	UserData string
+}
+
 type GetRechargeAddressResponse struct {
 	Total int
 	List  []*RechargeAddress
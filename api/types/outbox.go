@@ -0,0 +1,72 @@
+package types
+
+import "time"
+
+const (
+	// OutboxTopicOrder state transition of an order, its payload is an OrderEvent
+	OutboxTopicOrder = "order"
+)
+
+// OutboxEvent is an event saved in the same database transaction as the change it reports,
+// it is delivered at least once to every consumer of its topic.
+type OutboxEvent struct {
+	ID          int64     `db:"id"`
+	Topic       string    `db:"topic"`
+	EventKey    string    `db:"event_key"`
+	Payload     string    `db:"payload"`
+	CreatedTime time.Time `db:"created_time"`
+}
+
+// OutboxEventsResponse is a page of outbox events, Next is the id the following page starts from
+// and Now is the time of the outbox database before the page was listed
+type OutboxEventsResponse struct {
+	Next int64
+	List []*OutboxEvent
+	Now  time.Time
+}
+
+// OutboxDeliveryState is the state of an event whose handling failed for a consumer
+type OutboxDeliveryState int64
+
+const (
+	// OutboxDeliveryRetry the event is handed to the consumer again after a backoff
+	OutboxDeliveryRetry OutboxDeliveryState = iota
+	// OutboxDeliveryDead the event used all the attempts and waits for an admin
+	OutboxDeliveryDead
+	// OutboxDeliveryDone the event was handled by a retry
+	OutboxDeliveryDone
+)
+
+func (s OutboxDeliveryState) String() string {
+	switch s {
+	case OutboxDeliveryRetry:
+		return "Retry"
+	case OutboxDeliveryDead:
+		return "Dead"
+	case OutboxDeliveryDone:
+		return "Done"
+	default:
+		return "Unknown"
+	}
+}
+
+// OutboxDelivery is an event whose handling failed for a consumer, the event is copied
+// so that it is retried without its outbox.
+type OutboxDelivery struct {
+	Consumer    string              `db:"consumer"`
+	EventID     int64               `db:"event_id"`
+	Topic       string              `db:"topic"`
+	Payload     string              `db:"payload"`
+	State       OutboxDeliveryState `db:"state"`
+	Attempts    int64               `db:"attempts"`
+	LastErr     string              `db:"last_err"`
+	NextTime    time.Time           `db:"next_time"`
+	CreatedTime time.Time           `db:"created_time"`
+	UpdatedTime time.Time           `db:"updated_time"`
+}
+
+// OutboxDeliveryResponse is a page of failed deliveries
+type OutboxDeliveryResponse struct {
+	Total int
+	List  []*OutboxDelivery
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type Group string
 
 type Template struct {
@@ -7,3 +9,88 @@ type Template struct {
 	Name     string
 	Describe string
 }
+
+// ChainInfo describes the chains served by the transaction node
+type ChainInfo struct {
+	// the chain is a local stand-in, deposits are simulated
+	StandIn   bool
+	EvmChains []string
+	// the approved withdrawals are paid out by the transaction node
+	WithdrawEnabled  bool
+	WithdrawContract string
+	// id of the database of the transaction node, the mall refuses a transaction node on another database
+	DatabaseID string
+}
+
+// ChainEventType is the type of a chain event, it is the topic of the event in the outbox
+type ChainEventType string
+
+const (
+	// ChainEventTronTransfer transfer to a tron deposit address, it is credited once its block is confirmed
+	ChainEventTronTransfer ChainEventType = "tron_transfer"
+	// ChainEventTronDeposit the block of a tron deposit is replaced, confirmed or lost
+	ChainEventTronDeposit ChainEventType = "tron_deposit"
+	// ChainEventFvmTransfer confirmed transfer to a FEVM deposit address
+	ChainEventFvmTransfer ChainEventType = "fvm_transfer"
+	// ChainEventEvmTransfer confirmed ERC-20 transfer to a deposit address of an EVM chain
+	ChainEventEvmTransfer ChainEventType = "evm_transfer"
+	// ChainEventPayout progress of the payout of a withdrawal
+	ChainEventPayout ChainEventType = "payout"
+)
+
+// ChainEvent is the payload of a deposit or payout event of the transaction node, the field of its type is set
+type ChainEvent struct {
+	Type         ChainEventType
+	TronTransfer *TronTransferWatch `json:",omitempty"`
+	TronDeposit  *TronDeposit       `json:",omitempty"`
+	FvmTransfer  *FvmTransferWatch  `json:",omitempty"`
+	EvmTransfer  *EvmTransferWatch  `json:",omitempty"`
+	Payout       *WithdrawPayout    `json:",omitempty"`
+}
+
+// WithdrawPayout is the state of the payout transaction of a withdrawal, a payout with more attempts is newer.
+type WithdrawPayout struct {
+	OrderID      string
+	TxHash       string
+	State        WithdrawState
+	Msg          string
+	Attempts     int64
+	TxExpiration int64
+}
+
+// TronDeposit is a tron deposit tracked by the transaction node until its block is confirmed,
+// the state is the state of its recharge record.
+type TronDeposit struct {
+	TxHash      string        `db:"tx_hash"`
+	Token       string        `db:"token"`
+	BlockNumber int64         `db:"block_number"`
+	BlockHash   string        `db:"block_hash"`
+	State       RechargeState `db:"state"`
+	CreatedTime time.Time     `db:"created_time"`
+	UpdatedTime time.Time     `db:"updated_time"`
+}
+
+// Payout is the payout of an approved withdrawal by the transaction node, the attempts are never reset
+// and BaseAttempts are the attempts made before it was last retried by an admin.
+type Payout struct {
+	OrderID      string        `db:"order_id"`
+	WithdrawAddr string        `db:"withdraw_addr"`
+	Value        string        `db:"value"`
+	State        WithdrawState `db:"state"`
+	TxHash       string        `db:"tx_hash"`
+	TxExpiration int64         `db:"tx_expiration"`
+	Attempts     int64         `db:"attempts"`
+	BaseAttempts int64         `db:"base_attempts"`
+	Msg          string        `db:"msg"`
+	CreatedTime  time.Time     `db:"created_time"`
+	UpdatedTime  time.Time     `db:"updated_time"`
+}
+
+// TronDeposits are the transfers of a tron transaction to the deposit addresses
+type TronDeposits struct {
+	// the transaction is on chain
+	Found bool
+	// the transaction succeeded
+	Success bool
+	List    []*TronTransferWatch
+}
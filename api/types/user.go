@@ -1,8 +1,126 @@
 package types
 
-// UserInfo represents information about an user
+import "time"
+
+// UserInfo represents information about an user,
+// Balance is the available balance and LockedBalance the balance held by pending orders and withdrawals
 type UserInfo struct {
 	UserID        string `db:"user_id"`
 	Balance       string `db:"balance"`
 	LockedBalance string
+	TotalBalance  string
+}
+
+// LedgerAccount is an account of the double-entry ledger
+type LedgerAccount string
+
+const (
+	// LedgerUser the balance of a user
+	LedgerUser LedgerAccount = "user"
+	// LedgerRevenue the platform revenue of the paid orders
+	LedgerRevenue LedgerAccount = "revenue"
+	// LedgerDeposits the funds deposited by the users
+	LedgerDeposits LedgerAccount = "deposits"
+	// LedgerWithdrawals the funds withdrawn by the users
+	LedgerWithdrawals LedgerAccount = "withdrawals"
+	// LedgerRefunds the funds refunded to the users
+	LedgerRefunds LedgerAccount = "refunds"
+	// LedgerHeld the balance of a user held by pending orders and withdrawals
+	LedgerHeld LedgerAccount = "held"
+	// LedgerFees the fees charged on the withdrawals
+	LedgerFees LedgerAccount = "fees"
+)
+
+// LedgerRefType is the type of the business record a journal is posted for
+type LedgerRefType string
+
+const (
+	// LedgerRefOpening the opening balance of a user created before the ledger
+	LedgerRefOpening LedgerRefType = "opening"
+	// LedgerRefRecharge a recharge record
+	LedgerRefRecharge LedgerRefType = "recharge"
+	// LedgerRefWithdraw a withdraw record
+	LedgerRefWithdraw LedgerRefType = "withdraw"
+	// LedgerRefWithdrawReject a rejected withdraw record
+	LedgerRefWithdrawReject LedgerRefType = "withdraw_reject"
+	// LedgerRefOrder the payment of an order
+	LedgerRefOrder LedgerRefType = "order"
+	// LedgerRefOrderRefund the refund of the unfulfilled items of an order
+	LedgerRefOrderRefund LedgerRefType = "order_refund"
+	// LedgerRefInstanceRefund a refund record of an instance
+	LedgerRefInstanceRefund LedgerRefType = "instance_refund"
+)
+
+// LedgerEntry is a signed entry of the ledger, the entries of a journal sum to zero
+type LedgerEntry struct {
+	ID          int64         `db:"id"`
+	TxID        string        `db:"tx_id"`
+	Account     LedgerAccount `db:"account"`
+	UserID      string        `db:"user_id"`
+	Amount      string        `db:"amount"`
+	RefType     LedgerRefType `db:"ref_type"`
+	RefID       string        `db:"ref_id"`
+	CreatedTime time.Time     `db:"created_time"`
+}
+
+// HoldState balance hold state
+type HoldState int64
+
+// Constants defining various states of a balance hold.
+const (
+	// HoldPlaced the balance is held
+	HoldPlaced HoldState = iota
+	// HoldCaptured the held balance has been captured, the part that was not captured is released
+	HoldCaptured
+	// HoldReleased the held balance has been released to the user
+	HoldReleased
+)
+
+// String returns the string representation of the hold state.
+func (s HoldState) String() string {
+	switch s {
+	case HoldPlaced:
+		return "Placed"
+	case HoldCaptured:
+		return "Captured"
+	case HoldReleased:
+		return "Released"
+	}
+
+	return "Not found"
+}
+
+// BalanceHold is the balance held for a pending order or withdrawal
+type BalanceHold struct {
+	RefType     LedgerRefType `db:"ref_type"`
+	RefID       string        `db:"ref_id"`
+	UserID      string        `db:"user_id"`
+	Amount      string        `db:"amount"`
+	Captured    string        `db:"captured"`
+	State       HoldState     `db:"state"`
+	CreatedTime time.Time     `db:"created_time"`
+	DoneTime    time.Time     `db:"done_time"`
+}
+
+// StatementEntry is a line of an account statement
+type StatementEntry struct {
+	ID      int64
+	Time    time.Time
+	Type    string
+	RefType LedgerRefType
+	RefID   string
+	Amount  string
+	Balance string
+}
+
+// AccountStatement lists the changes of a user balance in a time range with the running balance,
+// NextCursor is passed to load the next page and is 0 on the last page
+type AccountStatement struct {
+	UserID         string
+	From           string
+	To             string
+	OpeningBalance string
+	ClosingBalance string
+	List           []*StatementEntry
+	NextCursor     int64
 }
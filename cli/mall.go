@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/LMF709268224/titan-vps/api"
 	"github.com/LMF709268224/titan-vps/api/types"
@@ -21,11 +22,21 @@ var adminCmds = &cli.Command{
 	Usage: "Manage admin",
 	Subcommands: []*cli.Command{
 		createAdminCmd,
+		createWithdrawalCmd,
 		approveWithdrawalCmd,
 		rejectWithdrawalCmd,
+		retryWithdrawalCmd,
 		getWithdrawalCmd,
 		getAddressesCmd,
 		supplementRechargeCmd,
+		approveRefundCmd,
+		rejectRefundCmd,
+		deadLettersCmd,
+		redriveCmd,
+		depositClaimsCmd,
+		recheckClaimCmd,
+		acceptClaimCmd,
+		rejectClaimCmd,
 	},
 }
 
@@ -43,6 +54,7 @@ var vpsCmds = &cli.Command{
 		GetInstanceDefaultCmd,
 		GetInstanceCpuCmd,
 		GetInstanceMemoryCmd,
+		reinstallInstanceCmd,
 	},
 }
 
@@ -54,6 +66,8 @@ var orderCmds = &cli.Command{
 		cancelOrderCmd,
 		paymentCompletedCmd,
 		listCmd,
+		orderItemsCmd,
+		orderTimelineCmd,
 	},
 }
 
@@ -63,7 +77,14 @@ var userCmds = &cli.Command{
 	Subcommands: []*cli.Command{
 		getBalanceCmd,
 		getRechargeAddrCmd,
+		claimDepositCmd,
+		userClaimsCmd,
 		withdrawCmd,
+		requestRefundCmd,
+		setBillingInfoCmd,
+		listInvoicesCmd,
+		downloadInvoiceCmd,
+		accountStatementCmd,
 	},
 }
 
@@ -453,7 +474,23 @@ var describeInstanceTypeCmd = &cli.Command{
 var createOrderCmd = &cli.Command{
 	Name:  "create",
 	Usage: "create order",
-	Flags: []cli.Flag{},
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "idempotency-key",
+			Usage: "client idempotency key, replaying a key returns the result of the first request",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "user-data",
+			Usage: "path of a user-data script or cloud-config file",
+			Value: "",
+		},
+		&cli.IntFlag{
+			Name:  "amount",
+			Usage: "number of instances",
+			Value: 1,
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		ctx := ReqContext(cctx)
 
@@ -464,6 +501,11 @@ var createOrderCmd = &cli.Command{
 
 		defer closer()
 
+		userData, err := readUserData(cctx.String("user-data"))
+		if err != nil {
+			return err
+		}
+
 		address, err := api.CreateOrder(ctx, types.CreateOrderReq{
 			CreateInstanceReq: types.CreateInstanceReq{
 				RegionId:                "cn-qingdao",
@@ -475,8 +517,9 @@ var createOrderCmd = &cli.Command{
 				InternetMaxBandwidthOut: 1,
 				SystemDiskCategory:      "cloud_efficiency",
 				SystemDiskSize:          40,
+				UserData:                userData,
 			},
-			Amount: 1,
+			Amount: int32(cctx.Int("amount")),
 		})
 		if err != nil {
 			return err
@@ -487,6 +530,62 @@ var createOrderCmd = &cli.Command{
 	},
 }
 
+var reinstallInstanceCmd = &cli.Command{
+	Name:  "reinstall",
+	Usage: "reinstall instance",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "instanceID",
+			Usage: "instance id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "image",
+			Usage: "image id, the current image is used when empty",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "user-data",
+			Usage: "path of a user-data script or cloud-config file, the stored user data is used when empty",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		userData, err := readUserData(cctx.String("user-data"))
+		if err != nil {
+			return err
+		}
+
+		return api.ReinstallInstance(ctx, &types.ReinstallInstanceReq{
+			InstanceID: cctx.String("instanceID"),
+			ImageID:    cctx.String("image"),
+			UserData:   userData,
+		})
+	},
+}
+
+// readUserData reads the user data file, an empty path means no user data
+func readUserData(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
 var cancelOrderCmd = &cli.Command{
 	Name:  "cancel",
 	Usage: "cancel order",
@@ -512,10 +611,79 @@ var cancelOrderCmd = &cli.Command{
 	},
 }
 
+var orderItemsCmd = &cli.Command{
+	Name:  "items",
+	Usage: "list the items of order",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "oid",
+			Usage: "order id",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		items, err := api.GetUserOrderItems(ctx, cctx.String("oid"))
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			fmt.Printf("%d vps:%d instance:%s value:%s refund:%s state:%s msg:%s \n", item.ItemIndex, item.VpsID, item.InstanceID, item.Value, item.Refund, item.State.String(), item.Msg)
+		}
+
+		return nil
+	},
+}
+
+var orderTimelineCmd = &cli.Command{
+	Name:  "timeline",
+	Usage: "show the state transitions of order",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "oid",
+			Usage: "order id",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		events, err := api.GetOrderTimeline(ctx, cctx.String("oid"))
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			fmt.Printf("%s %s %s -> %s actor:%s msg:%s \n", event.CreatedTime.Format("2006-01-02 15:04:05"), event.Event, event.FromState, event.ToState, event.Actor, event.Msg)
+		}
+
+		return nil
+	},
+}
+
 var paymentCompletedCmd = &cli.Command{
 	Name:  "payment",
 	Usage: "payment order",
 	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "idempotency-key",
+			Usage: "client idempotency key, replaying a key returns the result of the first request",
+			Value: "",
+		},
 		&cli.StringFlag{
 			Name:  "oid",
 			Usage: "order id",
@@ -594,7 +762,13 @@ var getBalanceCmd = &cli.Command{
 var getRechargeAddrCmd = &cli.Command{
 	Name:  "gra",
 	Usage: "get recharge address",
-	Flags: []cli.Flag{},
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "chain",
+			Usage: "deposit chain: tron, fvm or a configured evm chain",
+			Value: "tron",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		ctx := ReqContext(cctx)
 
@@ -605,7 +779,7 @@ var getRechargeAddrCmd = &cli.Command{
 
 		defer closer()
 
-		str, err := api.GetRechargeAddress(ctx)
+		str, err := api.GetRechargeAddress(ctx, cctx.String("chain"))
 		if err != nil {
 			return err
 		}
@@ -619,6 +793,11 @@ var withdrawCmd = &cli.Command{
 	Name:  "withdraw",
 	Usage: "withdraw",
 	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "idempotency-key",
+			Usage: "client idempotency key, replaying a key returns the result of the first request",
+			Value: "",
+		},
 		&cli.StringFlag{
 			Name:  "addr",
 			Usage: "user withdraw address",
@@ -643,14 +822,57 @@ var withdrawCmd = &cli.Command{
 		withdrawAddr := cctx.String("addr")
 		value := cctx.String("value")
 
-		err = bApi.Withdraw(ctx, withdrawAddr, value)
-		if webErr, ok := err.(*api.ErrWeb); ok {
-			fmt.Printf("web error code %d,message:%s \n", webErr.Code, webErr.Message)
-		} else {
-			fmt.Printf("web error message:%v \n", err)
+		orderID, err := bApi.Withdraw(ctx, withdrawAddr, value)
+		if err != nil {
+			if webErr, ok := err.(*api.ErrWeb); ok {
+				fmt.Printf("web error code %d,message:%s \n", webErr.Code, webErr.Message)
+			} else {
+				fmt.Printf("web error message:%v \n", err)
+			}
+			return err
+		}
+
+		fmt.Println(orderID)
+		return nil
+	},
+}
+
+var createWithdrawalCmd = &cli.Command{
+	Name:  "cw",
+	Usage: "create manual withdrawal for user",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "user",
+			Usage: "user id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "addr",
+			Usage: "user withdraw address",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "withdraw value",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		orderID, err := api.CreateUserWithdrawal(ctx, cctx.String("user"), cctx.String("addr"), cctx.String("value"))
+		if err != nil {
+			return err
 		}
 
-		return err
+		fmt.Println(orderID)
+		return nil
 	},
 }
 
@@ -710,6 +932,560 @@ var rejectWithdrawalCmd = &cli.Command{
 	},
 }
 
+var retryWithdrawalCmd = &cli.Command{
+	Name:  "retryw",
+	Usage: "retry failed withdrawal payout",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "oid",
+			Usage: "order id",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		oid := cctx.String("oid")
+
+		return api.RetryUserWithdrawal(ctx, oid)
+	},
+}
+
+var deadLettersCmd = &cli.Command{
+	Name:  "dead",
+	Usage: "list the outbox events a consumer failed to handle",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "consumer",
+			Usage: "consumer, all the consumers if empty",
+			Value: "",
+		},
+		&cli.Int64Flag{
+			Name:  "limit",
+			Usage: "limit",
+			Value: 100,
+		},
+		&cli.Int64Flag{
+			Name:  "page",
+			Usage: "page",
+			Value: 0,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		out, err := api.GetOutboxDeliveries(ctx, cctx.String("consumer"), types.OutboxDeliveryDead, cctx.Int64("limit"), cctx.Int64("page"))
+		if err != nil {
+			return err
+		}
+
+		for _, info := range out.List {
+			fmt.Printf("%s %d %s attempts:%d err:%s \n", info.Consumer, info.EventID, info.Topic, info.Attempts, info.LastErr)
+		}
+
+		return nil
+	},
+}
+
+var redriveCmd = &cli.Command{
+	Name:  "redrive",
+	Usage: "hand a dead-lettered outbox event to its consumer again",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "consumer",
+			Usage: "consumer",
+			Value: "",
+		},
+		&cli.Int64Flag{
+			Name:  "id",
+			Usage: "event id",
+			Value: 0,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.RedriveOutboxDelivery(ctx, cctx.String("consumer"), cctx.Int64("id"))
+	},
+}
+
+var claimDepositCmd = &cli.Command{
+	Name:  "claim",
+	Usage: "claim a deposit that was not credited",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "chain",
+			Usage: "deposit chain",
+			Value: "tron",
+		},
+		&cli.StringFlag{
+			Name:  "hash",
+			Usage: "transaction hash",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		info, err := api.ClaimDeposit(ctx, cctx.String("chain"), cctx.String("hash"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s %s %s %s %s\n", info.ClaimID, info.Chain, info.TxHash, info.State.String(), info.Reason)
+		return nil
+	},
+}
+
+var userClaimsCmd = &cli.Command{
+	Name:  "claims",
+	Usage: "list the deposit claims",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "limit",
+			Usage: "limit",
+			Value: 100,
+		},
+		&cli.Int64Flag{
+			Name:  "page",
+			Usage: "page",
+			Value: 0,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		out, err := api.GetUserDepositClaims(ctx, cctx.Int64("limit"), cctx.Int64("page"))
+		if err != nil {
+			return err
+		}
+
+		for _, info := range out.List {
+			fmt.Printf("%s %s %s %s %s\n", info.ClaimID, info.Chain, info.TxHash, info.State.String(), info.Reason)
+		}
+
+		return nil
+	},
+}
+
+var depositClaimsCmd = &cli.Command{
+	Name:  "claims",
+	Usage: "list the deposit claims waiting for review",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "limit",
+			Usage: "limit",
+			Value: 100,
+		},
+		&cli.Int64Flag{
+			Name:  "page",
+			Usage: "page",
+			Value: 0,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		out, err := api.GetDepositClaims(ctx, types.DepositClaimReview, cctx.Int64("limit"), cctx.Int64("page"))
+		if err != nil {
+			return err
+		}
+
+		for _, info := range out.List {
+			fmt.Printf("%s %s %s %s %s %s\n", info.ClaimID, info.UserID, info.Chain, info.TxHash, info.Reason, info.Msg)
+		}
+
+		return nil
+	},
+}
+
+var recheckClaimCmd = &cli.Command{
+	Name:  "recheck-claim",
+	Usage: "verify a deposit claim on chain again",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "id",
+			Usage: "claim id",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		info, err := api.RecheckDepositClaim(ctx, cctx.String("id"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s %s %s\n", info.ClaimID, info.State.String(), info.Reason)
+		return nil
+	},
+}
+
+var acceptClaimCmd = &cli.Command{
+	Name:  "accept-claim",
+	Usage: "credit a deposit claim checked by hand",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "id",
+			Usage: "claim id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "value credited to the user",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "msg",
+			Usage: "reason of the credit",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.AcceptDepositClaim(ctx, cctx.String("id"), cctx.String("value"), cctx.String("msg"))
+	},
+}
+
+var rejectClaimCmd = &cli.Command{
+	Name:  "reject-claim",
+	Usage: "reject a deposit claim",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "id",
+			Usage: "claim id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "msg",
+			Usage: "reason shown to the user",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.RejectDepositClaim(ctx, cctx.String("id"), cctx.String("msg"))
+	},
+}
+
+var requestRefundCmd = &cli.Command{
+	Name:  "refund",
+	Usage: "request instance refund",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "instanceID",
+			Usage: "instance id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "reason",
+			Usage: "refund reason",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		refundID, err := api.RequestInstanceRefund(ctx, cctx.String("instanceID"), cctx.String("reason"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(refundID)
+		return nil
+	},
+}
+
+var setBillingInfoCmd = &cli.Command{
+	Name:  "billing",
+	Usage: "set company billing details of invoices",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "company",
+			Usage: "company name",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "tax-id",
+			Usage: "company tax id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "address",
+			Usage: "company address",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "country",
+			Usage: "company country",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "email",
+			Usage: "billing email",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.SetBillingInfo(ctx, &types.BillingInfo{
+			CompanyName: cctx.String("company"),
+			TaxID:       cctx.String("tax-id"),
+			Address:     cctx.String("address"),
+			Country:     cctx.String("country"),
+			Email:       cctx.String("email"),
+		})
+	},
+}
+
+var listInvoicesCmd = &cli.Command{
+	Name:  "invoices",
+	Usage: "list invoices",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "limit",
+			Usage: "limit",
+			Value: 100,
+		},
+		&cli.Int64Flag{
+			Name:  "page",
+			Usage: "page",
+			Value: 0,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		out, err := api.GetUserInvoices(ctx, cctx.Int64("limit"), cctx.Int64("page"))
+		if err != nil {
+			return err
+		}
+
+		for _, info := range out.List {
+			fmt.Printf("%d %s ref:%s total:%s %s created:%s \n", info.InvoiceNo, info.InvoiceType.String(), info.RefID, info.Total, info.Currency, info.CreatedTime.Format("2006-01-02 15:04:05"))
+		}
+
+		return nil
+	},
+}
+
+var accountStatementCmd = &cli.Command{
+	Name:  "statement",
+	Usage: "show account statement",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "from",
+			Usage: "start date, e.g. 2023-09-01",
+		},
+		&cli.StringFlag{
+			Name:  "to",
+			Usage: "end date, e.g. 2023-09-30",
+		},
+		&cli.Int64Flag{
+			Name:  "cursor",
+			Usage: "cursor of the next page",
+			Value: 0,
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		out, err := api.GetAccountStatement(ctx, cctx.String("from"), cctx.String("to"), cctx.Int64("cursor"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s - %s opening balance:%s closing balance:%s \n", out.From, out.To, out.OpeningBalance, out.ClosingBalance)
+		for _, info := range out.List {
+			fmt.Printf("%s %s ref:%s amount:%s balance:%s \n", info.Time.Format("2006-01-02 15:04:05"), info.Type, info.RefID, info.Amount, info.Balance)
+		}
+
+		if out.NextCursor > 0 {
+			fmt.Printf("next cursor:%d \n", out.NextCursor)
+		}
+
+		return nil
+	},
+}
+
+var downloadInvoiceCmd = &cli.Command{
+	Name:  "invoice",
+	Usage: "download invoice",
+	Flags: []cli.Flag{
+		&cli.Int64Flag{
+			Name:  "no",
+			Usage: "invoice number",
+		},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "html or pdf",
+			Value: "pdf",
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "output file path",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		no := cctx.Int64("no")
+		format := cctx.String("format")
+
+		buf, err := api.RenderInvoice(ctx, no, format)
+		if err != nil {
+			return err
+		}
+
+		out := cctx.String("out")
+		if out == "" {
+			out = fmt.Sprintf("invoice-%d.%s", no, format)
+		}
+
+		return os.WriteFile(out, buf, 0o644)
+	},
+}
+
+var approveRefundCmd = &cli.Command{
+	Name:  "ar",
+	Usage: "approve refund",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "rid",
+			Usage: "refund id",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.ApproveInstanceRefund(ctx, cctx.String("rid"))
+	},
+}
+
+var rejectRefundCmd = &cli.Command{
+	Name:  "rr",
+	Usage: "reject refund",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "rid",
+			Usage: "refund id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "msg",
+			Usage: "reject reason",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetMallAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.RejectInstanceRefund(ctx, cctx.String("rid"), cctx.String("msg"))
+	},
+}
+
 var createAdminCmd = &cli.Command{
 	Name:  "create",
 	Usage: "create admin",
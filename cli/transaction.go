@@ -1 +1,80 @@
 package cli
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+)
+
+// TransactionCMDs Transaction cmd
+var TransactionCMDs = []*cli.Command{
+	WithCategory("chain", chainCmds),
+}
+
+var chainCmds = &cli.Command{
+	Name:  "chain",
+	Usage: "Manage chain",
+	Subcommands: []*cli.Command{
+		chainInfoCmd,
+		simulateDepositCmd,
+	},
+}
+
+var chainInfoCmd = &cli.Command{
+	Name:  "info",
+	Usage: "show the served chains",
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetTransactionAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		info, err := api.GetChainInfo(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("stand-in: %v\n", info.StandIn)
+		fmt.Printf("evm chains: %v\n", info.EvmChains)
+		fmt.Printf("withdraw enabled: %v\n", info.WithdrawEnabled)
+		fmt.Printf("withdraw contract: %s\n", info.WithdrawContract)
+		return nil
+	},
+}
+
+var simulateDepositCmd = &cli.Command{
+	Name:  "simulate-deposit",
+	Usage: "simulate a confirmed deposit on the stand-in chain",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "user",
+			Usage: "user id",
+			Value: "",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "value in the settlement currency",
+			Value: "",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		ctx := ReqContext(cctx)
+
+		api, closer, err := GetTransactionAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		hash, err := api.SimulateDeposit(ctx, cctx.String("user"), cctx.String("value"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(hash)
+		return nil
+	},
+}
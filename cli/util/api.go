@@ -19,6 +19,7 @@ import (
 
 	"github.com/LMF709268224/titan-vps/api"
 	"github.com/LMF709268224/titan-vps/api/client"
+	"github.com/LMF709268224/titan-vps/node/handler"
 	"github.com/LMF709268224/titan-vps/node/repo"
 )
 
@@ -187,6 +188,13 @@ func GetMallAPI(ctx *cli.Context) (api.Mall, jsonrpc.ClientCloser, error) {
 		_, _ = fmt.Fprintln(ctx.App.Writer, "using mall API v0 endpoint:", addr)
 	}
 
+	if key := ctx.String("idempotency-key"); key != "" {
+		if headers == nil {
+			headers = http.Header{}
+		}
+		headers.Set(handler.IdempotencyKeyHeader, key)
+	}
+
 	a, c, e := client.NewMall(ctx.Context, addr, headers)
 	v, err := a.Version(ctx.Context)
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	lcli "github.com/LMF709268224/titan-vps/cli"
 	cliutil "github.com/LMF709268224/titan-vps/cli/util"
 	liblog "github.com/LMF709268224/titan-vps/lib/log"
+	"github.com/LMF709268224/titan-vps/metrics"
 	"github.com/LMF709268224/titan-vps/node"
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/repo"
@@ -17,6 +18,7 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli/v2"
+	"go.opencensus.io/stats/view"
 	"golang.org/x/xerrors"
 )
 
@@ -173,6 +175,10 @@ var runCmd = &cli.Command{
 
 		shutdownChan := make(chan struct{})
 
+		if err := view.Register(metrics.MallNodeViews...); err != nil {
+			return xerrors.Errorf("registering metrics views: %w", err)
+		}
+
 		var bAPI api.Mall
 		stop, err := node.New(cctx.Context,
 			node.Mall(&bAPI),
@@ -10,6 +10,7 @@ import (
 	lcli "github.com/LMF709268224/titan-vps/cli"
 	cliutil "github.com/LMF709268224/titan-vps/cli/util"
 	liblog "github.com/LMF709268224/titan-vps/lib/log"
+	"github.com/LMF709268224/titan-vps/metrics"
 	"github.com/LMF709268224/titan-vps/node"
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/repo"
@@ -17,6 +18,7 @@ import (
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/mattn/go-isatty"
 	"github.com/urfave/cli/v2"
+	"go.opencensus.io/stats/view"
 	"golang.org/x/xerrors"
 )
 
@@ -39,6 +41,8 @@ func main() {
 		runCmd,
 	}
 
+	local = append(local, lcli.TransactionCMDs...)
+
 	if AdvanceBlockCmd != nil {
 		local = append(local, AdvanceBlockCmd)
 	}
@@ -163,6 +167,10 @@ var runCmd = &cli.Command{
 
 		shutdownChan := make(chan struct{})
 
+		if err := view.Register(metrics.TransactionNodeViews...); err != nil {
+			return xerrors.Errorf("registering metrics views: %w", err)
+		}
+
 		var tAPI api.Transaction
 		stop, err := node.New(cctx.Context,
 			node.Transaction(&tAPI),
@@ -1,6 +1,7 @@
 package aliyun
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -87,6 +88,9 @@ func CreateInstance(keyID, keySecret string, instanceReq *types.CreateInstanceRe
 			createInstanceRequest.DataDisk = append(createInstanceRequest.DataDisk, DataDiskInfo)
 		}
 	}
+	if instanceReq.UserData != "" {
+		createInstanceRequest.UserData = tea.String(base64.StdEncoding.EncodeToString([]byte(instanceReq.UserData)))
+	}
 	runtime := &util.RuntimeOptions{}
 	tryErr := func() (_e error) {
 		defer func() {
@@ -987,6 +991,127 @@ func RebootInstance(regionID, keyID, keySecret, instanceId string) *tea.SDKError
 	return nil
 }
 
+// StopInstance stop an instance
+func StopInstance(regionID, keyID, keySecret, instanceID string) *tea.SDKError {
+	client, err := newClient(regionID, keyID, keySecret)
+	if err != nil {
+		return err
+	}
+
+	stopInstanceRequest := &ecs20140526.StopInstanceRequest{
+		InstanceId: tea.String(instanceID),
+		ForceStop:  tea.Bool(false),
+	}
+	runtime := &util.RuntimeOptions{}
+	tryErr := func() (_e error) {
+		defer func() {
+			if r := tea.Recover(recover()); r != nil {
+				_e = r
+			}
+		}()
+		_, _e = client.StopInstanceWithOptions(stopInstanceRequest, runtime)
+		if _e != nil {
+			return _e
+		}
+
+		return nil
+	}()
+
+	if tryErr != nil {
+		errors := &tea.SDKError{}
+		if _t, ok := tryErr.(*tea.SDKError); ok {
+			errors = _t
+		} else {
+			errors.Message = tea.String(tryErr.Error())
+		}
+		return errors
+	}
+	return nil
+}
+
+// ModifyInstanceUserData replaces the user data of a stopped instance
+func ModifyInstanceUserData(regionID, keyID, keySecret, instanceID, userData string) *tea.SDKError {
+	client, err := newClient(regionID, keyID, keySecret)
+	if err != nil {
+		return err
+	}
+
+	modifyRequest := &ecs20140526.ModifyInstanceAttributeRequest{
+		InstanceId: tea.String(instanceID),
+		UserData:   tea.String(base64.StdEncoding.EncodeToString([]byte(userData))),
+	}
+	runtime := &util.RuntimeOptions{}
+	tryErr := func() (_e error) {
+		defer func() {
+			if r := tea.Recover(recover()); r != nil {
+				_e = r
+			}
+		}()
+		_, _e = client.ModifyInstanceAttributeWithOptions(modifyRequest, runtime)
+		if _e != nil {
+			return _e
+		}
+
+		return nil
+	}()
+
+	if tryErr != nil {
+		errors := &tea.SDKError{}
+		if _t, ok := tryErr.(*tea.SDKError); ok {
+			errors = _t
+		} else {
+			errors.Message = tea.String(tryErr.Error())
+		}
+		return errors
+	}
+	return nil
+}
+
+// ReplaceSystemDisk reinstalls the system disk of a stopped instance with the given image
+func ReplaceSystemDisk(regionID, keyID, keySecret, instanceID, imageID string) (string, *tea.SDKError) {
+	var diskID string
+
+	client, err := newClient(regionID, keyID, keySecret)
+	if err != nil {
+		return diskID, err
+	}
+
+	replaceRequest := &ecs20140526.ReplaceSystemDiskRequest{
+		InstanceId:      tea.String(instanceID),
+		ImageId:         tea.String(imageID),
+		PasswordInherit: tea.Bool(true),
+	}
+	runtime := &util.RuntimeOptions{}
+	tryErr := func() (_e error) {
+		defer func() {
+			if r := tea.Recover(recover()); r != nil {
+				_e = r
+			}
+		}()
+		result, _e := client.ReplaceSystemDiskWithOptions(replaceRequest, runtime)
+		if _e != nil {
+			return _e
+		}
+
+		if result.Body != nil && result.Body.DiskId != nil {
+			diskID = *result.Body.DiskId
+		}
+
+		return nil
+	}()
+
+	if tryErr != nil {
+		errors := &tea.SDKError{}
+		if _t, ok := tryErr.(*tea.SDKError); ok {
+			errors = _t
+		} else {
+			errors.Message = tea.String(tryErr.Error())
+		}
+		return diskID, errors
+	}
+	return diskID, nil
+}
+
 // RenewInstance renew instance
 func RenewInstance(keyID, keySecret string, renewInstanceRequest *types.RenewInstanceRequest) *tea.SDKError {
 	client, err := newClient(renewInstanceRequest.RegionId, keyID, keySecret)
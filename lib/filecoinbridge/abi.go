@@ -69,7 +69,7 @@ type IpcOrderInfo struct {
 
 // FvmMetaData contains all meta data concerning the Fvm contract.
 var FvmMetaData = &bind.MetaData{
-	ABI: "[{\"inputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"OrderID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"UserID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Value\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"CreatedTime\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Type\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PeriodUnit\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Period\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"RegionId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ImageId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Memory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"MemoryUsed\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Cores\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"CoresUsed\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SecurityGroupId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthOut\",\"type\":\"int32\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthIn\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"IpAddress\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"TradePrice\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SystemDiskCategory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"OSType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InternetChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"SystemDiskSize\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"DataDiskString\",\"type\":\"string\"},{\"components\":[{\"internalType\":\"string\",\"name\":\"Category\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PerformanceLevel\",\"type\":\"string\"},{\"internalType\":\"int64\",\"name\":\"Size\",\"type\":\"int64\"}],\"internalType\":\"structDescribePriceRequestDataDisk[]\",\"name\":\"DataDisk\",\"type\":\"tuple[]\"}],\"internalType\":\"structIpcOrderInfo\",\"name\":\"x\",\"type\":\"tuple\"}],\"name\":\"setOrderInfo\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"orderID\",\"type\":\"string\"}],\"name\":\"getOrderInfo\",\"outputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"OrderID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"UserID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Value\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"CreatedTime\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Type\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PeriodUnit\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Period\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"RegionId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ImageId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Memory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"MemoryUsed\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Cores\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"CoresUsed\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SecurityGroupId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthOut\",\"type\":\"int32\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthIn\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"IpAddress\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"TradePrice\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SystemDiskCategory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"OSType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InternetChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"SystemDiskSize\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"DataDiskString\",\"type\":\"string\"},{\"components\":[{\"internalType\":\"string\",\"name\":\"Category\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PerformanceLevel\",\"type\":\"string\"},{\"internalType\":\"int64\",\"name\":\"Size\",\"type\":\"int64\"}],\"internalType\":\"structDescribePriceRequestDataDisk[]\",\"name\":\"DataDisk\",\"type\":\"tuple[]\"}],\"internalType\":\"structIpcOrderInfo\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"}]",
+	ABI: "[{\"inputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"OrderID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"UserID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Value\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"CreatedTime\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Type\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PeriodUnit\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Period\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"RegionId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ImageId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Memory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"MemoryUsed\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Cores\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"CoresUsed\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SecurityGroupId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthOut\",\"type\":\"int32\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthIn\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"IpAddress\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"TradePrice\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SystemDiskCategory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"OSType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InternetChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"SystemDiskSize\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"DataDiskString\",\"type\":\"string\"},{\"components\":[{\"internalType\":\"string\",\"name\":\"Category\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PerformanceLevel\",\"type\":\"string\"},{\"internalType\":\"int64\",\"name\":\"Size\",\"type\":\"int64\"}],\"internalType\":\"structDescribePriceRequestDataDisk[]\",\"name\":\"DataDisk\",\"type\":\"tuple[]\"}],\"internalType\":\"structIpcOrderInfo\",\"name\":\"x\",\"type\":\"tuple\"}],\"name\":\"setOrderInfo\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"},{\"inputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"constructor\"},{\"inputs\":[{\"internalType\":\"string\",\"name\":\"orderID\",\"type\":\"string\"}],\"name\":\"getOrderInfo\",\"outputs\":[{\"components\":[{\"internalType\":\"string\",\"name\":\"OrderID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"UserID\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Value\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"CreatedTime\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Type\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PeriodUnit\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Period\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"RegionId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"ImageId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"Memory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"MemoryUsed\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"Cores\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"CoresUsed\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SecurityGroupId\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InstanceChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthOut\",\"type\":\"int32\"},{\"internalType\":\"int32\",\"name\":\"InternetMaxBandwidthIn\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"IpAddress\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"TradePrice\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"SystemDiskCategory\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"OSType\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"InternetChargeType\",\"type\":\"string\"},{\"internalType\":\"int32\",\"name\":\"SystemDiskSize\",\"type\":\"int32\"},{\"internalType\":\"string\",\"name\":\"DataDiskString\",\"type\":\"string\"},{\"components\":[{\"internalType\":\"string\",\"name\":\"Category\",\"type\":\"string\"},{\"internalType\":\"string\",\"name\":\"PerformanceLevel\",\"type\":\"string\"},{\"internalType\":\"int64\",\"name\":\"Size\",\"type\":\"int64\"}],\"internalType\":\"structDescribePriceRequestDataDisk[]\",\"name\":\"DataDisk\",\"type\":\"tuple[]\"}],\"internalType\":\"structIpcOrderInfo\",\"name\":\"\",\"type\":\"tuple\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"anonymous\":false,\"inputs\":[{\"indexed\":true,\"internalType\":\"address\",\"name\":\"from\",\"type\":\"address\"},{\"indexed\":true,\"internalType\":\"address\",\"name\":\"to\",\"type\":\"address\"},{\"indexed\":false,\"internalType\":\"uint256\",\"name\":\"value\",\"type\":\"uint256\"}],\"name\":\"Transfer\",\"type\":\"event\"}]",
 }
 
 // FvmABI is the input ABI used to generate the binding from.
@@ -269,3 +269,157 @@ func (_Fvm *FvmSession) SetOrderInfo(x IpcOrderInfo) (*types.Transaction, error)
 func (_Fvm *FvmTransactorSession) SetOrderInfo(x IpcOrderInfo) (*types.Transaction, error) {
 	return _Fvm.Contract.SetOrderInfo(&_Fvm.TransactOpts, x)
 }
+
+// FvmTransferIterator is returned from FilterTransfer and is used to iterate over the raw logs and unpacked data for Transfer events raised by the Fvm contract.
+type FvmTransferIterator struct {
+	Event *FvmTransfer // Event containing the contract specifics and raw log
+
+	contract *bind.BoundContract // Generic contract to use for unpacking event data
+	event    string              // Event name to use for unpacking event data
+
+	logs chan types.Log        // Log channel receiving the found contract events
+	sub  ethereum.Subscription // Subscription for errors, completion and termination
+	done bool                  // Whether the subscription completed delivering logs
+	fail error                 // Occurred error to stop iteration
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *FvmTransferIterator) Next() bool {
+	// If the iterator failed, stop iterating
+	if it.fail != nil {
+		return false
+	}
+	// If the iterator completed, deliver directly whatever's available
+	if it.done {
+		select {
+		case log := <-it.logs:
+			it.Event = new(FvmTransfer)
+			if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+				it.fail = err
+				return false
+			}
+			it.Event.Raw = log
+			return true
+
+		default:
+			return false
+		}
+	}
+	// Iterator still in progress, wait for either a data or an error event
+	select {
+	case log := <-it.logs:
+		it.Event = new(FvmTransfer)
+		if err := it.contract.UnpackLog(it.Event, it.event, log); err != nil {
+			it.fail = err
+			return false
+		}
+		it.Event.Raw = log
+		return true
+
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (it *FvmTransferIterator) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (it *FvmTransferIterator) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// FvmTransfer represents a Transfer event raised by the Fvm contract.
+type FvmTransfer struct {
+	From  common.Address
+	To    common.Address
+	Value *big.Int
+	Raw   types.Log // Blockchain specific contextual infos
+}
+
+// FilterTransfer is a free log retrieval operation binding the contract event 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef.
+//
+// Solidity: event Transfer(address indexed from, address indexed to, uint256 value)
+func (_Fvm *FvmFilterer) FilterTransfer(opts *bind.FilterOpts, from []common.Address, to []common.Address) (*FvmTransferIterator, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _Fvm.contract.FilterLogs(opts, "Transfer", fromRule, toRule)
+	if err != nil {
+		return nil, err
+	}
+	return &FvmTransferIterator{contract: _Fvm.contract, event: "Transfer", logs: logs, sub: sub}, nil
+}
+
+// WatchTransfer is a free log subscription operation binding the contract event 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef.
+//
+// Solidity: event Transfer(address indexed from, address indexed to, uint256 value)
+func (_Fvm *FvmFilterer) WatchTransfer(opts *bind.WatchOpts, sink chan<- *FvmTransfer, from []common.Address, to []common.Address) (event.Subscription, error) {
+
+	var fromRule []interface{}
+	for _, fromItem := range from {
+		fromRule = append(fromRule, fromItem)
+	}
+	var toRule []interface{}
+	for _, toItem := range to {
+		toRule = append(toRule, toItem)
+	}
+
+	logs, sub, err := _Fvm.contract.WatchLogs(opts, "Transfer", fromRule, toRule)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				// New log arrived, parse the event and forward to the user
+				event := new(FvmTransfer)
+				if err := _Fvm.contract.UnpackLog(event, "Transfer", log); err != nil {
+					return err
+				}
+				event.Raw = log
+
+				select {
+				case sink <- event:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ParseTransfer is a log parse operation binding the contract event 0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef.
+//
+// Solidity: event Transfer(address indexed from, address indexed to, uint256 value)
+func (_Fvm *FvmFilterer) ParseTransfer(log types.Log) (*FvmTransfer, error) {
+	event := new(FvmTransfer)
+	if err := _Fvm.contract.UnpackLog(event, "Transfer", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
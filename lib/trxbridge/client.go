@@ -25,6 +25,12 @@ type GrpcClient struct {
 	Address string
 	Conn    *grpc.ClientConn
 	Client  api.WalletClient
+	// Solidity serves the reads of a solidity node, they only see the solidified blocks
+	Solidity api.WalletSolidityClient
+	// Timeout of each call, timeout seconds are used if it is 0
+	Timeout time.Duration
+
+	solidity bool
 }
 
 // NewGrpcClient NewGrpcClient
@@ -34,6 +40,14 @@ func NewGrpcClient(address string) *GrpcClient {
 	return client
 }
 
+// NewSolidityGrpcClient returns a client of a solidity node, GetNowBlock, GetAccount and
+// GetConstantResultOfContract are served by its solidity service.
+func NewSolidityGrpcClient(address string) *GrpcClient {
+	client := NewGrpcClient(address)
+	client.solidity = true
+	return client
+}
+
 // Start Start
 func (g *GrpcClient) Start() error {
 	var err error
@@ -42,9 +56,19 @@ func (g *GrpcClient) Start() error {
 		return err
 	}
 	g.Client = api.NewWalletClient(g.Conn)
+	g.Solidity = api.NewWalletSolidityClient(g.Conn)
 	return nil
 }
 
+// contextTimeout returns the context of a call.
+func (g *GrpcClient) contextTimeout() (context.Context, context.CancelFunc) {
+	if g.Timeout > 0 {
+		return context.WithTimeout(context.Background(), g.Timeout)
+	}
+
+	return ContextTimeout(timeout)
+}
+
 // ContextTimeout
 func ContextTimeout(sec int) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), time.Second*time.Duration(sec))
@@ -52,7 +76,7 @@ func ContextTimeout(sec int) (context.Context, context.CancelFunc) {
 
 // ListWitnesses ListWitnesses
 func (g *GrpcClient) ListWitnesses() (*api.WitnessList, error) {
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	witnessList, err := g.Client.ListWitnesses(ctx,
 		new(api.EmptyMessage))
@@ -65,7 +89,7 @@ func (g *GrpcClient) ListWitnesses() (*api.WitnessList, error) {
 
 // ListNodes ListNodes
 func (g *GrpcClient) ListNodes() (*api.NodeList, error) {
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	nodeList, err := g.Client.ListNodes(ctx,
 		new(api.EmptyMessage))
@@ -77,7 +101,7 @@ func (g *GrpcClient) ListNodes() (*api.NodeList, error) {
 
 // GetNodeInfo GetNodeInfo
 func (g *GrpcClient) GetNodeInfo() (*core.NodeInfo, error) {
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	node, err := g.Client.GetNodeInfo(ctx, new(api.EmptyMessage))
 	if err != nil {
@@ -94,16 +118,22 @@ func (g *GrpcClient) GetAccount(address string) (*core.Account, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
+	if g.solidity {
+		return g.Solidity.GetAccount(ctx, account)
+	}
 	result, err := g.Client.GetAccount(ctx, account)
 	return result, err
 }
 
 // GetNowBlock GetNowBlock
 func (g *GrpcClient) GetNowBlock() (*api.BlockExtention, error) {
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
+	if g.solidity {
+		return g.Solidity.GetNowBlock2(ctx, new(api.EmptyMessage))
+	}
 	result, err := g.Client.GetNowBlock2(ctx, new(api.EmptyMessage))
 	return result, err
 }
@@ -112,7 +142,7 @@ func (g *GrpcClient) GetNowBlock() (*api.BlockExtention, error) {
 func (g *GrpcClient) GetAssetIssueByAccount(address string) (*api.AssetIssueList, error) {
 	account := new(core.Account)
 	account.Address, _ = hdwallet.DecodeCheck(address)
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	result, err := g.Client.GetAssetIssueByAccount(ctx, account)
 	if err != nil {
@@ -123,7 +153,7 @@ func (g *GrpcClient) GetAssetIssueByAccount(address string) (*api.AssetIssueList
 
 // GetNextMaintenanceTime GetNextMaintenanceTime
 func (g *GrpcClient) GetNextMaintenanceTime() (*api.NumberMessage, error) {
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	result, err := g.Client.GetNextMaintenanceTime(ctx, new(api.EmptyMessage))
 	if err != nil {
@@ -134,7 +164,7 @@ func (g *GrpcClient) GetNextMaintenanceTime() (*api.NumberMessage, error) {
 
 // TotalTransaction TotalTransaction
 func (g *GrpcClient) TotalTransaction() (*api.NumberMessage, error) {
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	return g.Client.TotalTransaction(ctx, new(api.EmptyMessage))
 }
@@ -144,7 +174,7 @@ func (g *GrpcClient) GetAccountNet(address string) (*api.AccountNetMessage, erro
 	account := new(core.Account)
 
 	account.Address, _ = hdwallet.DecodeCheck(address)
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	return g.Client.GetAccountNet(ctx, account)
 }
@@ -153,7 +183,7 @@ func (g *GrpcClient) GetAccountNet(address string) (*api.AccountNetMessage, erro
 func (g *GrpcClient) GetAssetIssueByName(name string) (*core.AssetIssueContract, error) {
 	assetName := new(api.BytesMessage)
 	assetName.Value = []byte(name)
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	return g.Client.GetAssetIssueByName(ctx, assetName)
 }
@@ -162,7 +192,7 @@ func (g *GrpcClient) GetAssetIssueByName(name string) (*core.AssetIssueContract,
 func (g *GrpcClient) GetBlockByNum(num int64) (*api.BlockExtention, error) {
 	numMessage := new(api.NumberMessage)
 	numMessage.Num = num
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	result, err := g.Client.GetBlockByNum2(ctx, numMessage)
 	return result, err
@@ -178,7 +208,7 @@ func (g *GrpcClient) GetBlockByID(id string) (*core.Block, error) {
 	if err != nil {
 		return nil, fmt.Errorf("get block by id error: %v", err)
 	}
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	result, err := g.Client.GetBlockById(ctx, blockID)
 	if err != nil {
@@ -189,7 +219,7 @@ func (g *GrpcClient) GetBlockByID(id string) (*core.Block, error) {
 
 // GetAssetIssueList GetAssetIssueList
 func (g *GrpcClient) GetAssetIssueList() (*api.AssetIssueList, error) {
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	return g.Client.GetAssetIssueList(ctx, new(api.EmptyMessage))
 }
@@ -199,7 +229,7 @@ func (g *GrpcClient) GetBlockByLimitNext(start, end int64) (*api.BlockListExtent
 	blockLimit := new(api.BlockLimit)
 	blockLimit.StartNum = start
 	blockLimit.EndNum = end
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	return g.Client.GetBlockByLimitNext2(ctx, blockLimit)
 }
@@ -212,7 +242,7 @@ func (g *GrpcClient) GetTransactionByID(id string) (*core.Transaction, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	return g.Client.GetTransactionById(ctx, transactionID)
 }
@@ -225,8 +255,11 @@ func (g *GrpcClient) GetTransactionInfoByID(id string) (*core.TransactionInfo, e
 	if err != nil {
 		return nil, err
 	}
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
+	if g.solidity {
+		return g.Solidity.GetTransactionInfoById(ctx, transactionID)
+	}
 	result, err := g.Client.GetTransactionInfoById(ctx, transactionID)
 	return result, err
 }
@@ -235,7 +268,7 @@ func (g *GrpcClient) GetTransactionInfoByID(id string) (*core.TransactionInfo, e
 func (g *GrpcClient) GetBlockByLatestNum(num int64) (*api.BlockListExtention, error) {
 	numMessage := new(api.NumberMessage)
 	numMessage.Num = num
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	result, err := g.Client.GetBlockByLatestNum2(ctx, numMessage)
 	return result, err
@@ -249,7 +282,7 @@ func (g *GrpcClient) CreateAccount(ownerKey *ecdsa.PrivateKey,
 	accountCreateContract.OwnerAddress = hdwallet.PubkeyToTronAddress(ownerKey.PublicKey).Bytes()
 	accountCreateContract.AccountAddress, _ = hdwallet.DecodeCheck(accountAddress)
 
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	accountCreateTransaction, err := g.Client.CreateAccount(ctx, accountCreateContract)
 	if err != nil {
@@ -274,7 +307,7 @@ func (g *GrpcClient) UpdateAccount(ownerKey *ecdsa.PrivateKey,
 	accountUpdateContract.AccountName = []byte(accountName)
 	accountUpdateContract.OwnerAddress = hdwallet.PubkeyToTronAddress(ownerKey.
 		PublicKey).Bytes()
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	accountUpdateTransaction, err := g.Client.UpdateAccount(ctx, accountUpdateContract)
 	if err != nil {
@@ -292,39 +325,41 @@ func (g *GrpcClient) UpdateAccount(ownerKey *ecdsa.PrivateKey,
 
 // Transfer Transfer
 func (g *GrpcClient) Transfer(ownerKey *ecdsa.PrivateKey, toAddress string, amount int64) (string, error) {
+	transferTransaction, txid, err := g.SignTransfer(ownerKey, toAddress, amount)
+	if err != nil {
+		return "", err
+	}
+
+	err = g.Broadcast(transferTransaction)
+	if err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+// SignTransfer creates and signs a TRX transfer without broadcasting it, the transaction id is returned with it
+func (g *GrpcClient) SignTransfer(ownerKey *ecdsa.PrivateKey, toAddress string, amount int64) (*core.Transaction, string, error) {
 	transferContract := new(core.TransferContract)
 	transferContract.OwnerAddress = hdwallet.PubkeyToTronAddress(ownerKey.
 		PublicKey).Bytes()
 	transferContract.ToAddress, _ = hdwallet.DecodeCheck(toAddress)
 	transferContract.Amount = amount
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	transferTransactionEx, err := g.Client.CreateTransaction2(ctx, transferContract)
-
-	var txid string
 	if err != nil {
-		return txid, err
+		return nil, "", err
 	}
 	transferTransaction := transferTransactionEx.Transaction
 	if transferTransaction == nil ||
 		len(transferTransaction.GetRawData().GetContract()) == 0 {
-		return txid, fmt.Errorf("transfer error: invalid transaction")
+		return nil, "", fmt.Errorf("transfer error: invalid transaction")
 	}
 	hash, err := util.SignTransaction(transferTransaction, ownerKey)
 	if err != nil {
-		return txid, err
-	}
-	txid = hexutil.Encode(hash)
-
-	result, err := g.Client.BroadcastTransaction(ctx,
-		transferTransaction)
-	if err != nil {
-		return "", err
+		return nil, "", err
 	}
-	if !result.Result {
-		return "", fmt.Errorf("api get false the msg: %s", result.String())
-	}
-	return txid, err
+	return transferTransaction, hexutil.Encode(hash), nil
 }
 
 // TransferAsset TransferAsset
@@ -335,7 +370,7 @@ func (g *GrpcClient) TransferAsset(ownerKey *ecdsa.PrivateKey, AssetName, toAddr
 	transferContract.ToAddress, _ = hdwallet.DecodeCheck(toAddress)
 	transferContract.AssetName, _ = hdwallet.DecodeCheck(AssetName)
 	transferContract.Amount = amount
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	transferTransactionEx, err := g.Client.TransferAsset2(ctx, transferContract)
 
@@ -366,22 +401,35 @@ func (g *GrpcClient) TransferAsset(ownerKey *ecdsa.PrivateKey, AssetName, toAddr
 
 // TransferContract TransferContract
 func (g *GrpcClient) TransferContract(ownerKey *ecdsa.PrivateKey, Contract string, data []byte, feeLimit int64) (string, error) {
+	transferTransaction, txid, err := g.SignTransferContract(ownerKey, Contract, data, feeLimit)
+	if err != nil {
+		return "", err
+	}
+
+	err = g.Broadcast(transferTransaction)
+	if err != nil {
+		return "", err
+	}
+	return txid, nil
+}
+
+// SignTransferContract creates and signs a contract call without broadcasting it, the transaction id is returned with it
+func (g *GrpcClient) SignTransferContract(ownerKey *ecdsa.PrivateKey, Contract string, data []byte, feeLimit int64) (*core.Transaction, string, error) {
 	transferContract := new(core.TriggerSmartContract)
 	transferContract.OwnerAddress = hdwallet.PubkeyToTronAddress(ownerKey.
 		PublicKey).Bytes()
 	transferContract.ContractAddress, _ = hdwallet.DecodeCheck(Contract)
 	transferContract.Data = data
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	transferTransactionEx, err := g.Client.TriggerConstantContract(ctx, transferContract)
-	var txid string
 	if err != nil {
-		return txid, err
+		return nil, "", err
 	}
 	transferTransaction := transferTransactionEx.Transaction
 	if transferTransaction == nil ||
 		len(transferTransaction.GetRawData().GetContract()) == 0 {
-		return txid, fmt.Errorf("transfer error: invalid transaction")
+		return nil, "", fmt.Errorf("transfer error: invalid transaction")
 	}
 	if feeLimit > 0 {
 		transferTransaction.RawData.FeeLimit = feeLimit
@@ -389,19 +437,27 @@ func (g *GrpcClient) TransferContract(ownerKey *ecdsa.PrivateKey, Contract strin
 
 	hash, err := util.SignTransaction(transferTransaction, ownerKey)
 	if err != nil {
-		return txid, err
+		return nil, "", err
 	}
-	txid = hexutil.Encode(hash)
+	return transferTransaction, hexutil.Encode(hash), nil
+}
 
-	result, err := g.Client.BroadcastTransaction(ctx,
-		transferTransaction)
+// Broadcast broadcasts a signed transaction
+func (g *GrpcClient) Broadcast(transaction *core.Transaction) error {
+	ctx, cancel := g.contextTimeout()
+	defer cancel()
+	result, err := g.Client.BroadcastTransaction(ctx, transaction)
 	if err != nil {
-		return "", err
+		return err
+	}
+	// the transaction was broadcast before, e.g. by a call that timed out
+	if result.Code == api.Return_DUP_TRANSACTION_ERROR {
+		return nil
 	}
 	if !result.Result {
-		return "", fmt.Errorf("api get false the msg: %s", result.String())
+		return fmt.Errorf("api get false the msg: %s", result.String())
 	}
-	return txid, err
+	return nil
 }
 
 // GetConstantResultOfContract GetConstantResultOfContract
@@ -410,9 +466,15 @@ func (g *GrpcClient) GetConstantResultOfContract(ownerKey *ecdsa.PrivateKey, Con
 	transferContract.OwnerAddress = hdwallet.PubkeyToTronAddress(ownerKey.PublicKey).Bytes()
 	transferContract.ContractAddress, _ = hdwallet.DecodeCheck(Contract)
 	transferContract.Data = data
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
-	transferTransactionEx, err := g.Client.TriggerConstantContract(ctx, transferContract)
+	var transferTransactionEx *api.TransactionExtention
+	var err error
+	if g.solidity {
+		transferTransactionEx, err = g.Solidity.TriggerConstantContract(ctx, transferContract)
+	} else {
+		transferTransactionEx, err = g.Client.TriggerConstantContract(ctx, transferContract)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -431,7 +493,7 @@ func (g *GrpcClient) FreezeBalance(ownerKey *ecdsa.PrivateKey,
 		PublicKey).Bytes()
 	freezeBalanceContract.FrozenBalance = frozenBalance
 	freezeBalanceContract.FrozenDuration = frozenDuration
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	freezeBalanceTransaction, err := g.Client.FreezeBalance(ctx, freezeBalanceContract)
 	if err != nil {
@@ -452,7 +514,7 @@ func (g *GrpcClient) FreezeBalance(ownerKey *ecdsa.PrivateKey,
 func (g *GrpcClient) UnfreezeBalance(ownerKey *ecdsa.PrivateKey) (*api.Return, error) {
 	unfreezeBalanceContract := new(core.UnfreezeBalanceContract)
 	unfreezeBalanceContract.OwnerAddress = hdwallet.PubkeyToTronAddress(ownerKey.PublicKey).Bytes()
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	unfreezeBalanceTransaction, err := g.Client.UnfreezeBalance(ctx, unfreezeBalanceContract)
 	if err != nil {
@@ -538,7 +600,7 @@ func (g *GrpcClient) CreateAssetIssue(ownerKey *ecdsa.PrivateKey,
 		assetIssueContract.FrozenSupply = append(assetIssueContract.
 			FrozenSupply, assetIssueContractFrozenSupply)
 	}
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	assetIssueTransaction, err := g.Client.CreateAssetIssue(ctx, assetIssueContract)
 	if err != nil {
@@ -569,7 +631,7 @@ func (g *GrpcClient) UpdateAssetIssue(ownerKey *ecdsa.PrivateKey,
 	updateAssetContract.Url = []byte(urlStr)
 	updateAssetContract.NewLimit = newLimit
 	updateAssetContract.NewPublicLimit = newPublicLimit
-	ctx, cancel := ContextTimeout(timeout)
+	ctx, cancel := g.contextTimeout()
 	defer cancel()
 	updateAssetTransaction, err := g.Client.UpdateAsset(ctx, updateAssetContract)
 	if err != nil {
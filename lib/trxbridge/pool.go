@@ -0,0 +1,521 @@
+package trxbridge
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/lib/trxbridge/api"
+	"github.com/LMF709268224/titan-vps/lib/trxbridge/core"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NodeKind distinguishes the full nodes of a pool from its solidity nodes.
+type NodeKind string
+
+const (
+	// FullNode serves the latest blocks and the transactions
+	FullNode NodeKind = "full"
+	// SolidityNode serves the solidified state
+	SolidityNode NodeKind = "solidity"
+)
+
+const (
+	defaultPoolMaxLag        = 20
+	defaultPoolCheckInterval = 10 * time.Second
+	defaultPoolBackoff       = 500 * time.Millisecond
+)
+
+// PoolConfig configures the nodes of a pool and how their calls are made.
+type PoolConfig struct {
+	FullNodes     []string
+	SolidityNodes []string
+	// a node lagging more blocks behind the highest node of its kind is unhealthy
+	MaxLag        int64
+	CheckInterval time.Duration
+	// timeout of each call
+	Timeout time.Duration
+	// a call failing with a connection error is retried on the next healthy node up to Retries times,
+	// the wait before a retry starts at Backoff and doubles with every retry
+	Retries int
+	Backoff time.Duration
+	// Report is called with the status of every node after each health check
+	Report func([]NodeStatus)
+}
+
+// NodeStatus is the health of a node at its last check.
+type NodeStatus struct {
+	Address  string
+	Kind     NodeKind
+	Healthy  bool
+	Height   int64
+	Lag      int64
+	Failures int64
+	Err      string
+}
+
+type poolNode struct {
+	client *GrpcClient
+	kind   NodeKind
+
+	healthy  bool
+	height   int64
+	lag      int64
+	failures int64
+	err      error
+}
+
+// Pool shares the connections to a list of full and solidity nodes. Calls go to the first healthy node
+// of their kind and fail over to the next one, the nodes are checked in the background.
+type Pool struct {
+	cfg PoolConfig
+
+	lk       sync.RWMutex
+	full     []*poolNode
+	solidity []*poolNode
+
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewPool connects to the nodes and starts checking their health.
+func NewPool(cfg PoolConfig) (*Pool, error) {
+	if len(cfg.FullNodes) == 0 {
+		return nil, errors.New("the pool needs a full node")
+	}
+
+	if cfg.MaxLag <= 0 {
+		cfg.MaxLag = defaultPoolMaxLag
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaultPoolCheckInterval
+	}
+	if cfg.Retries < 0 {
+		cfg.Retries = 0
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultPoolBackoff
+	}
+
+	p := &Pool{
+		cfg:     cfg,
+		closing: make(chan struct{}),
+	}
+
+	for _, addr := range cfg.FullNodes {
+		node, err := p.newNode(NewGrpcClient(addr), FullNode)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.full = append(p.full, node)
+	}
+
+	for _, addr := range cfg.SolidityNodes {
+		node, err := p.newNode(NewSolidityGrpcClient(addr), SolidityNode)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.solidity = append(p.solidity, node)
+	}
+
+	p.checkHealth()
+
+	go p.watchHealth()
+
+	return p, nil
+}
+
+func (p *Pool) newNode(client *GrpcClient, kind NodeKind) (*poolNode, error) {
+	client.Timeout = p.cfg.Timeout
+
+	// the connection is made lazily, a node that is down is only unhealthy
+	err := client.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return &poolNode{client: client, kind: kind, healthy: true}, nil
+}
+
+// Close stops the health checks and closes the connections.
+func (p *Pool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.closing)
+
+		for _, node := range append(append([]*poolNode{}, p.full...), p.solidity...) {
+			node.client.Conn.Close()
+		}
+	})
+}
+
+func (p *Pool) watchHealth() {
+	ticker := time.NewTicker(p.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth()
+		case <-p.closing:
+			return
+		}
+	}
+}
+
+// checkHealth asks every node for its height, a node is healthy if it answers
+// and does not lag more than MaxLag blocks behind the highest node of its kind.
+func (p *Pool) checkHealth() {
+	heights := make(map[*poolNode]int64)
+	errs := make(map[*poolNode]error)
+
+	var wg sync.WaitGroup
+	var lk sync.Mutex
+	for _, nodes := range [][]*poolNode{p.full, p.solidity} {
+		for _, node := range nodes {
+			wg.Add(1)
+			go func(node *poolNode) {
+				defer wg.Done()
+
+				block, err := node.client.GetNowBlock()
+
+				lk.Lock()
+				defer lk.Unlock()
+				if err != nil {
+					errs[node] = err
+					return
+				}
+				heights[node] = block.GetBlockHeader().GetRawData().GetNumber()
+			}(node)
+		}
+	}
+	wg.Wait()
+
+	p.lk.Lock()
+	for _, nodes := range [][]*poolNode{p.full, p.solidity} {
+		best := int64(0)
+		for _, node := range nodes {
+			if heights[node] > best {
+				best = heights[node]
+			}
+		}
+
+		for _, node := range nodes {
+			if err, failed := errs[node]; failed {
+				node.healthy = false
+				node.failures++
+				node.err = err
+				continue
+			}
+
+			node.height = heights[node]
+			node.lag = best - node.height
+			node.healthy = node.lag <= p.cfg.MaxLag
+			node.failures = 0
+			node.err = nil
+		}
+	}
+	p.lk.Unlock()
+
+	if p.cfg.Report != nil {
+		p.cfg.Report(p.Status())
+	}
+}
+
+// Status returns the health of the nodes.
+func (p *Pool) Status() []NodeStatus {
+	p.lk.RLock()
+	defer p.lk.RUnlock()
+
+	list := make([]NodeStatus, 0, len(p.full)+len(p.solidity))
+	for _, nodes := range [][]*poolNode{p.full, p.solidity} {
+		for _, node := range nodes {
+			status := NodeStatus{
+				Address:  node.client.Address,
+				Kind:     node.kind,
+				Healthy:  node.healthy,
+				Height:   node.height,
+				Lag:      node.lag,
+				Failures: node.failures,
+			}
+			if node.err != nil {
+				status.Err = node.err.Error()
+			}
+			list = append(list, status)
+		}
+	}
+
+	return list
+}
+
+// pick returns the first healthy node that was not tried yet.
+func (p *Pool) pick(nodes []*poolNode, tried map[*poolNode]bool) *poolNode {
+	p.lk.RLock()
+	defer p.lk.RUnlock()
+
+	for _, node := range nodes {
+		if node.healthy && !tried[node] {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// markFailed takes a node out of the rotation until the next health check.
+func (p *Pool) markFailed(node *poolNode, err error) {
+	p.lk.Lock()
+	defer p.lk.Unlock()
+
+	node.healthy = false
+	node.failures++
+	node.err = err
+}
+
+// Do calls fn with a full node, it is retried on another node if the call fails with a connection error.
+func (p *Pool) Do(fn func(*GrpcClient) error) error {
+	return p.do(p.full, fn)
+}
+
+// DoSolidity calls fn with a solidity node, the full nodes are used if the pool has no solidity node.
+func (p *Pool) DoSolidity(fn func(*GrpcClient) error) error {
+	if len(p.solidity) == 0 {
+		return p.Do(fn)
+	}
+
+	return p.do(p.solidity, fn)
+}
+
+func (p *Pool) do(nodes []*poolNode, fn func(*GrpcClient) error) error {
+	tried := make(map[*poolNode]bool)
+	backoff := p.cfg.Backoff
+
+	var err error
+	for i := 0; i <= p.cfg.Retries; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-p.closing:
+				return err
+			}
+			backoff *= 2
+		}
+
+		node := p.pick(nodes, tried)
+		if node == nil {
+			// every node was tried, the unhealthy ones are given another chance
+			tried = make(map[*poolNode]bool)
+			node = p.pick(nodes, tried)
+		}
+		if node == nil {
+			node = nodes[i%len(nodes)]
+		}
+		tried[node] = true
+
+		err = fn(node.client)
+		if err == nil || !retryable(err) {
+			return err
+		}
+
+		p.markFailed(node, err)
+	}
+
+	return err
+}
+
+// retryable checks if an error comes from the connection to a node rather than from the call itself.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+
+	return false
+}
+
+// GetNowBlock returns the latest block of a full node.
+func (p *Pool) GetNowBlock() (*api.BlockExtention, error) {
+	var block *api.BlockExtention
+	err := p.Do(func(client *GrpcClient) error {
+		var err error
+		block, err = client.GetNowBlock()
+		return err
+	})
+	return block, err
+}
+
+// GetBlockByNum returns a block by its number.
+func (p *Pool) GetBlockByNum(num int64) (*api.BlockExtention, error) {
+	var block *api.BlockExtention
+	err := p.Do(func(client *GrpcClient) error {
+		var err error
+		block, err = client.GetBlockByNum(num)
+		return err
+	})
+	return block, err
+}
+
+// GetBlockByLimitNext returns the blocks from start to end.
+func (p *Pool) GetBlockByLimitNext(start, end int64) (*api.BlockListExtention, error) {
+	var blocks *api.BlockListExtention
+	err := p.Do(func(client *GrpcClient) error {
+		var err error
+		blocks, err = client.GetBlockByLimitNext(start, end)
+		return err
+	})
+	return blocks, err
+}
+
+// GetTransactionByID returns a transaction by its id.
+func (p *Pool) GetTransactionByID(id string) (*core.Transaction, error) {
+	var tx *core.Transaction
+	err := p.Do(func(client *GrpcClient) error {
+		var err error
+		tx, err = client.GetTransactionByID(id)
+		return err
+	})
+	return tx, err
+}
+
+// GetTransactionInfoByID returns the result of a transaction by its id.
+func (p *Pool) GetTransactionInfoByID(id string) (*core.TransactionInfo, error) {
+	var info *core.TransactionInfo
+	err := p.Do(func(client *GrpcClient) error {
+		var err error
+		info, err = client.GetTransactionInfoByID(id)
+		return err
+	})
+	return info, err
+}
+
+// TransactionDropped checks if a transaction expired without being included in a block. A node confirms the drop
+// if its latest block is after the expiration and it has no block for the transaction, the drop needs the confirmation
+// of a solidity node or of two full nodes. A transaction found in a block by any node is not dropped.
+func (p *Pool) TransactionDropped(id string, expiration time.Time) (bool, error) {
+	p.lk.RLock()
+	nodes := append(append([]*poolNode{}, p.solidity...), p.full...)
+	p.lk.RUnlock()
+
+	var solidity, full int
+	var lastErr error
+	for _, node := range nodes {
+		block, err := node.client.GetNowBlock()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if block.GetBlockHeader().GetRawData().GetTimestamp() <= expiration.UnixMilli() {
+			// the node has not seen the end of the window the transaction could be included in
+			continue
+		}
+
+		info, err := node.client.GetTransactionInfoByID(id)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if info.GetBlockNumber() > 0 {
+			return false, nil
+		}
+
+		if node.kind == SolidityNode {
+			solidity++
+		} else {
+			full++
+		}
+	}
+
+	if solidity > 0 || full > 1 {
+		return true, nil
+	}
+
+	return false, lastErr
+}
+
+// GetAccount returns the solidified state of an account.
+func (p *Pool) GetAccount(address string) (*core.Account, error) {
+	var account *core.Account
+	err := p.DoSolidity(func(client *GrpcClient) error {
+		var err error
+		account, err = client.GetAccount(address)
+		return err
+	})
+	return account, err
+}
+
+// GetConstantResultOfContract calls a constant contract method on the solidified state.
+func (p *Pool) GetConstantResultOfContract(ownerKey *ecdsa.PrivateKey, contract string, data []byte) ([][]byte, error) {
+	var result [][]byte
+	err := p.DoSolidity(func(client *GrpcClient) error {
+		var err error
+		result, err = client.GetConstantResultOfContract(ownerKey, contract, data)
+		return err
+	})
+	return result, err
+}
+
+// SignTransfer creates and signs a TRX transfer without broadcasting it.
+func (p *Pool) SignTransfer(ownerKey *ecdsa.PrivateKey, toAddress string, amount int64) (*core.Transaction, string, error) {
+	var tx *core.Transaction
+	var txID string
+	err := p.Do(func(client *GrpcClient) error {
+		var err error
+		tx, txID, err = client.SignTransfer(ownerKey, toAddress, amount)
+		return err
+	})
+	return tx, txID, err
+}
+
+// SignTransferContract creates and signs a contract call without broadcasting it.
+func (p *Pool) SignTransferContract(ownerKey *ecdsa.PrivateKey, contract string, data []byte, feeLimit int64) (*core.Transaction, string, error) {
+	var tx *core.Transaction
+	var txID string
+	err := p.Do(func(client *GrpcClient) error {
+		var err error
+		tx, txID, err = client.SignTransferContract(ownerKey, contract, data, feeLimit)
+		return err
+	})
+	return tx, txID, err
+}
+
+// Broadcast broadcasts a signed transaction, a retry sends the same transaction again.
+func (p *Pool) Broadcast(tx *core.Transaction) error {
+	return p.Do(func(client *GrpcClient) error {
+		return client.Broadcast(tx)
+	})
+}
+
+// Transfer signs and broadcasts a TRX transfer and returns its transaction id.
+func (p *Pool) Transfer(ownerKey *ecdsa.PrivateKey, toAddress string, amount int64) (string, error) {
+	tx, txID, err := p.SignTransfer(ownerKey, toAddress, amount)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.Broadcast(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return txID, nil
+}
+
+// TransferContract signs and broadcasts a contract call and returns its transaction id.
+func (p *Pool) TransferContract(ownerKey *ecdsa.PrivateKey, contract string, data []byte, feeLimit int64) (string, error) {
+	tx, txID, err := p.SignTransferContract(ownerKey, contract, data, feeLimit)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.Broadcast(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return txID, nil
+}
@@ -0,0 +1,52 @@
+package trxbridge
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestPoolFailover(t *testing.T) {
+	p := &Pool{
+		cfg:     PoolConfig{Retries: 2, Backoff: time.Millisecond},
+		closing: make(chan struct{}),
+		full: []*poolNode{
+			{client: NewGrpcClient("a"), kind: FullNode, healthy: true},
+			{client: NewGrpcClient("b"), kind: FullNode, healthy: true},
+		},
+	}
+
+	calls := make([]string, 0)
+	err := p.Do(func(client *GrpcClient) error {
+		calls = append(calls, client.Address)
+		if client.Address == "a" {
+			return status.Error(codes.Unavailable, "connection refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 2 || calls[0] != "a" || calls[1] != "b" {
+		t.Fatalf("unexpected calls %v", calls)
+	}
+
+	if p.full[0].healthy || p.full[0].failures != 1 {
+		t.Fatal("the failed node should be unhealthy")
+	}
+
+	// a call error is returned without failing over
+	calls = calls[:0]
+	callErr := errors.New("contract validate error")
+	err = p.Do(func(client *GrpcClient) error {
+		calls = append(calls, client.Address)
+		return callErr
+	})
+	if err != callErr || len(calls) != 1 || calls[0] != "b" {
+		t.Fatalf("unexpected result %v %v", err, calls)
+	}
+}
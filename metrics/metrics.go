@@ -34,6 +34,9 @@ var (
 
 	Endpoint, _     = tag.NewKey("endpoint")
 	APIInterface, _ = tag.NewKey("api") // to distinguish between gateway api and full node api endpoint calls
+
+	// tron
+	TronNodeKind, _ = tag.NewKey("tron_node_kind")
 )
 
 // Measures
@@ -45,6 +48,12 @@ var (
 
 	// gateway rate limit
 	RateLimitCount = stats.Int64("ratelimit/limited", "rate limited connections", stats.UnitDimensionless)
+
+	// tron node pool
+	TronNodeHealthy  = stats.Int64("tron/node_healthy", "1 if the tron node passed its last health check", stats.UnitDimensionless)
+	TronNodeHeight   = stats.Int64("tron/node_height", "Block height of the tron node", stats.UnitDimensionless)
+	TronNodeLag      = stats.Int64("tron/node_lag", "Blocks the tron node lags behind the highest node of its kind", stats.UnitDimensionless)
+	TronNodeFailures = stats.Int64("tron/node_failures", "Consecutive failed health checks of the tron node", stats.UnitDimensionless)
 )
 
 var (
@@ -60,6 +69,27 @@ var (
 		Measure:     RateLimitCount,
 		Aggregation: view.Count(),
 	}
+
+	TronNodeHealthyView = &view.View{
+		Measure:     TronNodeHealthy,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{Endpoint, TronNodeKind},
+	}
+	TronNodeHeightView = &view.View{
+		Measure:     TronNodeHeight,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{Endpoint, TronNodeKind},
+	}
+	TronNodeLagView = &view.View{
+		Measure:     TronNodeLag,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{Endpoint, TronNodeKind},
+	}
+	TronNodeFailuresView = &view.View{
+		Measure:     TronNodeFailures,
+		Aggregation: view.LastValue(),
+		TagKeys:     []tag.Key{Endpoint, TronNodeKind},
+	}
 )
 
 // DefaultViews is an array of OpenCensus views for metric gathering purposes
@@ -75,6 +105,17 @@ var ChainNodeViews = append([]*view.View{}, DefaultViews...)
 
 var MinerNodeViews = append([]*view.View{}, DefaultViews...)
 
+var TronPoolViews = []*view.View{
+	TronNodeHealthyView,
+	TronNodeHeightView,
+	TronNodeLagView,
+	TronNodeFailuresView,
+}
+
+var MallNodeViews = append(append([]*view.View{}, TronPoolViews...), DefaultViews...)
+
+var TransactionNodeViews = append(append([]*view.View{}, TronPoolViews...), DefaultViews...)
+
 var GatewayNodeViews = append([]*view.View{
 	RateLimitedView,
 }, ChainNodeViews...)
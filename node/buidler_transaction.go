@@ -4,9 +4,12 @@ import (
 	"errors"
 
 	"github.com/LMF709268224/titan-vps/node/impl/transaction"
+	"github.com/LMF709268224/titan-vps/node/modules"
+	chain "github.com/LMF709268224/titan-vps/node/transaction"
 
 	"github.com/LMF709268224/titan-vps/api"
 	"github.com/LMF709268224/titan-vps/node/config"
+	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/LMF709268224/titan-vps/node/repo"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
@@ -41,5 +44,7 @@ func ConfigTransaction(c interface{}) Option {
 	return Options(
 		Override(new(*config.TransactionCfg), cfg),
 		ConfigCommon(&cfg.Common),
+		Override(new(*db.SQLDB), modules.NewTransactionDB),
+		Override(new(*chain.Manager), modules.NewChainManager),
 	)
 }
@@ -5,6 +5,8 @@ import (
 
 	"github.com/LMF709268224/titan-vps/node/account"
 	"github.com/LMF709268224/titan-vps/node/exchange"
+	"github.com/LMF709268224/titan-vps/node/invoice"
+	"github.com/LMF709268224/titan-vps/node/statement"
 	"github.com/LMF709268224/titan-vps/node/user"
 	"github.com/LMF709268224/titan-vps/node/vps"
 
@@ -16,8 +18,6 @@ import (
 	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
 	"github.com/LMF709268224/titan-vps/node/orders"
 	"github.com/LMF709268224/titan-vps/node/repo"
-	"github.com/LMF709268224/titan-vps/node/transaction"
-	"github.com/filecoin-project/pubsub"
 	"go.uber.org/fx"
 
 	"golang.org/x/xerrors"
@@ -54,13 +54,16 @@ func ConfigMall(c interface{}) Option {
 		ConfigCommon(&cfg.Common),
 		Override(new(dtypes.SetMallConfigFunc), modules.NewSetMallConfigFunc),
 		Override(new(dtypes.GetMallConfigFunc), modules.NewGetMallConfigFunc),
-		Override(new(*pubsub.PubSub), modules.NewPubSub),
 		Override(new(dtypes.MetadataDS), modules.Datastore),
 		Override(new(*db.SQLDB), modules.NewDB),
 		Override(new(*account.Cache), modules.NewCache),
-		Override(new(*transaction.Manager), transaction.NewManager),
+		Override(new(api.Transaction), modules.NewTransactionAPI),
 		Override(new(*exchange.RechargeManager), exchange.NewRechargeManager),
 		Override(new(*exchange.WithdrawManager), exchange.NewWithdrawManager),
+		Override(new(*exchange.ChainEventManager), exchange.NewChainEventManager),
+		Override(new(*exchange.ClaimManager), exchange.NewClaimManager),
+		Override(new(*invoice.Manager), invoice.NewManager),
+		Override(new(*statement.Manager), statement.NewManager),
 		Override(new(*orders.Manager), modules.NewStorageManager),
 		Override(new(*vps.Manager), vps.NewManager),
 		Override(new(*user.Manager), user.NewManager),
@@ -38,6 +38,41 @@ func DefaultMallCfg() *MallCfg {
 		AliyunAccessKeyID:     "",
 		AliyunAccessKeySecret: "",
 		DatabaseAddress:       "",
+		ChainCfg:              defaultChainCfg(),
+		WithdrawAddressLock:   Duration(24 * time.Hour),
+		DepositClaim: DepositClaimCfg{
+			HourlyLimit: 5,
+			DailyLimit:  20,
+			MaxOpen:     3,
+		},
+	}
+}
+
+// defaultChainCfg returns the default chain config
+func defaultChainCfg() ChainCfg {
+	return ChainCfg{
+		TrxConfirmations: 19,
+		TrxOrphanBlocks:  200,
+		TrxPool: TrxPoolCfg{
+			MaxLag:        20,
+			CheckInterval: Duration(10 * time.Second),
+			Timeout:       Duration(5 * time.Second),
+			Retries:       2,
+			Backoff:       Duration(500 * time.Millisecond),
+		},
+		TrxSweep: TrxSweepCfg{
+			Threshold: "10000000",
+			TopUp:     30000000,
+			FeeLimit:  40000000,
+			Interval:  Duration(time.Hour),
+		},
+		TrxWithdraw: TrxWithdrawCfg{
+			FeeLimit:    40000000,
+			MaxAttempts: 3,
+			Interval:    Duration(time.Minute),
+		},
+		FvmToken:         TokenCfg{Decimals: 18},
+		FvmConfirmations: 20,
 	}
 }
 
@@ -46,11 +81,12 @@ func DefaultTransactionCfg() *TransactionCfg {
 	return &TransactionCfg{
 		Common: Common{
 			API: API{
-				ListenAddress:       "0.0.0.0:5577",
+				ListenAddress:       "0.0.0.0:5578",
 				RemoteListenAddress: "",
 			},
 		},
 		DatabaseAddress: "",
+		ChainCfg:        defaultChainCfg(),
 	}
 }
 
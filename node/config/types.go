@@ -20,8 +20,11 @@ type API struct {
 // TransactionCfg transaction config
 type TransactionCfg struct {
 	Common
-	// database address
+	// database address, it must be the database of the mall: the mall creates the tables of the transaction node
+	// and refuses to start with a transaction node on another database
 	DatabaseAddress string
+
+	ChainCfg
 }
 
 // MallCfg base config
@@ -36,19 +39,84 @@ type MallCfg struct {
 
 	DatabaseAddress string
 
+	// chain settings of the chain served in process if TransactionAPI.InProcess is set
+	ChainCfg
+	// transaction node serving the chain, it must use the database of the mall, it is checked when the mall starts
+	TransactionAPI TransactionAPICfg
+
+	// fees charged on the withdrawals of each paid token, a token without a fee is withdrawn for free
+	WithdrawFees []WithdrawFeeCfg
+	// distinct admin approvals needed by the withdrawals of each amount tier, a withdrawal needs one approval if no tier matches
+	WithdrawApprovalTiers []ApprovalTierCfg
+	// a confirmed address of the withdrawal address book is usable after the lock period
+	WithdrawAddressLock Duration
+	// rate limits of the deposit claims of a user
+	DepositClaim DepositClaimCfg
+
+	Email EmailConfig
+}
+
+// DepositClaimCfg limits the deposit claims of a user, a limit of 0 is not checked
+type DepositClaimCfg struct {
+	HourlyLimit int64
+	DailyLimit  int64
+	// claims of a user pending or waiting for review
+	MaxOpen int64
+}
+
+// ChainCfg configures the chains watched for deposits and the payouts,
+// they are served by the transaction node.
+type ChainCfg struct {
+	// local stand-in chain, no chain is watched or paid on and deposits are simulated with SimulateDeposit
+	StandIn bool
+
 	TitanContractorAddr string
 	LotusWsAddr         string
 	LotusHTTPSAddr      string
 	PrivateKeyStr       string
 	PaymentAddresses    []string
+	// FEVM deposit token, ContractAddr defaults to TitanContractorAddr
+	FvmToken         TokenCfg
+	FvmConfirmations int64
 
-	TrxHTTPSAddr      string
+	TrxHTTPSAddr string
+	// full nodes shared by the tron consumers, TrxHTTPSAddr is used if it is empty
+	TrxNodes []string
+	// solidity nodes serving the account and contract reads, the full nodes serve them if it is empty
+	TrxSolidityNodes  []string
+	TrxPool           TrxPoolCfg
 	TrxContractorAddr string
+	// extended public key of m/44'/195'/0'/0, the deposit address of a user is derived from it at the user index.
+	// The RechargeAddresses pool is used if it is empty
+	TrxXpub           string
 	RechargeAddresses []string
+	// accepted TRC20 deposit tokens, TrxContractorAddr is accepted as 6-decimal USDT if the list is empty
+	TrxTokens []TokenCfg
+	// native TRX deposits are converted at the price returned by TrxRateURL, TrxRate is used if it is empty or unreachable
+	TrxRateURL string
+	TrxRate    string
+	// native TRX deposits are parked for admin review instead of credited
+	TrxReview bool
 
-	TrxHeight int64
+	TrxHeight        int64
+	TrxConfirmations int64
+	TrxSweep         TrxSweepCfg
+	TrxWithdraw      TrxWithdrawCfg
 
-	Email EmailConfig
+	// a pending deposit that is in no block for this many blocks is orphaned, it is retried until then
+	TrxOrphanBlocks int64
+
+	// EVM chains watched for ERC-20 deposits
+	EvmChains []EvmChainCfg
+}
+
+// TransactionAPICfg is the address of a transaction node and an admin token of it
+type TransactionAPICfg struct {
+	// e.g. http://127.0.0.1:5578/rpc/v0
+	Address string
+	Token   string
+	// serve the chain in the mall process with ChainCfg instead of a transaction node, for development only
+	InProcess bool
 }
 
 type RouteCfg struct {
@@ -58,6 +126,88 @@ type RouteCfg struct {
 	SecretKey   string
 }
 
+// TrxPoolCfg configures the health checks of the tron nodes and how their calls are retried
+type TrxPoolCfg struct {
+	// a node lagging more blocks behind the highest node of its kind is skipped
+	MaxLag        int64
+	CheckInterval Duration
+	// timeout of each call
+	Timeout Duration
+	// a call failing with a connection error is retried on the next healthy node, waiting Backoff doubled at each retry
+	Retries int
+	Backoff Duration
+}
+
+// TrxSweepCfg sweeps the TRC20 tokens of the derived deposit addresses to the collection address
+type TrxSweepCfg struct {
+	Enabled bool
+	// extended private key of m/44'/195'/0'/0 matching TrxXpub, it must only be configured on the node running the sweeper
+	Xprv string
+	// hex private key of the wallet paying the TRX for energy
+	FeeKey         string
+	CollectionAddr string
+	// swept token, TrxContractorAddr is used if it is empty
+	ContractAddr string
+	// minimum token balance swept, in the smallest unit of the token
+	Threshold string
+	// a deposit address holding less TRX is topped up to it before the token transfer, in sun
+	TopUp int64
+	// fee limit of the token transfer in sun
+	FeeLimit int64
+	Interval Duration
+}
+
+// TrxWithdrawCfg pays out the approved withdrawals as TRC20 transfers from the hot wallet
+type TrxWithdrawCfg struct {
+	Enabled bool
+	// hex private key of the hot wallet
+	HotWalletKey string
+	// paid token, TrxContractorAddr is used if it is empty
+	ContractAddr string
+	// fee limit of the token transfer in sun
+	FeeLimit int64
+	// a payout failing more times is marked failed for manual handling
+	MaxAttempts int64
+	Interval    Duration
+}
+
+// WithdrawFeeCfg is the fee charged on the withdrawals of a token, the amounts are in the settlement currency.
+// Fixed plus Percent of the value is charged, Min is charged if it is more
+type WithdrawFeeCfg struct {
+	ContractAddr string
+	Fixed        string
+	// e.g. 0.5 for 0.5%
+	Percent string
+	Min     string
+}
+
+// ApprovalTierCfg is the number of distinct admin approvals needed by the withdrawals of at least MinValue
+type ApprovalTierCfg struct {
+	MinValue  string
+	Approvals int64
+}
+
+// EvmChainCfg is an EVM chain watched for ERC-20 deposits
+type EvmChainCfg struct {
+	// name of the chain used by GetRechargeAddress, e.g. ethereum
+	Name          string
+	RPCAddr       string
+	Confirmations int64
+	Tokens        []TokenCfg
+	// deposit addresses assigned to the users
+	Addresses []string
+}
+
+// TokenCfg is a token accepted for deposits
+type TokenCfg struct {
+	ContractAddr string
+	Symbol       string
+	Decimals     int64
+	// minimum deposit in the smallest unit of the token
+	MinDeposit string
+	Enabled    bool
+}
+
 type EmailConfig struct {
 	Name     string
 	SMTPHost string
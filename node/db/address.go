@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/LMF709268224/titan-vps/api/types"
+	"golang.org/x/xerrors"
 )
 
 // SaveRechargeAddresses inserts multiple addresses into the database.
@@ -28,6 +29,55 @@ func (d *SQLDB) AssignUserToRechargeAddress(addr, userID string) error {
 	return err
 }
 
+// AssignDerivedRechargeAddress assigns the address derived at the next index to a user,
+// derive returns the address of an index.
+func (d *SQLDB) AssignDerivedRechargeAddress(userID string, derive func(index int64) (string, error)) (string, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("AssignDerivedRechargeAddress Rollback err:%s", err.Error())
+		}
+	}()
+
+	var index int64
+	query := fmt.Sprintf("SELECT COALESCE(MAX(derive_index), -1) + 1 FROM %s FOR UPDATE", rechargeAddressTable)
+	err = tx.Get(&index, query)
+	if err != nil {
+		return "", err
+	}
+
+	addr, err := derive(index)
+	if err != nil {
+		return "", err
+	}
+
+	// the unique index fails a concurrent assignment of the same index
+	query = fmt.Sprintf(`INSERT INTO %s (addr, user_id, derive_index) VALUES (?, ?, ?)`, rechargeAddressTable)
+	_, err = tx.Exec(query, addr, userID, index)
+	if err != nil {
+		return "", err
+	}
+
+	return addr, tx.Commit()
+}
+
+// LoadDerivedRechargeAddresses retrieves the recharge addresses derived from the extended public key.
+func (d *SQLDB) LoadDerivedRechargeAddresses() ([]*types.DerivedAddress, error) {
+	var infos []*types.DerivedAddress
+	query := fmt.Sprintf("SELECT addr, derive_index FROM %s WHERE derive_index IS NOT NULL", rechargeAddressTable)
+	err := d.db.Select(&infos, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
 // LoadUserByRechargeAddress retrieves the user associated with a recharge address.
 func (d *SQLDB) LoadUserByRechargeAddress(addr string) (string, error) {
 	var info string
@@ -67,7 +117,7 @@ func (d *SQLDB) LoadUnusedRechargeAddress() (string, error) {
 // LoadUsedRechargeAddresses retrieves all user-assigned recharge addresses.
 func (d *SQLDB) LoadUsedRechargeAddresses() ([]types.RechargeAddress, error) {
 	var infos []types.RechargeAddress
-	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id !='' ", rechargeAddressTable)
+	query := fmt.Sprintf("SELECT addr, user_id FROM %s WHERE user_id !='' ", rechargeAddressTable)
 	err := d.db.Select(&infos, query)
 	if err != nil {
 		return nil, err
@@ -81,7 +131,7 @@ func (d *SQLDB) LoadRechargeAddresses(limit, page int64) (*types.GetRechargeAddr
 	out := new(types.GetRechargeAddressResponse)
 
 	var infos []*types.RechargeAddress
-	query := fmt.Sprintf("SELECT * FROM %s order by user_id desc LIMIT ? OFFSET ?", rechargeAddressTable)
+	query := fmt.Sprintf("SELECT addr, user_id FROM %s order by user_id desc LIMIT ? OFFSET ?", rechargeAddressTable)
 	if limit > loadAddressesDefaultLimit {
 		limit = loadAddressesDefaultLimit
 	}
@@ -103,3 +153,137 @@ func (d *SQLDB) LoadRechargeAddresses(limit, page int64) (*types.GetRechargeAddr
 
 	return out, nil
 }
+
+// SavePaymentAddresses inserts multiple FEVM payment addresses into the database.
+func (d *SQLDB) SavePaymentAddresses(addresses []string) error {
+	for _, addr := range addresses {
+		query := fmt.Sprintf(
+			`INSERT INTO %s (addr) VALUES (?)`, paymentAddressTable)
+		d.db.Exec(query, addr)
+	}
+
+	return nil
+}
+
+// AssignUserToPaymentAddress assigns a user to a FEVM payment address.
+func (d *SQLDB) AssignUserToPaymentAddress(addr, userID string) error {
+	query := fmt.Sprintf(`UPDATE %s SET user_id=? WHERE addr=? AND user_id="" `, paymentAddressTable)
+	result, err := d.db.Exec(query, userID, addr)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("payment address %s is assigned", addr)
+	}
+
+	return nil
+}
+
+// LoadPaymentAddressByUser retrieves the FEVM payment address associated with a user.
+func (d *SQLDB) LoadPaymentAddressByUser(userID string) (string, error) {
+	var info string
+	query := fmt.Sprintf("SELECT addr FROM %s WHERE user_id=?", paymentAddressTable)
+	err := d.db.Get(&info, query, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	return info, nil
+}
+
+// LoadUnusedPaymentAddress retrieves an unused FEVM payment address, it is empty if every address is assigned.
+func (d *SQLDB) LoadUnusedPaymentAddress() (string, error) {
+	var addr string
+	query := fmt.Sprintf("SELECT addr FROM %s WHERE user_id='' limit 1 ", paymentAddressTable)
+	err := d.db.Get(&addr, query)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	return addr, nil
+}
+
+// LoadUsedPaymentAddresses retrieves all user-assigned FEVM payment addresses.
+func (d *SQLDB) LoadUsedPaymentAddresses() ([]types.RechargeAddress, error) {
+	var infos []types.RechargeAddress
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id !='' ", paymentAddressTable)
+	err := d.db.Select(&infos, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// SaveEvmAddresses inserts multiple deposit addresses of an EVM chain into the database.
+func (d *SQLDB) SaveEvmAddresses(chain string, addresses []string) error {
+	for _, addr := range addresses {
+		query := fmt.Sprintf(
+			`INSERT INTO %s (chain, addr) VALUES (?, ?)`, evmAddressTable)
+		d.db.Exec(query, chain, addr)
+	}
+
+	return nil
+}
+
+// AssignUserToEvmAddress assigns a user to a deposit address of an EVM chain.
+func (d *SQLDB) AssignUserToEvmAddress(chain, addr, userID string) error {
+	query := fmt.Sprintf(`UPDATE %s SET user_id=? WHERE chain=? AND addr=? AND user_id="" `, evmAddressTable)
+	result, err := d.db.Exec(query, userID, chain, addr)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("%s deposit address %s is assigned", chain, addr)
+	}
+
+	return nil
+}
+
+// LoadEvmAddressByUser retrieves the deposit address of an EVM chain associated with a user.
+func (d *SQLDB) LoadEvmAddressByUser(chain, userID string) (string, error) {
+	var info string
+	query := fmt.Sprintf("SELECT addr FROM %s WHERE chain=? AND user_id=?", evmAddressTable)
+	err := d.db.Get(&info, query, chain, userID)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	return info, nil
+}
+
+// LoadUnusedEvmAddress retrieves an unused deposit address of an EVM chain, it is empty if every address is assigned.
+func (d *SQLDB) LoadUnusedEvmAddress(chain string) (string, error) {
+	var addr string
+	query := fmt.Sprintf("SELECT addr FROM %s WHERE chain=? AND user_id='' limit 1 ", evmAddressTable)
+	err := d.db.Get(&addr, query, chain)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+
+	return addr, nil
+}
+
+// LoadUsedEvmAddresses retrieves all user-assigned deposit addresses of an EVM chain.
+func (d *SQLDB) LoadUsedEvmAddresses(chain string) ([]types.RechargeAddress, error) {
+	var infos []types.RechargeAddress
+	query := fmt.Sprintf("SELECT addr, user_id FROM %s WHERE chain=? AND user_id !='' ", evmAddressTable)
+	err := d.db.Select(&infos, query, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
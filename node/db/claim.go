@@ -0,0 +1,165 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"golang.org/x/xerrors"
+)
+
+// SaveDepositClaim saves a new deposit claim, it returns false if the user already claimed the transaction.
+func (d *SQLDB) SaveDepositClaim(info *types.DepositClaim) (bool, error) {
+	query := fmt.Sprintf(
+		`INSERT IGNORE INTO %s (claim_id, user_id, chain, tx_hash, state) 
+		        VALUES (:claim_id, :user_id, :chain, :tx_hash, :state)`, depositClaimTable)
+	result, err := d.db.NamedExec(query, info)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// LoadDepositClaim loads a deposit claim.
+func (d *SQLDB) LoadDepositClaim(claimID string) (*types.DepositClaim, error) {
+	var info types.DepositClaim
+	query := fmt.Sprintf("SELECT * FROM %s WHERE claim_id=?", depositClaimTable)
+	err := d.db.Get(&info, query, claimID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// LoadUserDepositClaim loads the claim of a user on a transaction.
+func (d *SQLDB) LoadUserDepositClaim(userID, chain, txHash string) (*types.DepositClaim, error) {
+	var info types.DepositClaim
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=? AND chain=? AND tx_hash=?", depositClaimTable)
+	err := d.db.Get(&info, query, userID, chain, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// UpdateDepositClaim moves a deposit claim from a state with the reason and the admin of the move.
+func (d *SQLDB) UpdateDepositClaim(claimID string, newState, oldState types.DepositClaimState, reason types.DepositClaimReason, msg, adminID string) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, reason=?, msg=?, admin_id=?, updated_time=NOW() WHERE claim_id=? AND state=?`, depositClaimTable)
+	result, err := d.db.Exec(query, newState, reason, msg, adminID, claimID, oldState)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("deposit claim %s is not in state %s", claimID, oldState)
+	}
+
+	return nil
+}
+
+// CreditDepositClaim accepts a claim waiting for review with the admin and the reason of the credit,
+// the recharge record of the claim is saved as done and credited to the user balance in the same transaction.
+func (d *SQLDB) CreditDepositClaim(claimID, adminID, msg string, rInfo *types.RechargeRecord) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("CreditDepositClaim Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET state=?, msg=?, admin_id=?, updated_time=NOW() WHERE claim_id=? AND state=?`, depositClaimTable)
+	result, err := tx.Exec(query, types.DepositClaimAccepted, msg, adminID, claimID, types.DepositClaimReview)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("deposit claim %s is not in state %s", claimID, types.DepositClaimReview)
+	}
+
+	err = creditRecharge(tx, rInfo)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CountDepositClaims counts the claims of a user made since a time.
+func (d *SQLDB) CountDepositClaims(userID string, since time.Time) (int64, error) {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE user_id=? AND created_time>=?", depositClaimTable)
+	err := d.db.Get(&count, query, userID, since)
+
+	return count, err
+}
+
+// CountOpenDepositClaims counts the claims of a user that are not settled.
+func (d *SQLDB) CountOpenDepositClaims(userID string) (int64, error) {
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE user_id=? AND state in (?,?)", depositClaimTable)
+	err := d.db.Get(&count, query, userID, types.DepositClaimPending, types.DepositClaimReview)
+
+	return count, err
+}
+
+// LoadDepositClaimsByUser loads the deposit claims of a user with pagination.
+func (d *SQLDB) LoadDepositClaimsByUser(userID string, limit, page int64) (*types.DepositClaimResponse, error) {
+	return d.loadDepositClaims("user_id=?", userID, limit, page)
+}
+
+// LoadDepositClaims loads the deposit claims in a state with pagination.
+func (d *SQLDB) LoadDepositClaims(state types.DepositClaimState, limit, page int64) (*types.DepositClaimResponse, error) {
+	return d.loadDepositClaims("state=?", state, limit, page)
+}
+
+func (d *SQLDB) loadDepositClaims(where string, arg interface{}, limit, page int64) (*types.DepositClaimResponse, error) {
+	out := new(types.DepositClaimResponse)
+
+	if limit > loadDepositClaimsDefaultLimit {
+		limit = loadDepositClaimsDefaultLimit
+	}
+
+	var infos []*types.DepositClaim
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s order by created_time desc LIMIT ? OFFSET ?", depositClaimTable, where)
+	err := d.db.Select(&infos, query, arg, limit, page*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", depositClaimTable, where)
+	var count int
+	err = d.db.Get(&count, countQuery, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Total = count
+	out.List = infos
+
+	return out, nil
+}
@@ -2,6 +2,9 @@ package db
 
 import (
 	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
 )
 
 // ConfigType config type
@@ -10,8 +13,17 @@ type ConfigType string
 const (
 	// ConfigTronHeight is used for storing the height of scanned blocks.
 	ConfigTronHeight ConfigType = "tron_height"
+	// ConfigFvmHeight is used for storing the height of scanned FEVM blocks.
+	ConfigFvmHeight ConfigType = "fvm_height"
+	// ConfigDatabaseID is used for storing the id of the database, the mall and its transaction node must share one database.
+	ConfigDatabaseID ConfigType = "database_id"
 )
 
+// EvmHeightConfig is used for storing the height of scanned blocks of an EVM chain.
+func EvmHeightConfig(chain string) ConfigType {
+	return ConfigType("evm_height_" + chain)
+}
+
 // SaveConfigValue saves a configuration value.
 func (d *SQLDB) SaveConfigValue(key ConfigType, value string) error {
 	query := fmt.Sprintf(
@@ -27,3 +39,17 @@ func (d *SQLDB) LoadConfigValue(key ConfigType, out interface{}) error {
 	query := fmt.Sprintf("SELECT value FROM %s WHERE name=?", configTable)
 	return d.db.Get(out, query, key)
 }
+
+// DatabaseID returns the id of the database, it is created the first time it is loaded.
+func (d *SQLDB) DatabaseID() (string, error) {
+	query := fmt.Sprintf(`INSERT IGNORE INTO %s (name, value) VALUES (?, ?)`, configTable)
+	_, err := d.db.Exec(query, ConfigDatabaseID, strings.ReplaceAll(uuid.NewString(), "-", ""))
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	err = d.LoadConfigValue(ConfigDatabaseID, &id)
+
+	return id, err
+}
@@ -4,14 +4,27 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/LMF709268224/titan-vps/api/types"
 	"github.com/jmoiron/sqlx"
+	"golang.org/x/xerrors"
 )
 
-// SaveRechargeRecordAndUserBalance saves recharge information and updates user balance.
-func (d *SQLDB) SaveRechargeRecordAndUserBalance(rInfo *types.RechargeRecord, balance, oldBalance string) error {
+// SaveRechargeRecord saves a recharge record, the user balance is credited when the record is confirmed.
+func (d *SQLDB) SaveRechargeRecord(rInfo *types.RechargeRecord) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (order_id, chain, from_addr, to_addr, value, state, user_id, token, raw_value, rate, block_number, block_hash) 
+		        VALUES (:order_id, :chain, :from_addr, :to_addr, :value, :state, :user_id, :token, :raw_value, :rate, :block_number, :block_hash)`, rechargeRecordTable)
+	_, err := d.db.NamedExec(query, rInfo)
+
+	return err
+}
+
+// CreditRechargeRecord saves a recharge record whose block is already confirmed as done and credits its value
+// to the user balance, a record that exists is not saved again.
+func (d *SQLDB) CreditRechargeRecord(rInfo *types.RechargeRecord) error {
 	tx, err := d.db.Beginx()
 	if err != nil {
 		return err
@@ -20,20 +33,73 @@ func (d *SQLDB) SaveRechargeRecordAndUserBalance(rInfo *types.RechargeRecord, ba
 	defer func() {
 		err = tx.Rollback()
 		if err != nil && err != sql.ErrTxDone {
-			log.Errorf("SaveRechargeRecordAndUserBalance Rollback err:%s", err.Error())
+			log.Errorf("CreditRechargeRecord Rollback err:%s", err.Error())
 		}
 	}()
 
+	err = creditRecharge(tx, rInfo)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// creditRecharge saves a recharge record as done and credits its value to the user balance.
+func creditRecharge(tx *sqlx.Tx, rInfo *types.RechargeRecord) error {
+	rInfo.State = types.RechargeDone
+
 	query := fmt.Sprintf(
-		`INSERT INTO %s (order_id, from_addr, to_addr, value, state,  user_id) 
-		        VALUES (:order_id, :from_addr, :to_addr, :value, :state, :user_id)`, rechargeRecordTable)
-	_, err = tx.NamedExec(query, rInfo)
+		`INSERT INTO %s (order_id, chain, from_addr, to_addr, value, state, user_id, token, raw_value, rate, block_number, block_hash, done_time) 
+		        VALUES (:order_id, :chain, :from_addr, :to_addr, :value, :state, :user_id, :token, :raw_value, :rate, :block_number, :block_hash, NOW())`, rechargeRecordTable)
+	_, err := tx.NamedExec(query, rInfo)
+	if err != nil {
+		return err
+	}
+
+	entries, err := userJournal(rInfo.UserID, types.LedgerDeposits, rInfo.Value, types.LedgerRefRecharge, rInfo.OrderID)
+	if err != nil {
+		return err
+	}
+
+	return postLedger(tx, entries)
+}
+
+// ConfirmRechargeRecord marks a pending or reviewed recharge record as done and credits its value to the user balance.
+func (d *SQLDB) ConfirmRechargeRecord(rInfo *types.RechargeRecord, oldState types.RechargeState) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("ConfirmRechargeRecord Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET state=?, done_time=NOW() WHERE order_id=? AND state=?`, rechargeRecordTable)
+	result, err := tx.Exec(query, types.RechargeDone, rInfo.OrderID, oldState)
 	if err != nil {
 		return err
 	}
 
-	query = fmt.Sprintf(`UPDATE %s SET balance=? WHERE user_id=? AND balance=?`, userTable)
-	_, err = tx.Exec(query, balance, rInfo.UserID, oldBalance)
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("recharge %s is not in state %s", rInfo.OrderID, oldState.String())
+	}
+
+	entries, err := userJournal(rInfo.UserID, types.LedgerDeposits, rInfo.Value, types.LedgerRefRecharge, rInfo.OrderID)
+	if err != nil {
+		return err
+	}
+
+	err = postLedger(tx, entries)
 	if err != nil {
 		return err
 	}
@@ -41,6 +107,34 @@ func (d *SQLDB) SaveRechargeRecordAndUserBalance(rInfo *types.RechargeRecord, ba
 	return tx.Commit()
 }
 
+// UpdateRechargeRecordBlock updates the block of a pending recharge record that was included in another block.
+func (d *SQLDB) UpdateRechargeRecordBlock(orderID string, blockNumber int64, blockHash string) error {
+	query := fmt.Sprintf(`UPDATE %s SET block_number=?, block_hash=? WHERE order_id=? AND state=?`, rechargeRecordTable)
+	_, err := d.db.Exec(query, blockNumber, blockHash, orderID, types.RechargeCreate)
+
+	return err
+}
+
+// UpdateRechargeRecordState updates the state of a recharge record.
+func (d *SQLDB) UpdateRechargeRecordState(orderID string, newState, oldState types.RechargeState) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, done_time=NOW() WHERE order_id=? AND state=?`, rechargeRecordTable)
+	result, err := d.db.Exec(query, newState, orderID, oldState)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("recharge %s is not in state %s", orderID, oldState.String())
+	}
+
+	return nil
+}
+
 // RechargeRecordExists checks if a recharge order exists.
 func (d *SQLDB) RechargeRecordExists(orderID string) (bool, error) {
 	var total int64
@@ -77,8 +171,9 @@ func (d *SQLDB) LoadRechargeRecords(state types.RechargeState) ([]*types.Recharg
 	return infos, nil
 }
 
-// SaveWithdrawInfoAndUserBalance saves withdraw information and updates user balance.
-func (d *SQLDB) SaveWithdrawInfoAndUserBalance(rInfo *types.WithdrawRecord, balance, oldBalance string) error {
+// SaveWithdrawInfoAndUserBalance saves withdraw information and holds its value on the user balance until it is approved or rejected.
+// The withdrawal is saved if check accepts it against the withdraw policy and the usage of the user.
+func (d *SQLDB) SaveWithdrawInfoAndUserBalance(rInfo *types.WithdrawRecord, check func(*types.WithdrawPolicy, *WithdrawUsage) error) error {
 	tx, err := d.db.Beginx()
 	if err != nil {
 		return err
@@ -91,16 +186,30 @@ func (d *SQLDB) SaveWithdrawInfoAndUserBalance(rInfo *types.WithdrawRecord, bala
 		}
 	}()
 
+	policy, err := lockWithdrawPolicy(tx)
+	if err != nil {
+		return err
+	}
+
+	usage, err := loadWithdrawUsage(tx, rInfo.UserID, policy)
+	if err != nil {
+		return err
+	}
+
+	err = check(policy, usage)
+	if err != nil {
+		return err
+	}
+
 	query := fmt.Sprintf(
-		`INSERT INTO %s (order_id, value, state, withdraw_addr, withdraw_hash,  user_id) 
-		        VALUES (:order_id,  :value, :state, :withdraw_addr, :withdraw_hash, :user_id)`, withdrawRecordTable)
+		`INSERT INTO %s (order_id, value, fee, net_value, required_approvals, state, withdraw_addr, withdraw_hash,  user_id, creator) 
+		        VALUES (:order_id,  :value, :fee, :net_value, :required_approvals, :state, :withdraw_addr, :withdraw_hash, :user_id, :creator)`, withdrawRecordTable)
 	_, err = tx.NamedExec(query, rInfo)
 	if err != nil {
 		return err
 	}
 
-	query = fmt.Sprintf(`UPDATE %s SET balance=? WHERE user_id=? AND balance=?`, userTable)
-	_, err = tx.Exec(query, balance, rInfo.UserID, oldBalance)
+	err = placeHold(tx, &types.BalanceHold{RefType: types.LedgerRefWithdraw, RefID: rInfo.OrderID, UserID: rInfo.UserID, Amount: rInfo.Value})
 	if err != nil {
 		return err
 	}
@@ -129,6 +238,337 @@ func (d *SQLDB) UpdateWithdrawRecord(info *types.WithdrawRecord, newState types.
 	return err
 }
 
+// ApproveWithdrawRecord marks a withdraw record paid out by hand as done and captures its hold to the withdrawals account,
+// the record must still be in info.State with info.WithdrawHash.
+func (d *SQLDB) ApproveWithdrawRecord(info *types.WithdrawRecord) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("ApproveWithdrawRecord Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET state=?, done_time=NOW(), withdraw_hash=?, executor=? WHERE order_id=? AND state=?`, withdrawRecordTable)
+	result, err := tx.Exec(query, types.WithdrawDone, info.WithdrawHash, info.Executor, info.OrderID, info.State)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("withdraw %s is not in state %s", info.OrderID, info.State)
+	}
+
+	err = settleWithdrawHold(tx, info)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RejectWithdrawal records the rejection of an admin on a created or failed withdraw record and returns the record,
+// the rejection completing the tier of the record refunds it and releases its hold in the same transaction.
+// An admin deciding on a created record again is not counted twice, the approvals of a failed record are replaced by rejections.
+// A failed record must still have the withdrawHash checked by the admin.
+// It returns ErrWithdrawNotPending if the record can no longer be rejected.
+func (d *SQLDB) RejectWithdrawal(orderID, adminID, withdrawHash string) (*types.WithdrawRecord, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("RejectWithdrawal Rollback err:%s", err.Error())
+		}
+	}()
+
+	var info types.WithdrawRecord
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id=? FOR UPDATE", withdrawRecordTable)
+	err = tx.Get(&info, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.State != types.WithdrawCreate && (info.State != types.WithdrawFailed || info.WithdrawHash != withdrawHash) {
+		return nil, ErrWithdrawNotPending
+	}
+
+	if info.State == types.WithdrawCreate {
+		var total int64
+		countSQL := fmt.Sprintf(`SELECT count(order_id) FROM %s WHERE order_id=? AND admin_id=?`, withdrawApprovalTable)
+		err = tx.Get(&total, countSQL, orderID, adminID)
+		if err != nil {
+			return nil, err
+		}
+
+		if total > 0 {
+			return &info, nil
+		}
+	}
+
+	err = saveWithdrawDecision(tx, orderID, adminID, types.ApprovalReject)
+	if err != nil {
+		return nil, err
+	}
+
+	var rejections int64
+	countSQL := fmt.Sprintf(`SELECT count(order_id) FROM %s WHERE order_id=? AND decision=?`, withdrawApprovalTable)
+	err = tx.Get(&rejections, countSQL, orderID, types.ApprovalReject)
+	if err != nil {
+		return nil, err
+	}
+
+	if rejections >= info.Required {
+		info.State = types.WithdrawRefund
+		info.Executor = adminID
+		query = fmt.Sprintf(`UPDATE %s SET state=?, done_time=NOW(), executor=? WHERE order_id=?`, withdrawRecordTable)
+		_, err = tx.Exec(query, info.State, info.Executor, orderID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = settleHold(tx, types.LedgerRefWithdraw, orderID, "0", types.LedgerWithdrawals)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// ApproveWithdrawal records the approval of an admin on a created withdraw record and returns the record with the approvals it has,
+// an admin approving it again is not counted twice. The approval completing the tier moves the record in the same transaction:
+// it is queued for the payout executor if queue is set, otherwise it is marked as done with the withdrawHash of a manual payout.
+// It returns ErrWithdrawNotPending if the record is no longer waiting for approvals.
+func (d *SQLDB) ApproveWithdrawal(orderID, adminID, withdrawHash string, queue bool) (*types.WithdrawRecord, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("ApproveWithdrawal Rollback err:%s", err.Error())
+		}
+	}()
+
+	var info types.WithdrawRecord
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id=? FOR UPDATE", withdrawRecordTable)
+	err = tx.Get(&info, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.State != types.WithdrawCreate || info.Approvals >= info.Required {
+		return nil, ErrWithdrawNotPending
+	}
+
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT count(order_id) FROM %s WHERE order_id=? AND admin_id=?`, withdrawApprovalTable)
+	err = tx.Get(&total, countSQL, orderID, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if total > 0 {
+		return &info, nil
+	}
+
+	info.Approvals++
+	if withdrawHash != "" && info.Approvals < info.Required {
+		return nil, xerrors.Errorf("withdraw %s needs %d approvals before it is paid out", orderID, info.Required)
+	}
+
+	if withdrawHash == "" && !queue && info.Approvals >= info.Required {
+		return nil, xerrors.Errorf("withdraw %s needs the hash of its manual payout", orderID)
+	}
+
+	err = saveWithdrawDecision(tx, orderID, adminID, types.ApprovalApprove)
+	if err != nil {
+		return nil, err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET approvals=approvals+1 WHERE order_id=?`, withdrawRecordTable)
+	_, err = tx.Exec(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Approvals >= info.Required {
+		info.Executor = adminID
+		if queue {
+			info.State = types.WithdrawApproved
+			query = fmt.Sprintf(`UPDATE %s SET state=?, executor=? WHERE order_id=?`, withdrawRecordTable)
+			_, err = tx.Exec(query, info.State, info.Executor, orderID)
+		} else {
+			info.State = types.WithdrawDone
+			info.WithdrawHash = withdrawHash
+			query = fmt.Sprintf(`UPDATE %s SET state=?, done_time=NOW(), withdraw_hash=?, executor=? WHERE order_id=?`, withdrawRecordTable)
+			_, err = tx.Exec(query, info.State, info.WithdrawHash, info.Executor, orderID)
+			if err == nil {
+				err = settleWithdrawHold(tx, &info)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// LoadWithdrawApprovals loads the admin decisions on a withdraw record.
+func (d *SQLDB) LoadWithdrawApprovals(orderID string) ([]*types.WithdrawApproval, error) {
+	var infos []*types.WithdrawApproval
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id=? order by created_time", withdrawApprovalTable)
+	err := d.db.Select(&infos, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+func saveWithdrawDecision(tx *sqlx.Tx, orderID, adminID string, decision types.ApprovalDecision) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (order_id, admin_id, decision) VALUES (?, ?, ?)
+				ON DUPLICATE KEY UPDATE decision=?, created_time=NOW()`, withdrawApprovalTable)
+	_, err := tx.Exec(query, orderID, adminID, decision, decision)
+
+	return err
+}
+
+// LoadApprovedWithdrawRecords loads the approved withdraw records, their payouts are submitted to the transaction node.
+func (d *SQLDB) LoadApprovedWithdrawRecords() ([]*types.WithdrawRecord, error) {
+	var infos []*types.WithdrawRecord
+	query := fmt.Sprintf("SELECT * FROM %s WHERE state=? order by created_time", withdrawRecordTable)
+	err := d.db.Select(&infos, query, types.WithdrawApproved)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// BroadcastWithdrawRecord saves the payout transaction of a withdraw record reported by the transaction node,
+// a report with no more attempts than the saved transaction is stale and ignored.
+func (d *SQLDB) BroadcastWithdrawRecord(payout *types.WithdrawPayout) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, withdraw_hash=?, tx_expiration=?, attempts=?, msg=""
+	    WHERE order_id=? AND state in (?,?,?) AND attempts<?`, withdrawRecordTable)
+	_, err := d.db.Exec(query, types.WithdrawBroadcast, payout.TxHash, payout.TxExpiration, payout.Attempts,
+		payout.OrderID, types.WithdrawApproved, types.WithdrawBroadcast, types.WithdrawFailed, payout.Attempts)
+
+	return err
+}
+
+// FailWithdrawRecord marks a withdraw record whose payout failed for manual handling,
+// a report older than the saved transaction is stale and ignored.
+func (d *SQLDB) FailWithdrawRecord(payout *types.WithdrawPayout) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, msg=? WHERE order_id=? AND state in (?,?) AND attempts<=?`, withdrawRecordTable)
+	_, err := d.db.Exec(query, types.WithdrawFailed, payout.Msg, payout.OrderID, types.WithdrawApproved, types.WithdrawBroadcast, payout.Attempts)
+
+	return err
+}
+
+// RetryWithdrawRecord approves a failed withdraw record again once its payout is retried by the transaction node,
+// a record whose new transaction is reported already is kept.
+func (d *SQLDB) RetryWithdrawRecord(orderID string) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, msg="" WHERE order_id=? AND state=?`, withdrawRecordTable)
+	_, err := d.db.Exec(query, types.WithdrawApproved, orderID, types.WithdrawFailed)
+
+	return err
+}
+
+// ConfirmWithdrawRecord marks a withdraw record whose payout is confirmed as done with the hash of the payout
+// and captures its hold to the withdrawals account.
+func (d *SQLDB) ConfirmWithdrawRecord(info *types.WithdrawRecord, txHash string) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("ConfirmWithdrawRecord Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET state=?, withdraw_hash=?, done_time=NOW() WHERE order_id=? AND state=?`, withdrawRecordTable)
+	result, err := tx.Exec(query, types.WithdrawDone, txHash, info.OrderID, info.State)
+	if err != nil {
+		return err
+	}
+
+	err = checkWithdrawUpdated(result, info.OrderID, info.State)
+	if err != nil {
+		return err
+	}
+
+	err = settleWithdrawHold(tx, info)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// settleWithdrawHold captures the hold of a paid out withdrawal to the withdrawals account and moves its fee to the fees account.
+func settleWithdrawHold(tx *sqlx.Tx, info *types.WithdrawRecord) error {
+	err := settleHold(tx, types.LedgerRefWithdraw, info.OrderID, info.Value, types.LedgerWithdrawals)
+	if err != nil {
+		return err
+	}
+
+	fee, ok := new(big.Int).SetString(info.Fee, 10)
+	if !ok || fee.Sign() <= 0 {
+		return nil
+	}
+
+	entries := []*types.LedgerEntry{
+		{Account: types.LedgerWithdrawals, UserID: info.UserID, Amount: new(big.Int).Neg(fee).String(), RefType: types.LedgerRefWithdraw, RefID: info.OrderID},
+		{Account: types.LedgerFees, UserID: info.UserID, Amount: fee.String(), RefType: types.LedgerRefWithdraw, RefID: info.OrderID},
+	}
+
+	return postLedger(tx, entries)
+}
+
+func checkWithdrawUpdated(result sql.Result, orderID string, state types.WithdrawState) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("withdraw %s is not in state %s", orderID, state)
+	}
+
+	return nil
+}
+
 // LoadWithdrawRecords loads withdraw records with optional filters.
 func (d *SQLDB) LoadWithdrawRecords(limit, page int64, statuses []types.WithdrawState, userID, startDate, endDate string) (*types.GetWithdrawResponse, error) {
 	out := new(types.GetWithdrawResponse)
@@ -0,0 +1,218 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/xerrors"
+)
+
+// placeHold moves the amount of a hold from the user balance to the held balance and saves the hold,
+// it is written in the transaction of the business record.
+func placeHold(tx *sqlx.Tx, info *types.BalanceHold) error {
+	info.State = types.HoldPlaced
+	info.Captured = "0"
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (ref_type, ref_id, user_id, amount, captured, state)
+		        VALUES (:ref_type, :ref_id, :user_id, :amount, :captured, :state)`, balanceHoldTable)
+	_, err := tx.NamedExec(query, info)
+	if err != nil {
+		return err
+	}
+
+	entries, err := userJournal(info.UserID, types.LedgerHeld, "-"+info.Amount, info.RefType, info.RefID)
+	if err != nil {
+		return err
+	}
+
+	return postLedger(tx, entries)
+}
+
+// settleHold captures a part of a placed hold to a platform account and releases the rest to the user balance.
+// It returns sql.ErrNoRows if the hold does not exist.
+func settleHold(tx *sqlx.Tx, refType types.LedgerRefType, refID, captured string, account types.LedgerAccount) error {
+	var info types.BalanceHold
+	query := fmt.Sprintf("SELECT * FROM %s WHERE ref_type=? AND ref_id=? FOR UPDATE", balanceHoldTable)
+	err := tx.Get(&info, query, refType, refID)
+	if err != nil {
+		return err
+	}
+
+	if info.State != types.HoldPlaced {
+		return xerrors.Errorf("hold %s %s is not in state %s", refType, refID, types.HoldPlaced.String())
+	}
+
+	amount, ok := new(big.Int).SetString(info.Amount, 10)
+	if !ok {
+		return xerrors.Errorf("invalid hold amount %s", info.Amount)
+	}
+
+	capture, ok := new(big.Int).SetString(captured, 10)
+	if !ok || capture.Sign() < 0 || capture.Cmp(amount) > 0 {
+		return xerrors.Errorf("can not capture %s of hold %s %s", captured, refType, refID)
+	}
+
+	release := new(big.Int).Sub(amount, capture)
+
+	state := types.HoldCaptured
+	if capture.Sign() == 0 {
+		state = types.HoldReleased
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET state=?, captured=?, done_time=NOW() WHERE ref_type=? AND ref_id=?`, balanceHoldTable)
+	_, err = tx.Exec(query, state, capture.String(), refType, refID)
+	if err != nil {
+		return err
+	}
+
+	entries := []*types.LedgerEntry{
+		{Account: types.LedgerHeld, UserID: info.UserID, Amount: new(big.Int).Neg(amount).String(), RefType: refType, RefID: refID},
+	}
+
+	// a hold captured in full releases nothing, no empty entry is shown on the statement
+	if release.Sign() > 0 {
+		entries = append(entries, &types.LedgerEntry{Account: types.LedgerUser, UserID: info.UserID, Amount: release.String(), RefType: refType, RefID: refID})
+	}
+
+	if capture.Sign() > 0 {
+		entries = append(entries, &types.LedgerEntry{Account: account, UserID: info.UserID, Amount: capture.String(), RefType: refType, RefID: refID})
+	}
+
+	return postLedger(tx, entries)
+}
+
+// PlaceHold holds the value of a pending record on the user balance, a hold that exists is not placed again.
+func (d *SQLDB) PlaceHold(refType types.LedgerRefType, refID, userID, value string) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("PlaceHold Rollback err:%s", err.Error())
+		}
+	}()
+
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT count(ref_id) FROM %s WHERE ref_type=? AND ref_id=?`, balanceHoldTable)
+	err = tx.Get(&total, countSQL, refType, refID)
+	if err != nil {
+		return err
+	}
+
+	if total > 0 {
+		return nil
+	}
+
+	err = placeHold(tx, &types.BalanceHold{RefType: refType, RefID: refID, UserID: userID, Amount: value})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CaptureHold captures a part of a placed hold to a platform account and releases the rest to the user balance.
+// It returns sql.ErrNoRows if the hold does not exist.
+func (d *SQLDB) CaptureHold(refType types.LedgerRefType, refID, captured string, account types.LedgerAccount) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("CaptureHold Rollback err:%s", err.Error())
+		}
+	}()
+
+	err = settleHold(tx, refType, refID, captured, account)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReleaseHold releases a placed hold to the user balance.
+// It returns sql.ErrNoRows if the hold does not exist.
+func (d *SQLDB) ReleaseHold(refType types.LedgerRefType, refID string) error {
+	return d.CaptureHold(refType, refID, "0", types.LedgerUser)
+}
+
+// LoadHold loads a balance hold.
+func (d *SQLDB) LoadHold(refType types.LedgerRefType, refID string) (*types.BalanceHold, error) {
+	var info types.BalanceHold
+	query := fmt.Sprintf("SELECT * FROM %s WHERE ref_type=? AND ref_id=?", balanceHoldTable)
+	err := d.db.Get(&info, query, refType, refID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// migrateWithdrawHolds places the holds of the withdrawals created before holds existed,
+// their value has already left the user balance and is moved from the withdrawals account to the held balance.
+func (d *SQLDB) migrateWithdrawHolds() error {
+	var infos []*types.WithdrawRecord
+	query := fmt.Sprintf(`SELECT * FROM %s w WHERE w.state=? AND NOT EXISTS
+		(SELECT ref_id FROM %s h WHERE h.ref_type=? AND h.ref_id=w.order_id)`, withdrawRecordTable, balanceHoldTable)
+	err := d.db.Select(&infos, query, types.WithdrawCreate, types.LedgerRefWithdraw)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		err = d.migrateWithdrawHold(info)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *SQLDB) migrateWithdrawHold(info *types.WithdrawRecord) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("migrateWithdrawHold Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (ref_type, ref_id, user_id, amount, captured, state)
+		        VALUES (?, ?, ?, ?, '0', ?)`, balanceHoldTable)
+	_, err = tx.Exec(query, types.LedgerRefWithdraw, info.OrderID, info.UserID, info.Value, types.HoldPlaced)
+	if err != nil {
+		return err
+	}
+
+	v, ok := new(big.Int).SetString(info.Value, 10)
+	if !ok {
+		return xerrors.Errorf("invalid withdraw value %s", info.Value)
+	}
+
+	err = postLedger(tx, []*types.LedgerEntry{
+		{Account: types.LedgerWithdrawals, UserID: info.UserID, Amount: new(big.Int).Neg(v).String(), RefType: types.LedgerRefWithdraw, RefID: info.OrderID},
+		{Account: types.LedgerHeld, UserID: info.UserID, Amount: v.String(), RefType: types.LedgerRefWithdraw, RefID: info.OrderID},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+// ReserveIdempotencyKey saves a pending idempotency record, it returns false if the key is already used by the user.
+func (d *SQLDB) ReserveIdempotencyKey(info *types.IdempotencyRecord) (bool, error) {
+	query := fmt.Sprintf(
+		`INSERT IGNORE INTO %s (user_id, idem_key, method, request_hash) 
+		        VALUES (:user_id, :idem_key, :method, :request_hash)`, idempotencyTable)
+	result, err := d.db.NamedExec(query, info)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// LoadIdempotencyRecord loads the idempotency record of a user key.
+func (d *SQLDB) LoadIdempotencyRecord(userID, key string) (*types.IdempotencyRecord, error) {
+	var info types.IdempotencyRecord
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=? AND idem_key=?", idempotencyTable)
+	err := d.db.Get(&info, query, userID, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// CompleteIdempotencyRecord saves the response of a request made with an idempotency key.
+func (d *SQLDB) CompleteIdempotencyRecord(userID, key, response string) error {
+	query := fmt.Sprintf(`UPDATE %s SET done=true, response=? WHERE user_id=? AND idem_key=?`, idempotencyTable)
+	_, err := d.db.Exec(query, response, userID, key)
+
+	return err
+}
+
+// DeleteIdempotencyRecord deletes the idempotency record of a user key.
+func (d *SQLDB) DeleteIdempotencyRecord(userID, key string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id=? AND idem_key=?`, idempotencyTable)
+	_, err := d.db.Exec(query, userID, key)
+
+	return err
+}
+
+// DeleteExpiredIdempotencyRecords deletes the idempotency records created before the given time.
+func (d *SQLDB) DeleteExpiredIdempotencyRecords(before time.Time) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE created_time<?`, idempotencyTable)
+	_, err := d.db.Exec(query, before)
+
+	return err
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+// SaveBillingInfo saves the billing details of a user.
+func (d *SQLDB) SaveBillingInfo(info *types.BillingInfo) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (user_id, company_name, tax_id, address, country, email)
+		        VALUES (:user_id, :company_name, :tax_id, :address, :country, :email)
+				ON DUPLICATE KEY UPDATE company_name=:company_name, tax_id=:tax_id, address=:address,
+				country=:country, email=:email`, billingInfoTable)
+	_, err := d.db.NamedExec(query, info)
+
+	return err
+}
+
+// LoadBillingInfo loads the billing details of a user.
+func (d *SQLDB) LoadBillingInfo(userID string) (*types.BillingInfo, error) {
+	var info types.BillingInfo
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=?", billingInfoTable)
+	err := d.db.Get(&info, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// SaveInvoice saves an invoice with the next sequential invoice number.
+func (d *SQLDB) SaveInvoice(info *types.Invoice) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveInvoice Rollback err:%s", err.Error())
+		}
+	}()
+
+	var no int64
+	query := fmt.Sprintf("SELECT COALESCE(MAX(invoice_no), 0) FROM %s FOR UPDATE", invoiceTable)
+	err = tx.Get(&no, query)
+	if err != nil {
+		return err
+	}
+
+	info.InvoiceNo = no + 1
+
+	query = fmt.Sprintf(
+		`INSERT INTO %s (invoice_no, invoice_type, ref_id, user_id, currency, usd_rate, total, billing_info, line_items)
+		        VALUES (:invoice_no, :invoice_type, :ref_id, :user_id, :currency, :usd_rate, :total, :billing_info, :line_items)`, invoiceTable)
+	_, err = tx.NamedExec(query, info)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// InvoiceExists checks if an invoice of the referenced order or refund exists.
+func (d *SQLDB) InvoiceExists(invoiceType types.InvoiceType, refID string) (bool, error) {
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT count(invoice_no) FROM %s WHERE invoice_type=? AND ref_id=?`, invoiceTable)
+	if err := d.db.Get(&total, countSQL, invoiceType, refID); err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
+
+// LoadInvoice loads an invoice.
+func (d *SQLDB) LoadInvoice(invoiceNo int64) (*types.Invoice, error) {
+	var info types.Invoice
+	query := fmt.Sprintf("SELECT * FROM %s WHERE invoice_no=?", invoiceTable)
+	err := d.db.Get(&info, query, invoiceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// LoadInvoicesByUser loads the invoices of a user with pagination.
+func (d *SQLDB) LoadInvoicesByUser(userID string, limit, page int64) (*types.InvoiceResponse, error) {
+	out := new(types.InvoiceResponse)
+
+	if limit > loadInvoicesDefaultLimit {
+		limit = loadInvoicesDefaultLimit
+	}
+
+	var infos []*types.Invoice
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=? order by invoice_no desc LIMIT ? OFFSET ?", invoiceTable)
+	err := d.db.Select(&infos, query, userID, limit, page*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE user_id=?", invoiceTable)
+	var count int
+	err = d.db.Get(&count, countQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Total = count
+	out.List = infos
+
+	return out, nil
+}
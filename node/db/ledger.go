@@ -0,0 +1,262 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/xerrors"
+)
+
+var (
+	// ErrInsufficientBalance is returned when a journal would make a user balance negative.
+	ErrInsufficientBalance = xerrors.New("insufficient balance")
+	// ErrBalanceConflict is returned when the balance saved with a user does not match the ledger.
+	ErrBalanceConflict = xerrors.New("user balance conflicts with the ledger")
+	// ErrWithdrawNotPending is returned when a withdraw record is no longer waiting for the decisions of the admins.
+	ErrWithdrawNotPending = xerrors.New("withdraw is not waiting for decisions")
+)
+
+// userJournal returns the entries moving value from a platform account to a user balance,
+// a negative value moves it from the user balance to the platform account.
+func userJournal(userID string, account types.LedgerAccount, value string, refType types.LedgerRefType, refID string) ([]*types.LedgerEntry, error) {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, xerrors.Errorf("invalid ledger amount %s", value)
+	}
+
+	return []*types.LedgerEntry{
+		{Account: types.LedgerUser, UserID: userID, Amount: v.String(), RefType: refType, RefID: refID},
+		{Account: account, UserID: userID, Amount: new(big.Int).Neg(v).String(), RefType: refType, RefID: refID},
+	}, nil
+}
+
+// postLedger writes a journal in the transaction of its business record and updates the balances of the users it touches.
+// The journal fails if its entries do not sum to zero, if a user balance would become negative
+// or if a saved balance does not match the ledger.
+func postLedger(tx *sqlx.Tx, entries []*types.LedgerEntry) error {
+	sum := new(big.Int)
+	users := make([]string, 0)
+	for _, entry := range entries {
+		v, ok := new(big.Int).SetString(entry.Amount, 10)
+		if !ok {
+			return xerrors.Errorf("invalid ledger amount %s", entry.Amount)
+		}
+		sum.Add(sum, v)
+
+		if entry.Account == types.LedgerUser {
+			users = append(users, entry.UserID)
+		}
+	}
+
+	if sum.Sign() != 0 {
+		return xerrors.Errorf("ledger journal is unbalanced by %s", sum.String())
+	}
+
+	// lock the users in the same order to avoid deadlocks between journals
+	sort.Strings(users)
+
+	balances := make(map[string]string)
+	for _, userID := range users {
+		if _, ok := balances[userID]; ok {
+			continue
+		}
+
+		var balance string
+		query := fmt.Sprintf("SELECT balance FROM %s WHERE user_id=? FOR UPDATE", userTable)
+		err := tx.Get(&balance, query, userID)
+		if err != nil {
+			return err
+		}
+
+		err = checkLedgerBalance(tx, userID, balance)
+		if err != nil {
+			return err
+		}
+
+		balances[userID] = balance
+	}
+
+	txID := uuid.NewString()
+	query := fmt.Sprintf(
+		`INSERT INTO %s (tx_id, account, user_id, amount, ref_type, ref_id)
+		        VALUES (:tx_id, :account, :user_id, :amount, :ref_type, :ref_id)`, ledgerEntryTable)
+	for _, entry := range entries {
+		entry.TxID = txID
+
+		_, err := tx.NamedExec(query, entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	for userID, original := range balances {
+		balance, err := loadLedgerBalance(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if balance.Sign() < 0 {
+			return ErrInsufficientBalance
+		}
+
+		query := fmt.Sprintf(`UPDATE %s SET balance=? WHERE user_id=? AND balance=?`, userTable)
+		result, err := tx.Exec(query, balance.String(), userID, original)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 && balance.String() != original {
+			log.Errorf("balance of %s changed outside of the ledger", userID)
+			return ErrBalanceConflict
+		}
+	}
+
+	return nil
+}
+
+// checkLedgerBalance reports a saved user balance that does not match the ledger.
+func checkLedgerBalance(tx *sqlx.Tx, userID, balance string) error {
+	ledger, err := loadLedgerBalance(tx, userID)
+	if err != nil {
+		return err
+	}
+
+	saved, ok := new(big.Int).SetString(balance, 10)
+	if !ok || saved.Cmp(ledger) != 0 {
+		log.Errorf("balance of %s is %s, the ledger balance is %s", userID, balance, ledger.String())
+		return ErrBalanceConflict
+	}
+
+	return nil
+}
+
+// loadLedgerBalance sums the ledger entries of a user balance.
+func loadLedgerBalance(tx *sqlx.Tx, userID string) (*big.Int, error) {
+	var sum string
+	query := fmt.Sprintf("SELECT CAST(COALESCE(SUM(amount), 0) AS CHAR) FROM %s WHERE account=? AND user_id=?", ledgerEntryTable)
+	err := tx.Get(&sum, query, types.LedgerUser, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := new(big.Int).SetString(sum, 10)
+	if !ok {
+		return nil, xerrors.Errorf("invalid ledger balance %s", sum)
+	}
+
+	return v, nil
+}
+
+// RefundOrder reverses the revenue of an order paid without a hold to the user balance,
+// an order that was refunded already is not refunded again.
+func (d *SQLDB) RefundOrder(orderID, userID, value string) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("RefundOrder Rollback err:%s", err.Error())
+		}
+	}()
+
+	posted, err := ledgerPosted(tx, userID, types.LedgerRefOrderRefund, orderID)
+	if err != nil {
+		return err
+	}
+
+	if posted {
+		return nil
+	}
+
+	entries, err := userJournal(userID, types.LedgerRevenue, value, types.LedgerRefOrderRefund, orderID)
+	if err != nil {
+		return err
+	}
+
+	err = postLedger(tx, entries)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ledgerPosted checks if a journal of a record was posted to a user balance,
+// the user is locked so that a concurrent journal of the record waits for the check.
+func ledgerPosted(tx *sqlx.Tx, userID string, refType types.LedgerRefType, refID string) (bool, error) {
+	var balance string
+	query := fmt.Sprintf("SELECT balance FROM %s WHERE user_id=? FOR UPDATE", userTable)
+	err := tx.Get(&balance, query, userID)
+	if err != nil {
+		return false, err
+	}
+
+	var total int64
+	query = fmt.Sprintf("SELECT count(id) FROM %s WHERE account=? AND user_id=? AND ref_type=? AND ref_id=?", ledgerEntryTable)
+	err = tx.Get(&total, query, types.LedgerUser, userID, refType, refID)
+	if err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
+
+// migrateLedger posts the opening balance of the users saved before the ledger existed
+// and reports the users whose balance does not match the ledger.
+func (d *SQLDB) migrateLedger() error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("migrateLedger Rollback err:%s", err.Error())
+		}
+	}()
+
+	opening := `INSERT INTO %s (tx_id, account, user_id, amount, ref_type, ref_id)
+		SELECT CONCAT('opening-', u.user_id), ?, u.user_id, %s, ?, u.user_id FROM %s u
+		WHERE u.balance!='0' AND NOT EXISTS (SELECT id FROM %s l WHERE l.account=? AND l.user_id=u.user_id)`
+
+	// the platform entries are written first as the user entries mark the user as migrated
+	query := fmt.Sprintf(opening, ledgerEntryTable, "-CAST(u.balance AS DECIMAL(65,0))", userTable, ledgerEntryTable)
+	_, err = tx.Exec(query, types.LedgerDeposits, types.LedgerRefOpening, types.LedgerUser)
+	if err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(opening, ledgerEntryTable, "CAST(u.balance AS DECIMAL(65,0))", userTable, ledgerEntryTable)
+	_, err = tx.Exec(query, types.LedgerUser, types.LedgerRefOpening, types.LedgerUser)
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+	query = fmt.Sprintf(`SELECT u.user_id FROM %s u WHERE CAST(u.balance AS DECIMAL(65,0))!=(SELECT COALESCE(SUM(l.amount), 0)
+		FROM %s l WHERE l.account=? AND l.user_id=u.user_id)`, userTable, ledgerEntryTable)
+	err = tx.Select(&conflicts, query, types.LedgerUser)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range conflicts {
+		log.Errorf("balance of %s conflicts with the ledger", userID)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+// columnMigration is a column added to a table after the table was first created,
+// the tables are created with "if not exists" so a database saved before the column has to be altered.
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// columnMigrations are applied in the order the columns were added.
+var columnMigrations = []columnMigration{
+	{userInstancesTable, "user_data", "TEXT NULL"},
+	{orderRecordTable, "amount", "INT DEFAULT 1"},
+	{rechargeRecordTable, "block_number", "BIGINT(20) DEFAULT 0"},
+	{rechargeRecordTable, "block_hash", "VARCHAR(128) DEFAULT \"\""},
+	{rechargeRecordTable, "token", "VARCHAR(16) DEFAULT \"\""},
+	{rechargeRecordTable, "raw_value", "VARCHAR(64) DEFAULT 0"},
+	{rechargeRecordTable, "rate", "VARCHAR(32) DEFAULT \"1\""},
+	{rechargeRecordTable, "chain", "VARCHAR(32) DEFAULT \"tron\""},
+	{rechargeAddressTable, "derive_index", "BIGINT(20) DEFAULT NULL"},
+	{withdrawRecordTable, "attempts", "INT DEFAULT 0"},
+	{withdrawRecordTable, "tx_expiration", "BIGINT(20) DEFAULT 0"},
+	{withdrawRecordTable, "msg", "VARCHAR(256) DEFAULT \"\""},
+	{withdrawRecordTable, "fee", "VARCHAR(32) DEFAULT \"0\""},
+	{withdrawRecordTable, "net_value", "VARCHAR(32) DEFAULT \"\""},
+	{withdrawRecordTable, "approvals", "INT DEFAULT 0"},
+	{withdrawRecordTable, "required_approvals", "INT DEFAULT 1"},
+	{withdrawRecordTable, "creator", "VARCHAR(128) DEFAULT \"\""},
+	{userInstancesTable, "reinstall_state", "INT DEFAULT 0"},
+	{userInstancesTable, "reinstall_msg", "VARCHAR(256) DEFAULT ''"},
+	{withdrawSettingTable, "email", "VARCHAR(128) DEFAULT \"\""},
+}
+
+// nullFill is a NULL-able column read into a string, its NULL values are replaced by the value,
+// e.g. a TEXT column that can not have a default value.
+type nullFill struct {
+	table  string
+	column string
+	value  string
+}
+
+var nullFills = []nullFill{
+	{userInstancesTable, "user_data", ""},
+}
+
+// indexMigration is an index added to a table after the table was first created.
+type indexMigration struct {
+	table      string
+	name       string
+	definition string
+}
+
+var indexMigrations = []indexMigration{
+	{rechargeRecordTable, "idx_state", "KEY idx_state (state)"},
+	{rechargeAddressTable, "uk_derive_index", "UNIQUE KEY uk_derive_index (derive_index)"},
+	{withdrawRecordTable, "idx_state", "KEY idx_state (state)"},
+}
+
+// widenMigration is a text column widened after the table was first created.
+type widenMigration struct {
+	table      string
+	column     string
+	length     int
+	definition string
+}
+
+var widenMigrations = []widenMigration{
+	{configTable, "name", 64, "VARCHAR(64) DEFAULT \"\""},
+}
+
+// migrateColumns adds the columns and indexes missing, fills the NULL values of the string columns and widens the columns too narrow
+// from a database saved by an older version, it can run any number of times.
+func (d *SQLDB) migrateColumns() error {
+	for _, m := range columnMigrations {
+		exist, err := d.columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			continue
+		}
+
+		log.Infof("add column %s to %s", m.column, m.table)
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		_, err = d.db.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range nullFills {
+		query := fmt.Sprintf("UPDATE %s SET %s=? WHERE %s IS NULL", m.table, m.column, m.column)
+		_, err := d.db.Exec(query, m.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range widenMigrations {
+		length, err := d.columnLength(m.table, m.column)
+		if err != nil {
+			return err
+		}
+
+		if length >= m.length {
+			continue
+		}
+
+		log.Infof("widen column %s of %s to %d", m.column, m.table, m.length)
+
+		query := fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", m.table, m.column, m.definition)
+		_, err = d.db.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range indexMigrations {
+		exist, err := d.indexExists(m.table, m.name)
+		if err != nil {
+			return err
+		}
+
+		if exist {
+			continue
+		}
+
+		log.Infof("add index %s to %s", m.name, m.table)
+
+		query := fmt.Sprintf("ALTER TABLE %s ADD %s", m.table, m.definition)
+		_, err = d.db.Exec(query)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateChainTracking copies the pending tron deposits and the payouts in progress to the tables of the transaction node,
+// the transaction node tracked them in the recharge and withdraw records of a database saved by an older version.
+// It can run any number of times, a deposit or a payout tracked already is kept.
+func (d *SQLDB) migrateChainTracking() error {
+	query := fmt.Sprintf(`INSERT IGNORE INTO %s (tx_hash, token, block_number, block_hash, state)
+	    SELECT order_id, token, block_number, block_hash, state FROM %s WHERE chain=? AND state=?`, tronDepositTable, rechargeRecordTable)
+	_, err := d.db.Exec(query, types.ChainTron, types.RechargeCreate)
+	if err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`INSERT IGNORE INTO %s (order_id, withdraw_addr, value, state, tx_hash, tx_expiration, attempts, msg)
+	    SELECT order_id, withdraw_addr, IF(net_value='', value, net_value), state, withdraw_hash, tx_expiration, attempts, msg
+	    FROM %s WHERE state IN (?, ?, ?)`, payoutTable, withdrawRecordTable)
+	_, err = d.db.Exec(query, types.WithdrawApproved, types.WithdrawBroadcast, types.WithdrawFailed)
+
+	return err
+}
+
+// tableExists checks if the current database has a table.
+func (d *SQLDB) tableExists(table string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=?`
+	err := d.db.Get(&count, query, table)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// columnExists checks if a table of the current database has a column.
+func (d *SQLDB) columnExists(table, column string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM information_schema.COLUMNS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND COLUMN_NAME=?`
+	err := d.db.Get(&count, query, table, column)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// columnLength returns the maximum length of a text column of a table in the current database.
+func (d *SQLDB) columnLength(table, column string) (int, error) {
+	var length int
+	query := `SELECT COALESCE(CHARACTER_MAXIMUM_LENGTH, 0) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND COLUMN_NAME=?`
+	err := d.db.Get(&length, query, table, column)
+	if err != nil {
+		return 0, err
+	}
+
+	return length, nil
+}
+
+// indexExists checks if a table of the current database has an index.
+func (d *SQLDB) indexExists(table, name string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM information_schema.STATISTICS WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND INDEX_NAME=?`
+	err := d.db.Get(&count, query, table, name)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
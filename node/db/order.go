@@ -2,19 +2,21 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 
 	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/utils"
 	"github.com/jmoiron/sqlx"
 )
 
 // SaveOrderInfo saves order information.
 func (d *SQLDB) SaveOrderInfo(rInfo *types.OrderRecord) error {
 	query := fmt.Sprintf(
-		`INSERT INTO %s (order_id, value, state, done_state, vps_id, msg, user_id, order_type, cycle_time) 
-		        VALUES (:order_id, :value, :state, :done_state, :vps_id, :msg, :user_id, :order_type, :cycle_time)
+		`INSERT INTO %s (order_id, value, state, done_state, vps_id, msg, user_id, order_type, cycle_time, amount) 
+		        VALUES (:order_id, :value, :state, :done_state, :vps_id, :msg, :user_id, :order_type, :cycle_time, :amount)
 				ON DUPLICATE KEY UPDATE state=:state, done_state=:done_state, done_time=NOW(), user_id=:user_id,
-				value=:value, vps_id=:vps_id, msg=:msg, order_type=:order_type, cycle_time=:cycle_time`, orderRecordTable)
+				value=:value, vps_id=:vps_id, msg=:msg, order_type=:order_type, cycle_time=:cycle_time, amount=:amount`, orderRecordTable)
 	_, err := d.db.NamedExec(query, rInfo)
 
 	return err
@@ -145,3 +147,230 @@ func (d *SQLDB) LoadOrderRecordsByVpsID(vpsID int64, state types.OrderState, don
 
 	return infos, nil
 }
+
+// SaveOrderItems saves the items of an order together with an instance record per item,
+// the instance is saved with the value of its item and the id of the instance is set on the item.
+// The items refer to saved instances if instance is nil.
+func (d *SQLDB) SaveOrderItems(instance *types.InstanceDetails, items []*types.OrderItem) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveOrderItems Rollback err:%s", err.Error())
+		}
+	}()
+
+	if instance != nil {
+		iQuery := fmt.Sprintf(insertInstanceQuery, userInstancesTable)
+		for _, item := range items {
+			instance.Value = item.Value
+
+			result, err := tx.NamedExec(iQuery, instance)
+			if err != nil {
+				return err
+			}
+
+			item.VpsID, err = result.LastInsertId()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (order_id, item_index, vps_id, instance_id, value, refund, usd_rate, state, msg) 
+		        VALUES (:order_id, :item_index, :vps_id, :instance_id, :value, :refund, :usd_rate, :state, :msg)`, orderItemTable)
+	for _, item := range items {
+		_, err = tx.NamedExec(query, item)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// LoadOrderItems loads the items of an order.
+func (d *SQLDB) LoadOrderItems(orderID string) ([]*types.OrderItem, error) {
+	var infos []*types.OrderItem
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id=? order by item_index asc", orderItemTable)
+
+	err := d.db.Select(&infos, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// LoadOrderItemsByVpsID loads the order items of a specific vps id with the specified state.
+func (d *SQLDB) LoadOrderItemsByVpsID(vpsID int64, state types.OrderItemState) ([]*types.OrderItem, error) {
+	var infos []*types.OrderItem
+	query := fmt.Sprintf("SELECT * FROM %s WHERE vps_id=? AND state=?", orderItemTable)
+
+	err := d.db.Select(&infos, query, vpsID, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// UpdateOrderItem updates the fulfilment state of an order item.
+func (d *SQLDB) UpdateOrderItem(item *types.OrderItem) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, instance_id=?, msg=?, done_time=NOW() WHERE order_id=? AND item_index=?`, orderItemTable)
+	_, err := d.db.Exec(query, item.State, item.InstanceID, item.Msg, item.OrderID, item.ItemIndex)
+
+	return err
+}
+
+// RefundOrderItems refunds every item of an order that has not been fulfilled or refunded yet,
+// marks them as failed and reverses their revenue to the user balance. It returns the refunded value.
+func (d *SQLDB) RefundOrderItems(orderID, userID string) (string, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return "0", err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("RefundOrderItems Rollback err:%s", err.Error())
+		}
+	}()
+
+	var items []*types.OrderItem
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id=? AND state!=? AND refund='0' FOR UPDATE", orderItemTable)
+	err = tx.Select(&items, query, orderID, types.OrderItemSucceeded)
+	if err != nil {
+		return "0", err
+	}
+
+	if len(items) == 0 {
+		return "0", nil
+	}
+
+	refund := "0"
+	query = fmt.Sprintf(`UPDATE %s SET state=?, refund=value, done_time=NOW() WHERE order_id=? AND item_index=?`, orderItemTable)
+	for _, item := range items {
+		refund, err = utils.AddBigInt(refund, item.Value)
+		if err != nil {
+			return "0", err
+		}
+
+		_, err = tx.Exec(query, types.OrderItemFailed, orderID, item.ItemIndex)
+		if err != nil {
+			return "0", err
+		}
+	}
+
+	entries, err := userJournal(userID, types.LedgerRevenue, refund, types.LedgerRefOrderRefund, orderID)
+	if err != nil {
+		return "0", err
+	}
+
+	err = postLedger(tx, entries)
+	if err != nil {
+		return "0", err
+	}
+
+	return refund, tx.Commit()
+}
+
+// SettleOrderHold captures the hold of a done order for its fulfilled items and releases the rest to the user,
+// the items that were not fulfilled are marked as failed with their value refunded in the same transaction.
+// A hold that was settled already is left as it is. It returns sql.ErrNoRows if the hold does not exist.
+func (d *SQLDB) SettleOrderHold(orderID, captured string) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SettleOrderHold Rollback err:%s", err.Error())
+		}
+	}()
+
+	var state types.HoldState
+	query := fmt.Sprintf("SELECT state FROM %s WHERE ref_type=? AND ref_id=? FOR UPDATE", balanceHoldTable)
+	err = tx.Get(&state, query, types.LedgerRefOrder, orderID)
+	if err != nil {
+		return err
+	}
+
+	if state != types.HoldPlaced {
+		return nil
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET state=?, refund=value, done_time=NOW() WHERE order_id=? AND state!=? AND refund='0'`, orderItemTable)
+	_, err = tx.Exec(query, types.OrderItemFailed, orderID, types.OrderItemSucceeded)
+	if err != nil {
+		return err
+	}
+
+	err = settleHold(tx, types.LedgerRefOrder, orderID, captured, types.LedgerRevenue)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveOrderEvent saves a state transition of an order, it is published in the outbox in the same transaction.
+func (d *SQLDB) SaveOrderEvent(info *types.OrderEvent) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveOrderEvent Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (order_id, event, from_state, to_state, actor, msg) 
+		        VALUES (:order_id, :event, :from_state, :to_state, :actor, :msg)`, orderEventTable)
+	result, err := tx.NamedExec(query, info)
+	if err != nil {
+		return err
+	}
+
+	info.ID, err = result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	event, err := NewOutboxEvent(types.OutboxTopicOrder, fmt.Sprintf("%s-%d", types.OutboxTopicOrder, info.ID), info)
+	if err != nil {
+		return err
+	}
+
+	err = insertOutboxEvents(tx, []*types.OutboxEvent{event})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LoadOrderEvents loads the state transitions of an order in the order they happened.
+func (d *SQLDB) LoadOrderEvents(orderID string) ([]*types.OrderEvent, error) {
+	var infos []*types.OrderEvent
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id=? order by id asc", orderEventTable)
+
+	err := d.db.Select(&infos, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/jmoiron/sqlx"
+)
+
+// NewOutboxEvent creates an outbox event with a json payload, an event saved again with the same key is ignored unless it is republished.
+func NewOutboxEvent(topic, key string, payload interface{}) (*types.OutboxEvent, error) {
+	buf, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.OutboxEvent{Topic: topic, EventKey: key, Payload: string(buf)}, nil
+}
+
+// insertOutboxEvents saves events in the outbox within a database transaction.
+func insertOutboxEvents(tx *sqlx.Tx, events []*types.OutboxEvent) error {
+	query := fmt.Sprintf(
+		`INSERT IGNORE INTO %s (topic, event_key, payload) VALUES (:topic, :event_key, :payload)`, outboxEventTable)
+
+	return execOutboxEvents(tx, query, events)
+}
+
+// replaceOutboxEvents saves events in the outbox within a database transaction, an event saved before with the same key is replaced.
+func replaceOutboxEvents(tx *sqlx.Tx, events []*types.OutboxEvent) error {
+	query := fmt.Sprintf(
+		`REPLACE INTO %s (topic, event_key, payload) VALUES (:topic, :event_key, :payload)`, outboxEventTable)
+
+	return execOutboxEvents(tx, query, events)
+}
+
+func execOutboxEvents(tx *sqlx.Tx, query string, events []*types.OutboxEvent) error {
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+
+		_, err := tx.NamedExec(query, event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveOutboxEvents saves events in the outbox.
+func (d *SQLDB) SaveOutboxEvents(events ...*types.OutboxEvent) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveOutboxEvents Rollback err:%s", err.Error())
+		}
+	}()
+
+	err = insertOutboxEvents(tx, events)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RepublishOutboxEvents saves events in the outbox again, an event saved before with the same key
+// is replaced by a new event that is dispatched to every consumer again.
+func (d *SQLDB) RepublishOutboxEvents(events ...*types.OutboxEvent) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("RepublishOutboxEvents Rollback err:%s", err.Error())
+		}
+	}()
+
+	err = replaceOutboxEvents(tx, events)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveOutboxEventsWithConfig saves events in the outbox together with the configuration value of the cursor
+// they were found up to, the cursor is never saved without its events.
+func (d *SQLDB) SaveOutboxEventsWithConfig(events []*types.OutboxEvent, key ConfigType, value string) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveOutboxEventsWithConfig Rollback err:%s", err.Error())
+		}
+	}()
+
+	err = insertOutboxEvents(tx, events)
+	if err != nil {
+		return err
+	}
+
+	err = saveConfigValue(tx, key, value)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveConfigValue saves a configuration value within a database transaction.
+func saveConfigValue(tx *sqlx.Tx, key ConfigType, value string) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (name, value) VALUES (?, ?)
+				ON DUPLICATE KEY UPDATE value=?`, configTable)
+	_, err := tx.Exec(query, key, value, value)
+
+	return err
+}
+
+// LoadOutboxEvents loads the events of the topics from an id in the order they were saved.
+func (d *SQLDB) LoadOutboxEvents(topics []string, from, limit int64) (*types.OutboxEventsResponse, error) {
+	if limit <= 0 || limit > loadOutboxEventsDefaultLimit {
+		limit = loadOutboxEventsDefaultLimit
+	}
+
+	// the time is read first, an event committed while the page is listed is not older than it
+	var now time.Time
+	err := d.db.Get(&now, "SELECT NOW()")
+	if err != nil {
+		return nil, err
+	}
+
+	sQuery := fmt.Sprintf(`SELECT * FROM %s WHERE topic in (?) AND id>=? order by id asc LIMIT ?`, outboxEventTable)
+	query, args, err := sqlx.In(sQuery, topics, from, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []*types.OutboxEvent
+	query = d.db.Rebind(query)
+	err = d.db.Select(&infos, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &types.OutboxEventsResponse{Next: from, List: infos, Now: now}
+	if len(infos) > 0 {
+		out.Next = infos[len(infos)-1].ID + 1
+	}
+
+	return out, nil
+}
+
+// LoadOutboxOffset loads the id of the next event to hand to a consumer, it is 0 for a new consumer.
+func (d *SQLDB) LoadOutboxOffset(consumer string) (int64, error) {
+	var next int64
+	query := fmt.Sprintf("SELECT next_id FROM %s WHERE consumer=?", outboxOffsetTable)
+	err := d.db.Get(&next, query, consumer)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	return next, err
+}
+
+// SaveOutboxOffset saves the id of the next event to hand to a consumer.
+func (d *SQLDB) SaveOutboxOffset(consumer string, next int64) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (consumer, next_id) VALUES (?, ?)
+				ON DUPLICATE KEY UPDATE next_id=?, updated_time=NOW()`, outboxOffsetTable)
+	_, err := d.db.Exec(query, consumer, next, next)
+
+	return err
+}
+
+// SaveOutboxDelivery saves an event whose handling failed for a consumer, a delivery that exists is overwritten.
+func (d *SQLDB) SaveOutboxDelivery(info *types.OutboxDelivery) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (consumer, event_id, topic, payload, state, attempts, last_err, next_time)
+		        VALUES (:consumer, :event_id, :topic, :payload, :state, :attempts, :last_err, :next_time)
+				ON DUPLICATE KEY UPDATE state=:state, attempts=:attempts, last_err=:last_err, next_time=:next_time, updated_time=NOW()`, outboxDeliveryTable)
+	_, err := d.db.NamedExec(query, info)
+
+	return err
+}
+
+// LoadDueOutboxDeliveries loads the failed deliveries of a consumer whose backoff has passed.
+func (d *SQLDB) LoadDueOutboxDeliveries(consumer string, limit int64) ([]*types.OutboxDelivery, error) {
+	if limit <= 0 || limit > loadOutboxDeliveryDefaultLimit {
+		limit = loadOutboxDeliveryDefaultLimit
+	}
+
+	var infos []*types.OutboxDelivery
+	query := fmt.Sprintf("SELECT * FROM %s WHERE consumer=? AND state=? AND next_time<=NOW() order by event_id asc LIMIT ?", outboxDeliveryTable)
+	err := d.db.Select(&infos, query, consumer, types.OutboxDeliveryRetry, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// LoadOutboxDeliveries loads the failed deliveries in a state with pagination, all the consumers are loaded if consumer is empty.
+func (d *SQLDB) LoadOutboxDeliveries(consumer string, state types.OutboxDeliveryState, limit, page int64) (*types.OutboxDeliveryResponse, error) {
+	out := new(types.OutboxDeliveryResponse)
+
+	if limit > loadOutboxDeliveryDefaultLimit {
+		limit = loadOutboxDeliveryDefaultLimit
+	}
+
+	where := "state=?"
+	args := []interface{}{state}
+	if consumer != "" {
+		where += " AND consumer=?"
+		args = append(args, consumer)
+	}
+
+	var infos []*types.OutboxDelivery
+	query := fmt.Sprintf("SELECT * FROM %s WHERE %s order by updated_time desc LIMIT ? OFFSET ?", outboxDeliveryTable, where)
+	err := d.db.Select(&infos, query, append(args, limit, page*limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", outboxDeliveryTable, where)
+	var count int
+	err = d.db.Get(&count, countQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Total = count
+	out.List = infos
+
+	return out, nil
+}
+
+// RedriveOutboxDelivery hands a dead letter to its consumer again with fresh attempts.
+func (d *SQLDB) RedriveOutboxDelivery(consumer string, eventID int64) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, attempts=0, next_time=NOW(), updated_time=NOW()
+	    WHERE consumer=? AND event_id=? AND state=?`, outboxDeliveryTable)
+	result, err := d.db.Exec(query, types.OutboxDeliveryRetry, consumer, eventID, types.OutboxDeliveryDead)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
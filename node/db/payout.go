@@ -0,0 +1,101 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"golang.org/x/xerrors"
+)
+
+// SavePayout queues the payout of an approved withdrawal, a payout saved before is kept.
+func (d *SQLDB) SavePayout(info *types.Payout) error {
+	query := fmt.Sprintf(
+		`INSERT IGNORE INTO %s (order_id, withdraw_addr, value, state)
+		        VALUES (:order_id, :withdraw_addr, :value, :state)`, payoutTable)
+	_, err := d.db.NamedExec(query, info)
+
+	return err
+}
+
+// LoadPayout loads the payout of a withdrawal.
+func (d *SQLDB) LoadPayout(orderID string) (*types.Payout, error) {
+	var info types.Payout
+	query := fmt.Sprintf("SELECT * FROM %s WHERE order_id=?", payoutTable)
+	err := d.db.Get(&info, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// LoadExecutablePayouts loads the payouts waiting for a transaction or for the confirmation of their transaction.
+func (d *SQLDB) LoadExecutablePayouts() ([]*types.Payout, error) {
+	var infos []*types.Payout
+	query := fmt.Sprintf("SELECT * FROM %s WHERE state in (?,?) order by created_time", payoutTable)
+	err := d.db.Select(&infos, query, types.WithdrawApproved, types.WithdrawBroadcast)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// BroadcastPayout saves the transaction of a queued payout before it is broadcast,
+// the event reporting it is saved in the same transaction.
+func (d *SQLDB) BroadcastPayout(orderID, txHash string, expiration int64, event *types.OutboxEvent) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, tx_hash=?, tx_expiration=?, attempts=attempts+1, msg="", updated_time=NOW()
+	    WHERE order_id=? AND state=?`, payoutTable)
+
+	return d.updatePayout(orderID, types.WithdrawApproved, event, query, types.WithdrawBroadcast, txHash, expiration, orderID, types.WithdrawApproved)
+}
+
+// UpdatePayoutState moves a payout between its states, a failed payout retried by an admin gets its attempts again.
+// The event reporting the move is saved in the same transaction if it is not nil.
+func (d *SQLDB) UpdatePayoutState(orderID string, newState, oldState types.WithdrawState, msg string, event *types.OutboxEvent) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, msg=?, updated_time=NOW() WHERE order_id=? AND state=?`, payoutTable)
+	if oldState == types.WithdrawFailed {
+		query = fmt.Sprintf(`UPDATE %s SET state=?, msg=?, base_attempts=attempts, updated_time=NOW() WHERE order_id=? AND state=?`, payoutTable)
+	}
+
+	return d.updatePayout(orderID, oldState, event, query, newState, msg, orderID, oldState)
+}
+
+// updatePayout runs the update of a payout in its state and saves the event reporting it.
+func (d *SQLDB) updatePayout(orderID string, oldState types.WithdrawState, event *types.OutboxEvent, query string, args ...interface{}) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("updatePayout Rollback err:%s", err.Error())
+		}
+	}()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("payout %s is not in state %s", orderID, oldState)
+	}
+
+	if event != nil {
+		err = insertOutboxEvents(tx, []*types.OutboxEvent{event})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
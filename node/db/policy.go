@@ -0,0 +1,152 @@
+package db
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/xerrors"
+)
+
+// WithdrawUsage is what a withdrawal is checked against by the withdraw policy
+type WithdrawUsage struct {
+	Balance *big.Int
+	// withdrawals of the user and of all users in the rolling windows, refunded withdrawals are not counted
+	Daily       *big.Int
+	Monthly     *big.Int
+	GlobalDaily *big.Int
+	// deposits of the user in the deposit hold window that were not spent on orders
+	HeldDeposits *big.Int
+	// seconds since the last login and credential change of the user, -1 if there is none
+	SinceLogin            int64
+	SinceCredentialChange int64
+}
+
+// LoadWithdrawPolicy loads the withdraw policy.
+func (d *SQLDB) LoadWithdrawPolicy() (*types.WithdrawPolicy, error) {
+	var info types.WithdrawPolicy
+	query := fmt.Sprintf("SELECT min_value, max_value, daily_limit, monthly_limit, global_daily_limit, login_cooldown, credential_cooldown, deposit_hold_hours, updated_time FROM %s WHERE id=1", withdrawPolicyTable)
+	err := d.db.Get(&info, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// SaveWithdrawPolicy saves the withdraw policy.
+func (d *SQLDB) SaveWithdrawPolicy(info *types.WithdrawPolicy) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (id, min_value, max_value, daily_limit, monthly_limit, global_daily_limit, login_cooldown, credential_cooldown, deposit_hold_hours)
+		        VALUES (1, :min_value, :max_value, :daily_limit, :monthly_limit, :global_daily_limit, :login_cooldown, :credential_cooldown, :deposit_hold_hours)
+				ON DUPLICATE KEY UPDATE min_value=:min_value, max_value=:max_value, daily_limit=:daily_limit, monthly_limit=:monthly_limit,
+				global_daily_limit=:global_daily_limit, login_cooldown=:login_cooldown, credential_cooldown=:credential_cooldown,
+				deposit_hold_hours=:deposit_hold_hours, updated_time=NOW()`, withdrawPolicyTable)
+	_, err := d.db.NamedExec(query, info)
+
+	return err
+}
+
+// SaveSecurityEvent saves the time of the last security event of a user.
+func (d *SQLDB) SaveSecurityEvent(userID string, event types.SecurityEvent) error {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (user_id, event) VALUES (?, ?)
+				ON DUPLICATE KEY UPDATE updated_time=NOW()`, securityEventTable)
+	_, err := d.db.Exec(query, userID, event)
+
+	return err
+}
+
+// lockWithdrawPolicy loads the withdraw policy and locks it, the withdrawals are checked one after another.
+func lockWithdrawPolicy(tx *sqlx.Tx) (*types.WithdrawPolicy, error) {
+	var info types.WithdrawPolicy
+	query := fmt.Sprintf("SELECT min_value, max_value, daily_limit, monthly_limit, global_daily_limit, login_cooldown, credential_cooldown, deposit_hold_hours, updated_time FROM %s WHERE id=1 FOR UPDATE", withdrawPolicyTable)
+	err := tx.Get(&info, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// loadWithdrawUsage loads what a withdrawal of a user is checked against.
+func loadWithdrawUsage(tx *sqlx.Tx, userID string, policy *types.WithdrawPolicy) (*WithdrawUsage, error) {
+	var err error
+	usage := &WithdrawUsage{}
+
+	usage.Balance, err = loadLedgerBalance(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawn := fmt.Sprintf(`SELECT CAST(COALESCE(SUM(CAST(value AS DECIMAL(65,0))), 0) AS CHAR) FROM %s
+	    WHERE state<>? AND created_time>=NOW()-INTERVAL ? DAY`, withdrawRecordTable)
+
+	usage.Daily, err = loadSum(tx, withdrawn+" AND user_id=?", types.WithdrawRefund, 1, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.Monthly, err = loadSum(tx, withdrawn+" AND user_id=?", types.WithdrawRefund, 30, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.GlobalDaily, err = loadSum(tx, withdrawn, types.WithdrawRefund, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	usage.HeldDeposits = new(big.Int)
+	if policy.DepositHoldHours > 0 {
+		ledger := fmt.Sprintf(`SELECT CAST(COALESCE(SUM(amount), 0) AS CHAR) FROM %s
+		    WHERE account=? AND user_id=? AND ref_type=? AND created_time>=NOW()-INTERVAL ? HOUR`, ledgerEntryTable)
+
+		deposits, err := loadSum(tx, ledger, types.LedgerUser, userID, types.LedgerRefRecharge, policy.DepositHoldHours)
+		if err != nil {
+			return nil, err
+		}
+
+		// order payments are negative entries of the user balance
+		spent, err := loadSum(tx, ledger, types.LedgerUser, userID, types.LedgerRefOrder, policy.DepositHoldHours)
+		if err != nil {
+			return nil, err
+		}
+
+		usage.HeldDeposits.Add(deposits, spent)
+		if usage.HeldDeposits.Sign() < 0 {
+			usage.HeldDeposits.SetInt64(0)
+		}
+	}
+
+	since := fmt.Sprintf(`SELECT COALESCE(MIN(TIMESTAMPDIFF(SECOND, updated_time, NOW())), -1) FROM %s
+	    WHERE user_id=? AND event=?`, securityEventTable)
+
+	err = tx.Get(&usage.SinceLogin, since, userID, types.SecurityEventLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Get(&usage.SinceCredentialChange, since, userID, types.SecurityEventEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func loadSum(tx *sqlx.Tx, query string, args ...interface{}) (*big.Int, error) {
+	var sum string
+	err := tx.Get(&sum, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := new(big.Int).SetString(sum, 10)
+	if !ok {
+		return nil, xerrors.Errorf("invalid sum %s", sum)
+	}
+
+	return v, nil
+}
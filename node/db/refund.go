@@ -0,0 +1,163 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"golang.org/x/xerrors"
+)
+
+// SaveRefundRecord saves a refund record, it returns false if the instance has a refund record that has not been rejected.
+// The instance is locked so that concurrent requests save one refund record.
+func (d *SQLDB) SaveRefundRecord(info *types.RefundRecord) (bool, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveRefundRecord Rollback err:%s", err.Error())
+		}
+	}()
+
+	var id int64
+	query := fmt.Sprintf("SELECT id FROM %s WHERE id=? FOR UPDATE", userInstancesTable)
+	err = tx.Get(&id, query, info.VpsID)
+	if err != nil {
+		return false, err
+	}
+
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT count(refund_id) FROM %s WHERE instance_id=? AND state!=?`, refundRecordTable)
+	err = tx.Get(&total, countSQL, info.InstanceID, types.RefundRejected)
+	if err != nil {
+		return false, err
+	}
+
+	if total > 0 {
+		return false, nil
+	}
+
+	query = fmt.Sprintf(
+		`INSERT INTO %s (refund_id, user_id, instance_id, vps_id, reason, state, value)
+		        VALUES (:refund_id, :user_id, :instance_id, :vps_id, :reason, :state, :value)`, refundRecordTable)
+	_, err = tx.NamedExec(query, info)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// LoadRefundRecord loads a refund record.
+func (d *SQLDB) LoadRefundRecord(refundID string) (*types.RefundRecord, error) {
+	var info types.RefundRecord
+	query := fmt.Sprintf("SELECT * FROM %s WHERE refund_id=?", refundRecordTable)
+	err := d.db.Get(&info, query, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// UpdateRefundRecord moves a refund record from the old state to the state of info.
+func (d *SQLDB) UpdateRefundRecord(info *types.RefundRecord, oldState types.RefundState) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, provider_amount=?, provider_order_id=?, usd_rate=?, value=?, executor=?, msg=?, done_time=NOW()
+	    WHERE refund_id=? AND state=?`, refundRecordTable)
+	result, err := d.db.Exec(query, info.State, info.ProviderAmount, info.ProviderOrderID, info.USDRate, info.Value, info.Executor, info.Msg, info.RefundID, oldState)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("refund %s is not in state %s", info.RefundID, oldState.String())
+	}
+
+	return nil
+}
+
+// CreditRefundRecord marks a provider refunded record as credited and adds its value to the user balance.
+func (d *SQLDB) CreditRefundRecord(info *types.RefundRecord) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("CreditRefundRecord Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET state=?, done_time=NOW() WHERE refund_id=? AND state=?`, refundRecordTable)
+	result, err := tx.Exec(query, types.RefundCredited, info.RefundID, types.RefundProviderRefunded)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("refund %s is not in state %s", info.RefundID, types.RefundProviderRefunded.String())
+	}
+
+	entries, err := userJournal(info.UserID, types.LedgerRefunds, info.Value, types.LedgerRefInstanceRefund, info.RefundID)
+	if err != nil {
+		return err
+	}
+
+	err = postLedger(tx, entries)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LoadRefundRecords loads refund records with pagination, all users are loaded if userID is empty.
+func (d *SQLDB) LoadRefundRecords(userID string, limit, page int64) (*types.GetRefundResponse, error) {
+	out := new(types.GetRefundResponse)
+
+	whereStr := ""
+	args := make([]interface{}, 0)
+	if userID != "" {
+		whereStr = "WHERE user_id=?"
+		args = append(args, userID)
+	}
+
+	if limit > loadRefundRecordsDefaultLimit {
+		limit = loadRefundRecordsDefaultLimit
+	}
+
+	var infos []*types.RefundRecord
+	query := fmt.Sprintf("SELECT * FROM %s %s order by created_time desc LIMIT ? OFFSET ?", refundRecordTable, whereStr)
+	err := d.db.Select(&infos, query, append(args, limit, page*limit)...)
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s %s", refundRecordTable, whereStr)
+	var count int
+	err = d.db.Get(&count, countQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Total = count
+	out.List = infos
+
+	return out, nil
+}
@@ -7,6 +7,7 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
+	"golang.org/x/xerrors"
 
 	logging "github.com/ipfs/go-log/v2"
 )
@@ -18,9 +19,56 @@ type SQLDB struct {
 	db *sqlx.DB
 }
 
-// NewSQLDB creates a new database connection using the given MySQL connection string.
+// NewSQLDB creates a new database connection using the given MySQL connection string, the tables are created and migrated.
 // The function returns a SQLDB pointer or an error if the connection failed.
 func NewSQLDB(path string) (*SQLDB, error) {
+	s, err := openSQLDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s.initTables()
+
+	if err = s.migrateColumns(); err != nil {
+		return nil, err
+	}
+
+	if err = s.migrateLedger(); err != nil {
+		return nil, err
+	}
+
+	if err = s.migrateWithdrawHolds(); err != nil {
+		return nil, err
+	}
+
+	if err = s.migrateChainTracking(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// OpenSQLDB opens a database whose tables are created and migrated by the mall, so that the migrations run in one process.
+// It fails if the mall has not created the tables of this version yet.
+func OpenSQLDB(path string) (*SQLDB, error) {
+	s, err := openSQLDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	exist, err := s.tableExists(payoutTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exist {
+		return nil, xerrors.New("the tables are not created, start the mall on the database first")
+	}
+
+	return s, nil
+}
+
+func openSQLDB(path string) (*SQLDB, error) {
 	path = fmt.Sprintf("%s?parseTime=true&loc=Local", path)
 
 	client, err := sqlx.Open("mysql", path)
@@ -32,15 +80,14 @@ func NewSQLDB(path string) (*SQLDB, error) {
 		return nil, err
 	}
 
-	s := &SQLDB{client}
-	s.initTables()
-
-	return s, nil
+	return &SQLDB{client}, nil
 }
 
 const (
 	// Database table names.
 	orderRecordTable      = "order_record"
+	orderItemTable        = "order_item"
+	orderEventTable       = "order_events"
 	rechargeRecordTable   = "recharge_record"
 	withdrawRecordTable   = "withdraw_record"
 	userInstancesTable    = "user_instances_details"
@@ -48,16 +95,46 @@ const (
 	userTable             = "user_info"
 	adminTable            = "admin_info"
 	rechargeAddressTable  = "recharge_address"
+	paymentAddressTable   = "payment_address"
+	evmAddressTable       = "evm_address"
+	sweepRecordTable      = "sweep_record"
 	instanceBaseInfoTable = "instance_base_info"
 	instanceRefundTable   = "instance_refund"
+	refundRecordTable     = "refund_record"
+	idempotencyTable      = "idempotency_record"
+	billingInfoTable      = "billing_info"
+	invoiceTable          = "invoice"
 	invitationTable       = "invitation"
 	accountTable          = "account"
+	ledgerEntryTable      = "ledger_entry"
+	balanceHoldTable      = "balance_hold"
+	statementMailTable    = "statement_mail"
+	withdrawPolicyTable   = "withdraw_policy"
+	withdrawApprovalTable = "withdraw_approval"
+	withdrawAddressTable  = "withdraw_address"
+	withdrawAddrLogTable  = "withdraw_address_log"
+	withdrawSettingTable  = "withdraw_setting"
+	securityEventTable    = "security_event"
+	outboxEventTable      = "outbox_event"
+	outboxOffsetTable     = "outbox_offset"
+	outboxDeliveryTable   = "outbox_delivery"
+	depositClaimTable     = "deposit_claim"
+	tronDepositTable      = "tron_deposit"
+	payoutTable           = "payout"
 	// Default limits for loading table entries.
 	loadOrderRecordsDefaultLimit    = 1000
 	loadRechargeRecordsDefaultLimit = 1000
 	loadWithdrawRecordsDefaultLimit = 1000
+	loadRefundRecordsDefaultLimit   = 1000
+	loadInvoicesDefaultLimit        = 1000
 	loadAddressesDefaultLimit       = 1000
+	loadSweepRecordsDefaultLimit    = 1000
+	loadAddressLogsDefaultLimit     = 1000
 	loadInstancesDefaultLimit       = 100
+	loadStatementDefaultLimit       = 100
+	loadOutboxEventsDefaultLimit    = 500
+	loadOutboxDeliveryDefaultLimit  = 1000
+	loadDepositClaimsDefaultLimit   = 1000
 )
 
 // initTables initializes data tables.
@@ -77,17 +154,43 @@ func (d *SQLDB) initTables() error {
 
 	// Execute table creation statements
 	tx.MustExec(fmt.Sprintf(cOrderRecordTable, orderRecordTable))
+	tx.MustExec(fmt.Sprintf(cOrderItemTable, orderItemTable))
+	tx.MustExec(fmt.Sprintf(cOrderEventTable, orderEventTable))
 	tx.MustExec(fmt.Sprintf(cInstanceDetailsTable, userInstancesTable))
 	tx.MustExec(fmt.Sprintf(cRechargeTable, rechargeRecordTable))
 	tx.MustExec(fmt.Sprintf(cWithdrawTable, withdrawRecordTable))
 	tx.MustExec(fmt.Sprintf(cConfigTable, configTable))
 	tx.MustExec(fmt.Sprintf(cUserTable, userTable))
 	tx.MustExec(fmt.Sprintf(cRechargeAddressTable, rechargeAddressTable))
+	tx.MustExec(fmt.Sprintf(cPaymentAddressTable, paymentAddressTable))
+	tx.MustExec(fmt.Sprintf(cEvmAddressTable, evmAddressTable))
+	tx.MustExec(fmt.Sprintf(cSweepRecordTable, sweepRecordTable))
 	tx.MustExec(fmt.Sprintf(cAdminTable, adminTable))
 	tx.MustExec(fmt.Sprintf(cInstanceDefaultTable, instanceBaseInfoTable))
 	tx.MustExec(fmt.Sprintf(cInstanceRefundTable, instanceRefundTable))
+	tx.MustExec(fmt.Sprintf(cRefundRecordTable, refundRecordTable))
+	tx.MustExec(fmt.Sprintf(cIdempotencyTable, idempotencyTable))
+	tx.MustExec(fmt.Sprintf(cBillingInfoTable, billingInfoTable))
+	tx.MustExec(fmt.Sprintf(cInvoiceTable, invoiceTable))
 	tx.MustExec(fmt.Sprintf(cInvitationTable, invitationTable))
 	tx.MustExec(fmt.Sprintf(cAccountTable, accountTable))
+	tx.MustExec(fmt.Sprintf(cLedgerEntryTable, ledgerEntryTable))
+	tx.MustExec(fmt.Sprintf(cBalanceHoldTable, balanceHoldTable))
+	tx.MustExec(fmt.Sprintf(cStatementMailTable, statementMailTable))
+	tx.MustExec(fmt.Sprintf(cWithdrawPolicyTable, withdrawPolicyTable))
+	tx.MustExec(fmt.Sprintf(cWithdrawApprovalTable, withdrawApprovalTable))
+	tx.MustExec(fmt.Sprintf(cWithdrawAddressTable, withdrawAddressTable))
+	tx.MustExec(fmt.Sprintf(cWithdrawAddressLogTable, withdrawAddrLogTable))
+	tx.MustExec(fmt.Sprintf(cWithdrawSettingTable, withdrawSettingTable))
+	tx.MustExec(fmt.Sprintf(cSecurityEventTable, securityEventTable))
+	tx.MustExec(fmt.Sprintf(cOutboxEventTable, outboxEventTable))
+	tx.MustExec(fmt.Sprintf(cOutboxOffsetTable, outboxOffsetTable))
+	tx.MustExec(fmt.Sprintf(cOutboxDeliveryTable, outboxDeliveryTable))
+	tx.MustExec(fmt.Sprintf(cDepositClaimTable, depositClaimTable))
+	tx.MustExec(fmt.Sprintf(cTronDepositTable, tronDepositTable))
+	tx.MustExec(fmt.Sprintf(cPayoutTable, payoutTable))
+	// the withdraw policy is a single row locked by every withdrawal
+	tx.MustExec(fmt.Sprintf(`INSERT IGNORE INTO %s (id) VALUES (1)`, withdrawPolicyTable))
 
 	return tx.Commit()
 }
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+// LoadLedgerEntries loads the entries of a user balance created in [start, end) after the cursor entry.
+func (d *SQLDB) LoadLedgerEntries(userID string, start, end time.Time, cursor, limit int64) ([]*types.LedgerEntry, error) {
+	if limit <= 0 || limit > loadStatementDefaultLimit {
+		limit = loadStatementDefaultLimit
+	}
+
+	var infos []*types.LedgerEntry
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE account=? AND user_id=? AND created_time>=? AND created_time<? AND id>?
+	    order by id asc LIMIT ?`, ledgerEntryTable)
+	err := d.db.Select(&infos, query, types.LedgerUser, userID, start, end, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// LoadLedgerBalanceBefore sums the entries of a user balance saved before an entry.
+func (d *SQLDB) LoadLedgerBalanceBefore(userID string, id int64) (string, error) {
+	var info string
+	query := fmt.Sprintf("SELECT CAST(COALESCE(SUM(amount), 0) AS CHAR) FROM %s WHERE account=? AND user_id=? AND id<?", ledgerEntryTable)
+	err := d.db.Get(&info, query, types.LedgerUser, userID, id)
+	if err != nil {
+		return "0", err
+	}
+
+	return info, nil
+}
+
+// LoadLedgerBalanceAt sums the entries of a user balance created before a time.
+func (d *SQLDB) LoadLedgerBalanceAt(userID string, t time.Time) (string, error) {
+	var info string
+	query := fmt.Sprintf("SELECT CAST(COALESCE(SUM(amount), 0) AS CHAR) FROM %s WHERE account=? AND user_id=? AND created_time<?", ledgerEntryTable)
+	err := d.db.Get(&info, query, types.LedgerUser, userID, t)
+	if err != nil {
+		return "0", err
+	}
+
+	return info, nil
+}
+
+// LoadLedgerUsers loads the users whose balance changed in [start, end).
+func (d *SQLDB) LoadLedgerUsers(start, end time.Time) ([]string, error) {
+	var infos []string
+	query := fmt.Sprintf("SELECT DISTINCT user_id FROM %s WHERE account=? AND created_time>=? AND created_time<?", ledgerEntryTable)
+	err := d.db.Select(&infos, query, types.LedgerUser, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// SaveStatementMail records that the statement of a period has been mailed to a user.
+func (d *SQLDB) SaveStatementMail(userID, period, email string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, period, email) VALUES (?, ?, ?)`, statementMailTable)
+	_, err := d.db.Exec(query, userID, period, email)
+
+	return err
+}
+
+// StatementMailExists checks if the statement of a period has been mailed to a user.
+func (d *SQLDB) StatementMailExists(userID, period string) (bool, error) {
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT count(user_id) FROM %s WHERE user_id=? AND period=?`, statementMailTable)
+	if err := d.db.Get(&total, countSQL, userID, period); err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"golang.org/x/xerrors"
+)
+
+// SaveSweepRecord saves a sweep record and returns its id.
+func (d *SQLDB) SaveSweepRecord(info *types.SweepRecord) (int64, error) {
+	query := fmt.Sprintf(
+		`INSERT INTO %s (addr, contract_addr, amount, top_up_hash, tx_hash, state, msg) 
+		        VALUES (:addr, :contract_addr, :amount, :top_up_hash, :tx_hash, :state, :msg)`, sweepRecordTable)
+	result, err := d.db.NamedExec(query, info)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// UpdateSweepRecord updates the state of a sweep record, the token transfer hash is kept if txHash is empty.
+func (d *SQLDB) UpdateSweepRecord(id int64, newState, oldState types.SweepState, txHash, msg string) error {
+	query := fmt.Sprintf(`UPDATE %s SET state=?, tx_hash=IF(?='', tx_hash, ?), msg=?, done_time=NOW() WHERE id=? AND state=?`, sweepRecordTable)
+	result, err := d.db.Exec(query, newState, txHash, txHash, msg, id, oldState)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("sweep %d is not in state %s", id, oldState.String())
+	}
+
+	return nil
+}
+
+// LoadOpenSweepRecords loads the sweep records waiting for a transaction.
+func (d *SQLDB) LoadOpenSweepRecords() ([]*types.SweepRecord, error) {
+	var infos []*types.SweepRecord
+	query := fmt.Sprintf("SELECT * FROM %s WHERE state IN (?, ?)", sweepRecordTable)
+	err := d.db.Select(&infos, query, types.SweepTopUp, types.SweepSent)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// LoadSweepRecords loads sweep records with pagination.
+func (d *SQLDB) LoadSweepRecords(limit, page int64) (*types.SweepRecordResponse, error) {
+	out := new(types.SweepRecordResponse)
+
+	if limit > loadSweepRecordsDefaultLimit {
+		limit = loadSweepRecordsDefaultLimit
+	}
+
+	var infos []*types.SweepRecord
+	query := fmt.Sprintf("SELECT * FROM %s order by id desc LIMIT ? OFFSET ?", sweepRecordTable)
+	err := d.db.Select(&infos, query, limit, page*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s", sweepRecordTable)
+	var count int
+	err = d.db.Get(&count, countQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Total = count
+	out.List = infos
+
+	return out, nil
+}
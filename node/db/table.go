@@ -14,10 +14,42 @@ var cOrderRecordTable = `
 		msg                VARCHAR(2048) DEFAULT "",
 		order_type         INT           DEFAULT 0,
 		expiration         DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		amount             INT           DEFAULT 1,
 		PRIMARY KEY (order_id),
 		KEY idx_user (user_id)
 	) ENGINE=InnoDB COMMENT='order record';`
 
+var cOrderItemTable = `
+	CREATE TABLE if not exists %s (
+		order_id           VARCHAR(128)  NOT NULL,
+		item_index         INT           NOT NULL,
+		vps_id             BIGINT(20)    NOT NULL,
+		instance_id        VARCHAR(128)  DEFAULT '',
+		value              VARCHAR(32)   DEFAULT 0,
+		refund             VARCHAR(32)   DEFAULT 0,
+		usd_rate           FLOAT         DEFAULT 0,
+		state              INT           DEFAULT 0,
+		msg                VARCHAR(2048) DEFAULT "",
+		created_time       DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		done_time          DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (order_id, item_index),
+		KEY idx_vps (vps_id)
+	) ENGINE=InnoDB COMMENT='order item';`
+
+var cOrderEventTable = `
+	CREATE TABLE if not exists %s (
+		id                 BIGINT(20)    NOT NULL AUTO_INCREMENT,
+		order_id           VARCHAR(128)  NOT NULL,
+		event              VARCHAR(64)   DEFAULT "",
+		from_state         VARCHAR(32)   DEFAULT "",
+		to_state           VARCHAR(32)   DEFAULT "",
+		actor              VARCHAR(128)  DEFAULT "",
+		msg                VARCHAR(2048) DEFAULT "",
+		created_time       DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id),
+		KEY idx_order (order_id)
+	) ENGINE=InnoDB COMMENT='order events';`
+
 var cInstanceDetailsTable = `
 	CREATE TABLE if not exists %s (
 		id          		 BIGINT(20) NOT NULL AUTO_INCREMENT,
@@ -52,6 +84,9 @@ var cInstanceDetailsTable = `
 		renew                VARCHAR(16)   DEFAULT '',
 		state                VARCHAR(16)   DEFAULT '',
 		update_time          DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		user_data            TEXT          NULL,
+		reinstall_state      INT           DEFAULT 0,
+		reinstall_msg        VARCHAR(256)  DEFAULT '',
 		PRIMARY KEY (id),
 		KEY idx_user (user_id),
 		KEY idx_instance (instance_id)
@@ -60,16 +95,23 @@ var cInstanceDetailsTable = `
 var cRechargeTable = `
 	CREATE TABLE if not exists %s (
 		order_id           VARCHAR(128) NOT NULL UNIQUE,
+		chain              VARCHAR(32)  DEFAULT "tron",
 		from_addr          VARCHAR(128) DEFAULT "",
 		to_addr            VARCHAR(128) NOT NULL,
 		user_id            VARCHAR(128) DEFAULT "",
 		value              VARCHAR(32)  DEFAULT 0,
 		created_time       DATETIME     DEFAULT CURRENT_TIMESTAMP,
 		state              INT          DEFAULT 0,
+		token              VARCHAR(16)  DEFAULT "",
+		raw_value          VARCHAR(64)  DEFAULT 0,
+		rate               VARCHAR(32)  DEFAULT "1",
+		block_number       BIGINT(20)   DEFAULT 0,
+		block_hash         VARCHAR(128) DEFAULT "",
 		done_time          DATETIME     DEFAULT CURRENT_TIMESTAMP,
 		PRIMARY KEY (order_id),
 		KEY idx_user (user_id),
-		KEY idx_to (to_addr)
+		KEY idx_to (to_addr),
+		KEY idx_state (state)
 	) ENGINE=InnoDB COMMENT='recharge info';`
 
 var cWithdrawTable = `
@@ -83,13 +125,86 @@ var cWithdrawTable = `
 		state              INT          DEFAULT 0,
 		done_time          DATETIME     DEFAULT CURRENT_TIMESTAMP,
 		executor           VARCHAR(128) DEFAULT "",
+		attempts           INT          DEFAULT 0,
+		tx_expiration      BIGINT(20)   DEFAULT 0,
+		msg                VARCHAR(256) DEFAULT "",
+		fee                VARCHAR(32)  DEFAULT "0",
+		net_value          VARCHAR(32)  DEFAULT "",
+		approvals          INT          DEFAULT 0,
+		required_approvals INT          DEFAULT 1,
+		creator            VARCHAR(128) DEFAULT "",
 		PRIMARY KEY (order_id),
-		KEY idx_user (user_id)
+		KEY idx_user (user_id),
+		KEY idx_state (state)
 	) ENGINE=InnoDB COMMENT='withdraw info';`
 
+var cWithdrawApprovalTable = `
+	CREATE TABLE if not exists %s (
+		order_id       VARCHAR(128) NOT NULL,
+		admin_id       VARCHAR(128) NOT NULL,
+		decision       INT          NOT NULL,
+		created_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (order_id, admin_id)
+	) ENGINE=InnoDB COMMENT='admin decisions on withdrawals';`
+
+var cWithdrawAddressTable = `
+	CREATE TABLE if not exists %s (
+		user_id        VARCHAR(128) NOT NULL,
+		addr           VARCHAR(128) NOT NULL,
+		label          VARCHAR(64)  DEFAULT "",
+		state          INT          DEFAULT 0,
+		created_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		usable_time    DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, addr)
+	) ENGINE=InnoDB COMMENT='withdraw address book';`
+
+var cWithdrawAddressLogTable = `
+	CREATE TABLE if not exists %s (
+		id             BIGINT(20)   NOT NULL AUTO_INCREMENT,
+		user_id        VARCHAR(128) NOT NULL,
+		addr           VARCHAR(128) DEFAULT "",
+		action         VARCHAR(32)  NOT NULL,
+		label          VARCHAR(64)  DEFAULT "",
+		created_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id),
+		KEY idx_user (user_id)
+	) ENGINE=InnoDB COMMENT='withdraw address book changes';`
+
+var cWithdrawSettingTable = `
+	CREATE TABLE if not exists %s (
+		user_id        VARCHAR(128) NOT NULL,
+		whitelist_only BOOLEAN      DEFAULT false,
+		email          VARCHAR(128) DEFAULT "",
+		updated_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id)
+	) ENGINE=InnoDB COMMENT='withdraw setting of a user';`
+
+var cWithdrawPolicyTable = `
+	CREATE TABLE if not exists %s (
+		id                  INT          NOT NULL,
+		min_value           VARCHAR(32)  DEFAULT "",
+		max_value           VARCHAR(32)  DEFAULT "",
+		daily_limit         VARCHAR(32)  DEFAULT "",
+		monthly_limit       VARCHAR(32)  DEFAULT "",
+		global_daily_limit  VARCHAR(32)  DEFAULT "",
+		login_cooldown      BIGINT(20)   DEFAULT 0,
+		credential_cooldown BIGINT(20)   DEFAULT 0,
+		deposit_hold_hours  BIGINT(20)   DEFAULT 0,
+		updated_time        DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id)
+	) ENGINE=InnoDB COMMENT='withdraw policy';`
+
+var cSecurityEventTable = `
+	CREATE TABLE if not exists %s (
+		user_id        VARCHAR(128) NOT NULL,
+		event          VARCHAR(32)  NOT NULL,
+		updated_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, event)
+	) ENGINE=InnoDB COMMENT='last security event of a user';`
+
 var cConfigTable = `
 	CREATE TABLE if not exists %s (
-		name       VARCHAR(16)  DEFAULT "",
+		name       VARCHAR(64)  DEFAULT "",
 		value      VARCHAR(32)  DEFAULT "",
 		PRIMARY KEY (name)
 	) ENGINE=InnoDB COMMENT='config info';`
@@ -103,11 +218,29 @@ var cUserTable = `
 	) ENGINE=InnoDB COMMENT='user info';`
 
 var cRechargeAddressTable = `
+	CREATE TABLE if not exists %s (
+		addr          VARCHAR(128) NOT NULL UNIQUE,
+		user_id       VARCHAR(128) DEFAULT "",
+		derive_index  BIGINT(20)   DEFAULT NULL,
+		PRIMARY KEY (addr),
+		UNIQUE KEY uk_derive_index (derive_index)
+	) ENGINE=InnoDB COMMENT='recharge address ';`
+
+var cPaymentAddressTable = `
 	CREATE TABLE if not exists %s (
 		addr      VARCHAR(128) NOT NULL UNIQUE,
 		user_id   VARCHAR(128) DEFAULT "",
 		PRIMARY KEY (addr)
-	) ENGINE=InnoDB COMMENT='recharge address ';`
+	) ENGINE=InnoDB COMMENT='fvm payment address ';`
+
+var cEvmAddressTable = `
+	CREATE TABLE if not exists %s (
+		chain     VARCHAR(32)  NOT NULL,
+		addr      VARCHAR(128) NOT NULL,
+		user_id   VARCHAR(128) DEFAULT "",
+		PRIMARY KEY (chain, addr),
+		KEY idx_user (user_id)
+	) ENGINE=InnoDB COMMENT='evm deposit address ';`
 
 var cAdminTable = `
 	CREATE TABLE if not exists %s (
@@ -144,6 +277,68 @@ var cInstanceRefundTable = `
 		PRIMARY KEY (instance_id)
 	) ENGINE=InnoDB COMMENT='instance refund';`
 
+var cRefundRecordTable = `
+	CREATE TABLE if not exists %s (
+		refund_id          VARCHAR(128)  NOT NULL UNIQUE,
+		user_id            VARCHAR(128)  NOT NULL,
+		instance_id        VARCHAR(128)  NOT NULL,
+		vps_id             BIGINT(20)    NOT NULL,
+		reason             VARCHAR(512)  DEFAULT "",
+		state              INT           DEFAULT 0,
+		provider_amount    DOUBLE        DEFAULT 0,
+		provider_order_id  BIGINT(20)    DEFAULT 0,
+		usd_rate           FLOAT         DEFAULT 0,
+		value              VARCHAR(32)   DEFAULT 0,
+		executor           VARCHAR(128)  DEFAULT "",
+		msg                VARCHAR(2048) DEFAULT "",
+		created_time       DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		done_time          DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (refund_id),
+		KEY idx_user (user_id),
+		KEY idx_instance (instance_id)
+	) ENGINE=InnoDB COMMENT='refund record';`
+
+var cIdempotencyTable = `
+	CREATE TABLE if not exists %s (
+		user_id        VARCHAR(128)  NOT NULL,
+		idem_key       VARCHAR(128)  NOT NULL,
+		method         VARCHAR(64)   NOT NULL,
+		request_hash   VARCHAR(64)   NOT NULL,
+		done           BOOLEAN       DEFAULT false,
+		response       VARCHAR(256)  DEFAULT "",
+		created_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, idem_key),
+		KEY idx_created (created_time)
+	) ENGINE=InnoDB COMMENT='idempotency record';`
+
+var cBillingInfoTable = `
+	CREATE TABLE if not exists %s (
+		user_id        VARCHAR(128)  NOT NULL UNIQUE,
+		company_name   VARCHAR(256)  DEFAULT "",
+		tax_id         VARCHAR(64)   DEFAULT "",
+		address        VARCHAR(512)  DEFAULT "",
+		country        VARCHAR(64)   DEFAULT "",
+		email          VARCHAR(128)  DEFAULT "",
+		PRIMARY KEY (user_id)
+	) ENGINE=InnoDB COMMENT='billing info';`
+
+var cInvoiceTable = `
+	CREATE TABLE if not exists %s (
+		invoice_no     BIGINT(20)    NOT NULL UNIQUE,
+		invoice_type   INT           NOT NULL,
+		ref_id         VARCHAR(128)  NOT NULL,
+		user_id        VARCHAR(128)  NOT NULL,
+		currency       VARCHAR(16)   DEFAULT "",
+		usd_rate       FLOAT         DEFAULT 0,
+		total          VARCHAR(32)   DEFAULT 0,
+		billing_info   TEXT          NOT NULL,
+		line_items     TEXT          NOT NULL,
+		created_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (invoice_no),
+		UNIQUE KEY (invoice_type, ref_id),
+		KEY idx_user (user_id)
+	) ENGINE=InnoDB COMMENT='invoice';`
+
 var cInvitationTable = `
 	CREATE TABLE if not exists %s (
 		id   	VARCHAR(128) NOT NULL,
@@ -160,3 +355,142 @@ var cAccountTable = `
 	    create_time BIGINT(20),
 	    PRIMARY KEY (id)
 	)ENGINE=InnoDB COMMENT='account';`
+
+var cSweepRecordTable = `
+	CREATE TABLE if not exists %s (
+		id              BIGINT(20)   NOT NULL AUTO_INCREMENT,
+		addr            VARCHAR(128) NOT NULL,
+		contract_addr   VARCHAR(128) NOT NULL,
+		amount          VARCHAR(64)  DEFAULT 0,
+		top_up_hash     VARCHAR(128) DEFAULT "",
+		tx_hash         VARCHAR(128) DEFAULT "",
+		state           INT          DEFAULT 0,
+		msg             VARCHAR(256) DEFAULT "",
+		created_time    DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		done_time       DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id),
+		KEY idx_addr (addr),
+		KEY idx_state (state)
+	) ENGINE=InnoDB COMMENT='deposit address sweep';`
+
+var cLedgerEntryTable = `
+	CREATE TABLE if not exists %s (
+		id             BIGINT(20)    NOT NULL AUTO_INCREMENT,
+		tx_id          VARCHAR(128)  NOT NULL,
+		account        VARCHAR(32)   NOT NULL,
+		user_id        VARCHAR(128)  NOT NULL DEFAULT "",
+		amount         DECIMAL(65,0) NOT NULL,
+		ref_type       VARCHAR(32)   NOT NULL,
+		ref_id         VARCHAR(128)  NOT NULL,
+		created_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id),
+		KEY idx_tx (tx_id),
+		KEY idx_user (user_id, account),
+		KEY idx_ref (ref_type, ref_id)
+	) ENGINE=InnoDB COMMENT='ledger entry';`
+
+var cBalanceHoldTable = `
+	CREATE TABLE if not exists %s (
+		ref_type       VARCHAR(32)   NOT NULL,
+		ref_id         VARCHAR(128)  NOT NULL,
+		user_id        VARCHAR(128)  NOT NULL,
+		amount         VARCHAR(32)   DEFAULT 0,
+		captured       VARCHAR(32)   DEFAULT 0,
+		state          INT           DEFAULT 0,
+		created_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		done_time      DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (ref_type, ref_id),
+		KEY idx_user (user_id)
+	) ENGINE=InnoDB COMMENT='balance hold';`
+
+var cStatementMailTable = `
+	CREATE TABLE if not exists %s (
+		user_id        VARCHAR(128)  NOT NULL,
+		period         VARCHAR(16)   NOT NULL,
+		email          VARCHAR(128)  DEFAULT "",
+		created_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, period)
+	) ENGINE=InnoDB COMMENT='statement mail';`
+
+var cOutboxEventTable = `
+	CREATE TABLE if not exists %s (
+		id             BIGINT(20)   NOT NULL AUTO_INCREMENT,
+		topic          VARCHAR(32)  NOT NULL,
+		event_key      VARCHAR(255) NOT NULL,
+		payload        TEXT         NOT NULL,
+		created_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (id),
+		UNIQUE KEY idx_key (event_key),
+		KEY idx_topic (topic, id)
+	) ENGINE=InnoDB COMMENT='outbox events';`
+
+var cOutboxOffsetTable = `
+	CREATE TABLE if not exists %s (
+		consumer       VARCHAR(64)  NOT NULL,
+		next_id        BIGINT(20)   DEFAULT 0,
+		updated_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (consumer)
+	) ENGINE=InnoDB COMMENT='outbox consumer offsets';`
+
+var cOutboxDeliveryTable = `
+	CREATE TABLE if not exists %s (
+		consumer       VARCHAR(64)   NOT NULL,
+		event_id       BIGINT(20)    NOT NULL,
+		topic          VARCHAR(32)   NOT NULL,
+		payload        TEXT          NOT NULL,
+		state          INT           DEFAULT 0,
+		attempts       INT           DEFAULT 0,
+		last_err       VARCHAR(2048) DEFAULT "",
+		next_time      DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		created_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		updated_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (consumer, event_id),
+		KEY idx_state (state, next_time)
+	) ENGINE=InnoDB COMMENT='failed outbox deliveries';`
+
+var cDepositClaimTable = `
+	CREATE TABLE if not exists %s (
+		claim_id       VARCHAR(128)  NOT NULL,
+		user_id        VARCHAR(128)  NOT NULL,
+		chain          VARCHAR(32)   NOT NULL,
+		tx_hash        VARCHAR(128)  NOT NULL,
+		state          INT           DEFAULT 0,
+		reason         VARCHAR(32)   DEFAULT "",
+		msg            VARCHAR(2048) DEFAULT "",
+		admin_id       VARCHAR(128)  DEFAULT "",
+		created_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		updated_time   DATETIME      DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (claim_id),
+		UNIQUE KEY idx_user_tx (user_id, chain, tx_hash),
+		KEY idx_state (state)
+	) ENGINE=InnoDB COMMENT='deposit claims';`
+
+var cTronDepositTable = `
+	CREATE TABLE if not exists %s (
+		tx_hash        VARCHAR(128) NOT NULL,
+		token          VARCHAR(16)  DEFAULT "",
+		block_number   BIGINT(20)   DEFAULT 0,
+		block_hash     VARCHAR(128) DEFAULT "",
+		state          INT          DEFAULT 0,
+		created_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		updated_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (tx_hash),
+		KEY idx_state (state)
+	) ENGINE=InnoDB COMMENT='tron deposits tracked by the transaction node';`
+
+var cPayoutTable = `
+	CREATE TABLE if not exists %s (
+		order_id       VARCHAR(128) NOT NULL,
+		withdraw_addr  VARCHAR(128) NOT NULL,
+		value          VARCHAR(32)  NOT NULL,
+		state          INT          DEFAULT 0,
+		tx_hash        VARCHAR(128) DEFAULT "",
+		tx_expiration  BIGINT(20)   DEFAULT 0,
+		attempts       INT          DEFAULT 0,
+		base_attempts  INT          DEFAULT 0,
+		msg            VARCHAR(256) DEFAULT "",
+		created_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		updated_time   DATETIME     DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (order_id),
+		KEY idx_state (state)
+	) ENGINE=InnoDB COMMENT='payouts of the transaction node';`
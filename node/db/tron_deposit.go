@@ -0,0 +1,136 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/xerrors"
+)
+
+// insertTronDeposits saves the tron deposits to track within a database transaction, a deposit tracked already is kept.
+func insertTronDeposits(tx *sqlx.Tx, deposits []*types.TronDeposit) error {
+	query := fmt.Sprintf(
+		`INSERT IGNORE INTO %s (tx_hash, token, block_number, block_hash, state)
+		        VALUES (:tx_hash, :token, :block_number, :block_hash, :state)`, tronDepositTable)
+
+	for _, info := range deposits {
+		_, err := tx.NamedExec(query, info)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SaveTronDepositsWithConfig saves the tron deposits to track until their block is confirmed together with their events
+// and the configuration value of the cursor they were found up to.
+func (d *SQLDB) SaveTronDepositsWithConfig(deposits []*types.TronDeposit, events []*types.OutboxEvent, key ConfigType, value string) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveTronDepositsWithConfig Rollback err:%s", err.Error())
+		}
+	}()
+
+	err = insertTronDeposits(tx, deposits)
+	if err != nil {
+		return err
+	}
+
+	err = insertOutboxEvents(tx, events)
+	if err != nil {
+		return err
+	}
+
+	err = saveConfigValue(tx, key, value)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RepublishTronDeposits saves the tron deposits to track and replaces their events so that they are dispatched again.
+func (d *SQLDB) RepublishTronDeposits(deposits []*types.TronDeposit, events []*types.OutboxEvent) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("RepublishTronDeposits Rollback err:%s", err.Error())
+		}
+	}()
+
+	err = insertTronDeposits(tx, deposits)
+	if err != nil {
+		return err
+	}
+
+	err = replaceOutboxEvents(tx, events)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LoadTronDeposits loads the tracked tron deposits in a state.
+func (d *SQLDB) LoadTronDeposits(state types.RechargeState) ([]*types.TronDeposit, error) {
+	var infos []*types.TronDeposit
+	query := fmt.Sprintf("SELECT * FROM %s WHERE state=?", tronDepositTable)
+	err := d.db.Select(&infos, query, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// UpdateTronDeposit saves the block and the state of a tracked tron deposit that is still in oldState,
+// the event reporting it is saved in the same transaction.
+func (d *SQLDB) UpdateTronDeposit(info *types.TronDeposit, oldState types.RechargeState, event *types.OutboxEvent) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("UpdateTronDeposit Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET state=?, block_number=?, block_hash=?, updated_time=NOW() WHERE tx_hash=? AND state=?`, tronDepositTable)
+	result, err := tx.Exec(query, info.State, info.BlockNumber, info.BlockHash, info.TxHash, oldState)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("tron deposit %s is not in state %s", info.TxHash, oldState)
+	}
+
+	err = insertOutboxEvents(tx, []*types.OutboxEvent{event})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
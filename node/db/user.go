@@ -16,19 +16,20 @@ func (d *SQLDB) SaveUserInfo(rInfo *types.UserInfo) error {
 	return err
 }
 
-// UpdateUserBalance updates user balance.
-func (d *SQLDB) UpdateUserBalance(userID, balance, oldBalance string) error {
-	query := fmt.Sprintf(`UPDATE %s SET balance=? WHERE user_id=? AND balance=?`, userTable)
-	_, err := d.db.Exec(query, balance, userID, oldBalance)
+// LoadUserBalance loads the available user balance from the ledger.
+func (d *SQLDB) LoadUserBalance(userID string) (string, error) {
+	return d.loadLedgerAccount(types.LedgerUser, userID)
+}
 
-	return err
+// LoadHeldBalance loads the user balance held by pending orders and withdrawals from the ledger.
+func (d *SQLDB) LoadHeldBalance(userID string) (string, error) {
+	return d.loadLedgerAccount(types.LedgerHeld, userID)
 }
 
-// LoadUserBalance loads user balance.
-func (d *SQLDB) LoadUserBalance(userID string) (string, error) {
+func (d *SQLDB) loadLedgerAccount(account types.LedgerAccount, userID string) (string, error) {
 	var info string
-	query := fmt.Sprintf("SELECT balance FROM %s WHERE user_id=?", userTable)
-	err := d.db.Get(&info, query, userID)
+	query := fmt.Sprintf("SELECT CAST(COALESCE(SUM(amount), 0) AS CHAR) FROM %s WHERE account=? AND user_id=?", ledgerEntryTable)
+	err := d.db.Get(&info, query, account, userID)
 	if err != nil {
 		return "0", err
 	}
@@ -33,17 +33,16 @@ func (d *SQLDB) LoadUserInstanceInfoByInstanceID(instanceID string) (*types.Inst
 	return &info, nil
 }
 
+const insertInstanceQuery = `INSERT INTO %s (region_id,instance_id,user_id, instance_type, image_id, order_id,
+	    security_group_id, instance_charge_type,internet_charge_type, period_unit, period, bandwidth_out,bandwidth_in,
+	    ip_address,value,system_disk_category,system_disk_size,os_type,data_disk,auto_renew, access_key, state, user_data) 
+		VALUES (:region_id,:instance_id,:user_id, :instance_type, :image_id, :order_id,
+		:security_group_id, :instance_charge_type,:internet_charge_type, :period_unit, :period, :bandwidth_out,:bandwidth_in,
+		:ip_address,:value,:system_disk_category,:system_disk_size,:os_type,:data_disk,:auto_renew, :access_key, :state, :user_data)`
+
 // SaveInstanceInfoOfUser saves VPS instance information into the database.
 func (d *SQLDB) SaveInstanceInfoOfUser(rInfo *types.InstanceDetails) (int64, error) {
-	query := fmt.Sprintf(
-		`INSERT INTO %s (region_id,instance_id,user_id, instance_type, image_id, order_id,
-			    security_group_id, instance_charge_type,internet_charge_type, period_unit, period, bandwidth_out,bandwidth_in,
-			    ip_address,value,system_disk_category,system_disk_size,os_type,data_disk,auto_renew, access_key, state) 
-				VALUES (:region_id,:instance_id,:user_id, :instance_type, :image_id, :order_id,
-				:security_group_id, :instance_charge_type,:internet_charge_type, :period_unit, :period, :bandwidth_out,:bandwidth_in,
-				:ip_address,:value,:system_disk_category,:system_disk_size,:os_type,:data_disk,:auto_renew, :access_key, :state)`, userInstancesTable)
-
-	result, err := d.db.NamedExec(query, rInfo)
+	result, err := d.db.NamedExec(fmt.Sprintf(insertInstanceQuery, userInstancesTable), rInfo)
 	if err != nil {
 		return 0, err
 	}
@@ -69,6 +68,46 @@ func (d *SQLDB) UpdateInstanceState(instanceID, state string) error {
 	return err
 }
 
+// UpdateInstanceImageAndUserData updates the image and user data of a VPS instance after a reinstall.
+func (d *SQLDB) UpdateInstanceImageAndUserData(instanceID, imageID, userData string) error {
+	query := fmt.Sprintf(`UPDATE %s SET image_id=?, user_data=?, update_time=NOW() WHERE instance_id=?`, userInstancesTable)
+	_, err := d.db.Exec(query, imageID, userData, instanceID)
+
+	return err
+}
+
+// StartInstanceReinstall marks a reinstall of an instance as running, false is returned if one is running already.
+func (d *SQLDB) StartInstanceReinstall(instanceID string) (bool, error) {
+	query := fmt.Sprintf(`UPDATE %s SET reinstall_state=?, reinstall_msg='', update_time=NOW() WHERE instance_id=? AND reinstall_state!=?`, userInstancesTable)
+	result, err := d.db.Exec(query, types.ReinstallRunning, instanceID, types.ReinstallRunning)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+// UpdateInstanceReinstall saves the result of the running reinstall of an instance.
+func (d *SQLDB) UpdateInstanceReinstall(instanceID string, state types.ReinstallState, msg string) error {
+	query := fmt.Sprintf(`UPDATE %s SET reinstall_state=?, reinstall_msg=?, update_time=NOW() WHERE instance_id=? AND reinstall_state=?`, userInstancesTable)
+	_, err := d.db.Exec(query, state, msg, instanceID, types.ReinstallRunning)
+
+	return err
+}
+
+// FailRunningReinstalls fails the reinstalls that were running when the mall stopped.
+func (d *SQLDB) FailRunningReinstalls(msg string) error {
+	query := fmt.Sprintf(`UPDATE %s SET reinstall_state=?, reinstall_msg=?, update_time=NOW() WHERE reinstall_state=?`, userInstancesTable)
+	_, err := d.db.Exec(query, types.ReinstallFailed, msg, types.ReinstallRunning)
+
+	return err
+}
+
 // RenewVpsInstance updates VPS instance renewal information in the database.
 func (d *SQLDB) RenewVpsInstance(info *types.InstanceDetails) error {
 	query := fmt.Sprintf(`UPDATE %s SET period_unit=?, period=?, value=?,auto_renew=? WHERE instance_id=?`, userInstancesTable)
@@ -0,0 +1,280 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/xerrors"
+)
+
+// SaveWithdrawAddress saves a pending address in the withdrawal address book of a user.
+func (d *SQLDB) SaveWithdrawAddress(info *types.WithdrawAddress) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveWithdrawAddress Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (user_id, addr, label, state)
+		        VALUES (:user_id, :addr, :label, :state)`, withdrawAddressTable)
+	_, err = tx.NamedExec(query, info)
+	if err != nil {
+		return err
+	}
+
+	err = saveWithdrawAddressLog(tx, info.UserID, info.Addr, types.WithdrawAddressAdd, info.Label)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ConfirmWithdrawAddress confirms a pending address, it is usable after the lock period in seconds.
+func (d *SQLDB) ConfirmWithdrawAddress(userID, addr string, lock int64) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("ConfirmWithdrawAddress Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`UPDATE %s SET state=?, usable_time=NOW()+INTERVAL ? SECOND WHERE user_id=? AND addr=? AND state=?`, withdrawAddressTable)
+	result, err := tx.Exec(query, types.WithdrawAddressConfirmed, lock, userID, addr, types.WithdrawAddressPending)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return xerrors.Errorf("withdraw address %s is not in state %s", addr, types.WithdrawAddressPending)
+	}
+
+	err = saveWithdrawAddressLog(tx, userID, addr, types.WithdrawAddressConfirm, "")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveWithdrawAddress removes an address from the withdrawal address book of a user.
+func (d *SQLDB) RemoveWithdrawAddress(userID, addr string) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("RemoveWithdrawAddress Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id=? AND addr=?`, withdrawAddressTable)
+	result, err := tx.Exec(query, userID, addr)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	err = saveWithdrawAddressLog(tx, userID, addr, types.WithdrawAddressRemove, "")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LoadWithdrawAddress loads an address of the withdrawal address book of a user.
+func (d *SQLDB) LoadWithdrawAddress(userID, addr string) (*types.WithdrawAddress, error) {
+	var info types.WithdrawAddress
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=? AND addr=?", withdrawAddressTable)
+	err := d.db.Get(&info, query, userID, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// LoadWithdrawAddresses loads the withdrawal address book of a user.
+func (d *SQLDB) LoadWithdrawAddresses(userID string) ([]*types.WithdrawAddress, error) {
+	var infos []*types.WithdrawAddress
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=? order by created_time", withdrawAddressTable)
+	err := d.db.Select(&infos, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return infos, nil
+}
+
+// WithdrawAddressUsable checks if an address of the withdrawal address book of a user is confirmed and out of its lock period.
+func (d *SQLDB) WithdrawAddressUsable(userID, addr string) (bool, error) {
+	var total int64
+	countSQL := fmt.Sprintf(`SELECT count(addr) FROM %s WHERE user_id=? AND addr=? AND state=? AND usable_time<=NOW()`, withdrawAddressTable)
+	if err := d.db.Get(&total, countSQL, userID, addr, types.WithdrawAddressConfirmed); err != nil {
+		return false, err
+	}
+
+	return total > 0, nil
+}
+
+// SaveWithdrawWhitelistOnly saves if a user can only withdraw to the usable addresses of the address book.
+func (d *SQLDB) SaveWithdrawWhitelistOnly(userID string, enabled bool) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveWithdrawWhitelistOnly Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (user_id, whitelist_only) VALUES (?, ?)
+				ON DUPLICATE KEY UPDATE whitelist_only=?, updated_time=NOW()`, withdrawSettingTable)
+	_, err = tx.Exec(query, userID, enabled, enabled)
+	if err != nil {
+		return err
+	}
+
+	action := types.WithdrawWhitelistOnlyDisable
+	if enabled {
+		action = types.WithdrawWhitelistOnlyEnable
+	}
+
+	err = saveWithdrawAddressLog(tx, userID, "", action, "")
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveAccountEmail saves the verified e-mail the verify codes of a user are mailed to,
+// the confirmed addresses of the address book are locked again for the lock period in seconds.
+func (d *SQLDB) SaveAccountEmail(userID, email string, lock int64) error {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err = tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			log.Errorf("SaveAccountEmail Rollback err:%s", err.Error())
+		}
+	}()
+
+	query := fmt.Sprintf(
+		`INSERT INTO %s (user_id, email) VALUES (?, ?)
+				ON DUPLICATE KEY UPDATE email=?, updated_time=NOW()`, withdrawSettingTable)
+	_, err = tx.Exec(query, userID, email, email)
+	if err != nil {
+		return err
+	}
+
+	query = fmt.Sprintf(`UPDATE %s SET usable_time=GREATEST(usable_time, NOW()+INTERVAL ? SECOND) WHERE user_id=? AND state=?`, withdrawAddressTable)
+	_, err = tx.Exec(query, lock, userID, types.WithdrawAddressConfirmed)
+	if err != nil {
+		return err
+	}
+
+	err = saveWithdrawAddressLog(tx, userID, "", types.WithdrawEmailChange, email)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LoadAccountEmail loads the verified e-mail of a user, it is empty if the user has not set one.
+func (d *SQLDB) LoadAccountEmail(userID string) (string, error) {
+	var email string
+	query := fmt.Sprintf("SELECT email FROM %s WHERE user_id=?", withdrawSettingTable)
+	err := d.db.Get(&email, query, userID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return email, err
+}
+
+// WithdrawWhitelistOnly checks if a user can only withdraw to the usable addresses of the address book.
+func (d *SQLDB) WithdrawWhitelistOnly(userID string) (bool, error) {
+	var enabled bool
+	query := fmt.Sprintf("SELECT whitelist_only FROM %s WHERE user_id=?", withdrawSettingTable)
+	err := d.db.Get(&enabled, query, userID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	return enabled, err
+}
+
+// LoadWithdrawAddressLogs loads the changes of the withdrawal address book of a user with pagination.
+func (d *SQLDB) LoadWithdrawAddressLogs(userID string, limit, page int64) (*types.WithdrawAddressLogResponse, error) {
+	out := new(types.WithdrawAddressLogResponse)
+
+	var infos []*types.WithdrawAddressLog
+	query := fmt.Sprintf("SELECT * FROM %s WHERE user_id=? order by id desc LIMIT ? OFFSET ?", withdrawAddrLogTable)
+	if limit > loadAddressLogsDefaultLimit {
+		limit = loadAddressLogsDefaultLimit
+	}
+
+	err := d.db.Select(&infos, query, userID, limit, page*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE user_id=?", withdrawAddrLogTable)
+	var count int
+	err = d.db.Get(&count, countQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	out.Total = count
+	out.List = infos
+
+	return out, nil
+}
+
+func saveWithdrawAddressLog(tx *sqlx.Tx, userID, addr, action, label string) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, addr, action, label) VALUES (?, ?, ?, ?)`, withdrawAddrLogTable)
+	_, err := tx.Exec(query, userID, addr, action, label)
+
+	return err
+}
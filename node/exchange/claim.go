@@ -0,0 +1,312 @@
+package exchange
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/config"
+	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
+	"github.com/google/uuid"
+)
+
+// ClaimManager manages the deposit claims, a claimed transaction is verified on chain
+// and credited if it pays a deposit address of the user, the others wait for an admin.
+type ClaimManager struct {
+	*db.SQLDB
+	cfg   config.MallCfg
+	chain api.Transaction
+}
+
+// NewClaimManager creates a new manager instance for handling deposit claims
+func NewClaimManager(sdb *db.SQLDB, getCfg dtypes.GetMallConfigFunc, chain api.Transaction) (*ClaimManager, error) {
+	cfg, err := getCfg()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &ClaimManager{
+		SQLDB: sdb,
+		cfg:   cfg,
+		chain: chain,
+	}
+
+	return m, nil
+}
+
+// ClaimDeposit saves the claim of a user on a transaction and verifies it, the claim that exists is returned
+// if the user claimed the transaction already.
+func (m *ClaimManager) ClaimDeposit(ctx context.Context, userID, chain, hash string) (*types.DepositClaim, error) {
+	chain = strings.ToLower(strings.TrimSpace(chain))
+	hash = normalizeTxHash(chain, hash)
+	if chain == "" || hash == "" {
+		return nil, &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "chain and hash are needed"}
+	}
+
+	info, err := m.LoadUserDepositClaim(userID, chain, hash)
+	if err == nil {
+		return info, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	err = m.checkClaimLimits(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	info = &types.DepositClaim{
+		ClaimID: uuid.NewString(),
+		UserID:  userID,
+		Chain:   chain,
+		TxHash:  hash,
+		State:   types.DepositClaimPending,
+	}
+
+	saved, err := m.SaveDepositClaim(info)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if !saved {
+		// claimed by a concurrent request
+		info, err = m.LoadUserDepositClaim(userID, chain, hash)
+		if err != nil {
+			return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+		return info, nil
+	}
+
+	m.verifyClaim(ctx, info, "")
+
+	return m.loadClaim(info.ClaimID)
+}
+
+// RecheckDepositClaim verifies a claim waiting for review again, e.g. once the chain can be queried.
+// A claim left pending by a stopped mall is verified as well, a claim is never credited twice.
+func (m *ClaimManager) RecheckDepositClaim(ctx context.Context, claimID, adminID string) (*types.DepositClaim, error) {
+	info, err := m.loadClaim(claimID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch info.State {
+	case types.DepositClaimReview:
+		err = m.UpdateDepositClaim(claimID, types.DepositClaimPending, types.DepositClaimReview, "", "", adminID)
+		if err != nil {
+			return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+	case types.DepositClaimPending:
+	default:
+		return nil, &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
+
+	m.verifyClaim(ctx, info, adminID)
+
+	return m.loadClaim(claimID)
+}
+
+// RejectDepositClaim rejects a claim waiting for review.
+func (m *ClaimManager) RejectDepositClaim(claimID, adminID, msg string) error {
+	info, err := m.loadClaim(claimID)
+	if err != nil {
+		return err
+	}
+
+	if info.State != types.DepositClaimReview {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
+
+	err = m.UpdateDepositClaim(claimID, types.DepositClaimRejected, types.DepositClaimReview, info.Reason, msg, adminID)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// AcceptDepositClaim credits a claim waiting for review with the value checked by an admin,
+// e.g. a deposit on a chain that is not watched. The admin and the reason of the credit are saved with the claim
+// and a transaction already recorded as a deposit is never credited again.
+func (m *ClaimManager) AcceptDepositClaim(claimID, adminID, value, msg string) error {
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok || amount.Sign() <= 0 {
+		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "value must be a positive integer"}
+	}
+
+	if strings.TrimSpace(msg) == "" {
+		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "the reason of the credit is needed"}
+	}
+
+	info, err := m.loadClaim(claimID)
+	if err != nil {
+		return err
+	}
+
+	if info.State != types.DepositClaimReview {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
+
+	exist, err := m.RechargeRecordExists(info.TxHash)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if exist {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: "the deposit is recorded already"}
+	}
+
+	record := &types.RechargeRecord{
+		OrderID:  info.TxHash,
+		Chain:    info.Chain,
+		UserID:   info.UserID,
+		Value:    amount.String(),
+		RawValue: amount.String(),
+		Rate:     "1",
+	}
+
+	err = m.CreditDepositClaim(claimID, adminID, msg, record)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// checkClaimLimits checks the rate limits of the claims of a user.
+func (m *ClaimManager) checkClaimLimits(userID string) error {
+	cfg := m.cfg.DepositClaim
+
+	if cfg.MaxOpen > 0 {
+		count, err := m.CountOpenDepositClaims(userID)
+		if err != nil {
+			return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		if count >= cfg.MaxOpen {
+			return &api.ErrWeb{Code: terrors.DepositClaimLimit.Int(), Message: "too many claims waiting for review"}
+		}
+	}
+
+	limits := []struct {
+		limit  int64
+		period time.Duration
+	}{
+		{cfg.HourlyLimit, time.Hour},
+		{cfg.DailyLimit, 24 * time.Hour},
+	}
+
+	for _, l := range limits {
+		if l.limit <= 0 {
+			continue
+		}
+
+		count, err := m.CountDepositClaims(userID, time.Now().Add(-l.period))
+		if err != nil {
+			return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		if count >= l.limit {
+			return &api.ErrWeb{Code: terrors.DepositClaimLimit.Int(), Message: terrors.DepositClaimLimit.String()}
+		}
+	}
+
+	return nil
+}
+
+// verifyClaim verifies a pending claim on chain, it is accepted if the transaction pays a deposit address
+// of the user and moved to review with the reason otherwise.
+func (m *ClaimManager) verifyClaim(ctx context.Context, info *types.DepositClaim, adminID string) {
+	reason, msg := m.checkClaim(ctx, info)
+
+	state := types.DepositClaimAccepted
+	if reason != "" {
+		state = types.DepositClaimReview
+	}
+
+	err := m.UpdateDepositClaim(info.ClaimID, state, types.DepositClaimPending, reason, msg, adminID)
+	if err != nil {
+		log.Errorf("%s UpdateDepositClaim err:%s", info.ClaimID, err.Error())
+	}
+}
+
+// checkClaim returns why a claim cannot be accepted, the deposit of an accepted claim is recorded.
+func (m *ClaimManager) checkClaim(ctx context.Context, info *types.DepositClaim) (types.DepositClaimReason, string) {
+	if info.Chain != types.ChainTron {
+		return types.ClaimReasonUnsupportedChain, "deposits of " + info.Chain + " are checked by an admin"
+	}
+
+	record, err := m.LoadRechargeRecord(info.TxHash)
+	if err != nil && err != sql.ErrNoRows {
+		return types.ClaimReasonVerifyError, err.Error()
+	}
+
+	if record != nil {
+		if record.UserID != info.UserID {
+			return types.ClaimReasonOtherUser, ""
+		}
+		return "", "the deposit is recorded already"
+	}
+
+	deposits, err := m.chain.GetTronDeposits(ctx, info.TxHash)
+	if err != nil {
+		return types.ClaimReasonVerifyError, err.Error()
+	}
+
+	if !deposits.Found {
+		return types.ClaimReasonNotFound, ""
+	}
+
+	if !deposits.Success {
+		return types.ClaimReasonFailed, ""
+	}
+
+	paid := false
+	for _, tr := range deposits.List {
+		if tr.UserID == info.UserID {
+			paid = true
+			break
+		}
+	}
+
+	if !paid {
+		return types.ClaimReasonNotUserAddress, ""
+	}
+
+	// the deposit is recorded through the outbox and credited once its block is confirmed
+	err = m.chain.SupplementTronOrder(ctx, info.TxHash)
+	if err != nil {
+		return types.ClaimReasonVerifyError, err.Error()
+	}
+
+	return "", ""
+}
+
+func (m *ClaimManager) loadClaim(claimID string) (*types.DepositClaim, error) {
+	info, err := m.LoadDepositClaim(claimID)
+	if err == sql.ErrNoRows {
+		return nil, &api.ErrWeb{Code: terrors.NotFound.Int(), Message: "deposit claim not found"}
+	}
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// normalizeTxHash returns a transaction hash in the form saved by the chain watchers.
+func normalizeTxHash(chain, hash string) string {
+	hash = strings.TrimSpace(hash)
+	if chain == types.ChainTron {
+		hash = strings.TrimPrefix(strings.ToLower(hash), "0x")
+	}
+
+	return hash
+}
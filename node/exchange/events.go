@@ -0,0 +1,133 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/LMF709268224/titan-vps/node/outbox"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// chainEventsConsumer is the outbox consumer of the chain events in the mall
+	chainEventsConsumer = "mall_chain"
+	// submitPayoutInterval is how often the approved withdrawals are submitted to the transaction node
+	submitPayoutInterval = time.Minute
+)
+
+// ChainEventManager consumes the deposit and payout events of the transaction node,
+// they are dispatched from its outbox at least once. The recharge and withdraw records are written
+// by the mall only, the transaction node keeps the chain state in its own tables.
+type ChainEventManager struct {
+	*db.SQLDB
+	chain      api.Transaction
+	recharge   *RechargeManager
+	dispatcher *outbox.Dispatcher
+}
+
+// NewChainEventManager creates a new manager instance for consuming the chain events
+func NewChainEventManager(sdb *db.SQLDB, chain api.Transaction, rm *RechargeManager) (*ChainEventManager, error) {
+	m := &ChainEventManager{
+		SQLDB:    sdb,
+		chain:    chain,
+		recharge: rm,
+	}
+
+	m.dispatcher = outbox.NewDispatcher(sdb, chainEventsConsumer, chain.GetChainEvents, m.handleChainEvent)
+	m.dispatcher.Start()
+
+	go m.cronSubmitPayouts()
+
+	return m, nil
+}
+
+// handleChainEvent hands a chain event to its handler.
+func (m *ChainEventManager) handleChainEvent(info *types.OutboxEvent) error {
+	event := &types.ChainEvent{}
+	err := json.Unmarshal([]byte(info.Payload), event)
+	if err != nil {
+		return xerrors.Errorf("decode chain event %d err:%s", info.ID, err.Error())
+	}
+
+	switch event.Type {
+	case types.ChainEventTronTransfer:
+		return m.recharge.handleTronTransfer(event.TronTransfer)
+	case types.ChainEventTronDeposit:
+		return m.recharge.handleTronDeposit(event.TronDeposit)
+	case types.ChainEventFvmTransfer:
+		return m.recharge.handleFvmTransfer(event.FvmTransfer)
+	case types.ChainEventEvmTransfer:
+		return m.recharge.handleEvmTransfer(event.EvmTransfer)
+	case types.ChainEventPayout:
+		return m.handlePayout(event.Payout)
+	default:
+		log.Warnf("unknown chain event %d %s", info.ID, event.Type)
+		return nil
+	}
+}
+
+// handlePayout saves the state of the payout of a withdrawal and settles it once its payout is confirmed.
+func (m *ChainEventManager) handlePayout(payout *types.WithdrawPayout) error {
+	info, err := m.LoadWithdrawRecord(payout.OrderID)
+	if err != nil {
+		return xerrors.Errorf("%s LoadWithdrawRecord err:%s", payout.OrderID, err.Error())
+	}
+
+	switch payout.State {
+	case types.WithdrawBroadcast:
+		err = m.BroadcastWithdrawRecord(payout)
+		if err != nil {
+			return xerrors.Errorf("%s BroadcastWithdrawRecord err:%s", payout.OrderID, err.Error())
+		}
+	case types.WithdrawFailed:
+		log.Warnf("withdraw %s payout %s failed: %s", payout.OrderID, payout.TxHash, payout.Msg)
+
+		err = m.FailWithdrawRecord(payout)
+		if err != nil {
+			return xerrors.Errorf("%s FailWithdrawRecord err:%s", payout.OrderID, err.Error())
+		}
+	case types.WithdrawDone:
+		// a payout handled again is settled already
+		if info.State != types.WithdrawApproved && info.State != types.WithdrawBroadcast && info.State != types.WithdrawFailed {
+			return nil
+		}
+
+		err = m.ConfirmWithdrawRecord(info, payout.TxHash)
+		if err != nil {
+			return xerrors.Errorf("%s ConfirmWithdrawRecord err:%s", payout.OrderID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (m *ChainEventManager) cronSubmitPayouts() {
+	ticker := time.NewTicker(submitPayoutInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		m.submitPayouts()
+	}
+}
+
+// submitPayouts submits the payouts of the approved withdrawals to the transaction node, a payout submitted before is kept.
+func (m *ChainEventManager) submitPayouts() {
+	list, err := m.LoadApprovedWithdrawRecords()
+	if err != nil {
+		log.Errorf("LoadApprovedWithdrawRecords err:%s", err.Error())
+		return
+	}
+
+	for _, info := range list {
+		err = m.chain.SubmitPayout(context.Background(), info.OrderID, info.WithdrawAddr, info.PayoutValue())
+		if err != nil {
+			log.Errorf("%s SubmitPayout err:%s", info.OrderID, err.Error())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package exchange
+
+import (
+	"math/big"
+
+	"github.com/LMF709268224/titan-vps/node/config"
+	"golang.org/x/xerrors"
+)
+
+// withdrawFee returns the fee charged on a withdrawal of a token, the percentage part is rounded down.
+func withdrawFee(fees []config.WithdrawFeeCfg, contract string, value *big.Int) (*big.Int, error) {
+	fee := new(big.Int)
+
+	for _, cfg := range fees {
+		if cfg.ContractAddr != contract {
+			continue
+		}
+
+		fixed, err := feeAmount(cfg.Fixed)
+		if err != nil {
+			return nil, err
+		}
+		fee.Add(fee, fixed)
+
+		if cfg.Percent != "" {
+			percent, ok := new(big.Rat).SetString(cfg.Percent)
+			if !ok || percent.Sign() < 0 {
+				return nil, xerrors.Errorf("invalid withdraw fee percent %s", cfg.Percent)
+			}
+
+			part := new(big.Rat).Mul(new(big.Rat).SetInt(value), percent)
+			part.Quo(part, big.NewRat(100, 1))
+			fee.Add(fee, new(big.Int).Quo(part.Num(), part.Denom()))
+		}
+
+		min, err := feeAmount(cfg.Min)
+		if err != nil {
+			return nil, err
+		}
+
+		if fee.Cmp(min) < 0 {
+			fee.Set(min)
+		}
+
+		break
+	}
+
+	return fee, nil
+}
+
+func feeAmount(amount string) (*big.Int, error) {
+	if amount == "" {
+		return new(big.Int), nil
+	}
+
+	v, ok := new(big.Int).SetString(amount, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, xerrors.Errorf("invalid withdraw fee amount %s", amount)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,43 @@
+package exchange
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/node/config"
+)
+
+func TestWithdrawFee(t *testing.T) {
+	fees := []config.WithdrawFeeCfg{
+		{ContractAddr: "fixed", Fixed: "1000000"},
+		{ContractAddr: "percent", Percent: "0.5", Min: "2000000"},
+		{ContractAddr: "both", Fixed: "1000000", Percent: "0.1"},
+	}
+
+	cases := []struct {
+		contract string
+		value    int64
+		want     int64
+	}{
+		{"fixed", 50000000, 1000000},
+		{"percent", 1000000000, 5000000},
+		{"percent", 100000000, 2000000},
+		{"both", 100000001, 1100000},
+		{"free", 100000000, 0},
+	}
+
+	for _, c := range cases {
+		fee, err := withdrawFee(fees, c.contract, big.NewInt(c.value))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if fee.Int64() != c.want {
+			t.Errorf("fee of %d on %s is %s, want %d", c.value, c.contract, fee.String(), c.want)
+		}
+	}
+
+	if _, err := withdrawFee([]config.WithdrawFeeCfg{{ContractAddr: "bad", Percent: "x"}}, "bad", big.NewInt(1)); err == nil {
+		t.Errorf("withdrawFee should fail with an invalid percent")
+	}
+}
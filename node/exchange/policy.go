@@ -0,0 +1,96 @@
+package exchange
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/db"
+	"golang.org/x/xerrors"
+)
+
+// ValidateWithdrawPolicy checks the limits and durations of a withdraw policy.
+func ValidateWithdrawPolicy(policy *types.WithdrawPolicy) error {
+	limits := map[string]string{
+		"MinValue":         policy.MinValue,
+		"MaxValue":         policy.MaxValue,
+		"DailyLimit":       policy.DailyLimit,
+		"MonthlyLimit":     policy.MonthlyLimit,
+		"GlobalDailyLimit": policy.GlobalDailyLimit,
+	}
+
+	for name, limit := range limits {
+		if limit == "" {
+			continue
+		}
+
+		v, ok := new(big.Int).SetString(limit, 10)
+		if !ok || v.Sign() < 0 {
+			return xerrors.Errorf("invalid %s %s", name, limit)
+		}
+	}
+
+	if policy.LoginCooldown < 0 || policy.CredentialCooldown < 0 || policy.DepositHoldHours < 0 {
+		return xerrors.New("durations can not be negative")
+	}
+
+	return nil
+}
+
+// checkWithdrawPolicy checks a withdrawal against the withdraw policy, a violated rule is returned with its error code.
+func checkWithdrawPolicy(policy *types.WithdrawPolicy, usage *db.WithdrawUsage, value *big.Int) error {
+	if min, ok := policyLimit(policy.MinValue); ok && value.Cmp(min) < 0 {
+		return policyError(terrors.WithdrawBelowMinimum, policy.MinValue)
+	}
+
+	if max, ok := policyLimit(policy.MaxValue); ok && value.Cmp(max) > 0 {
+		return policyError(terrors.WithdrawAboveMaximum, policy.MaxValue)
+	}
+
+	if cooldownActive(usage.SinceLogin, policy.LoginCooldown) ||
+		cooldownActive(usage.SinceCredentialChange, policy.CredentialCooldown) {
+		return policyError(terrors.WithdrawCooldown, "")
+	}
+
+	if limit, ok := policyLimit(policy.DailyLimit); ok && new(big.Int).Add(usage.Daily, value).Cmp(limit) > 0 {
+		return policyError(terrors.WithdrawDailyLimit, policy.DailyLimit)
+	}
+
+	if limit, ok := policyLimit(policy.MonthlyLimit); ok && new(big.Int).Add(usage.Monthly, value).Cmp(limit) > 0 {
+		return policyError(terrors.WithdrawMonthlyLimit, policy.MonthlyLimit)
+	}
+
+	if limit, ok := policyLimit(policy.GlobalDailyLimit); ok && new(big.Int).Add(usage.GlobalDaily, value).Cmp(limit) > 0 {
+		return policyError(terrors.WithdrawGlobalLimit, policy.GlobalDailyLimit)
+	}
+
+	// the deposits on hold stay on the balance, only the rest of it can be withdrawn
+	if usage.HeldDeposits.Sign() > 0 && new(big.Int).Sub(usage.Balance, usage.HeldDeposits).Cmp(value) < 0 {
+		return policyError(terrors.WithdrawDepositHeld, usage.HeldDeposits.String())
+	}
+
+	return nil
+}
+
+func policyLimit(limit string) (*big.Int, bool) {
+	if limit == "" {
+		return nil, false
+	}
+
+	return new(big.Int).SetString(limit, 10)
+}
+
+func cooldownActive(since, cooldown int64) bool {
+	return cooldown > 0 && since >= 0 && since < cooldown
+}
+
+func policyError(code terrors.TError, limit string) error {
+	msg := code.String()
+	if limit != "" {
+		msg = fmt.Sprintf("%s: %s", msg, limit)
+	}
+
+	return &api.ErrWeb{Code: code.Int(), Message: msg}
+}
@@ -0,0 +1,92 @@
+package exchange
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/db"
+)
+
+func TestCheckWithdrawPolicy(t *testing.T) {
+	policy := &types.WithdrawPolicy{
+		MinValue:           "10",
+		MaxValue:           "1000",
+		DailyLimit:         "1500",
+		MonthlyLimit:       "5000",
+		GlobalDailyLimit:   "100000",
+		LoginCooldown:      600,
+		CredentialCooldown: 86400,
+		DepositHoldHours:   24,
+	}
+
+	usage := func() *db.WithdrawUsage {
+		return &db.WithdrawUsage{
+			Balance:               big.NewInt(3000),
+			Daily:                 big.NewInt(0),
+			Monthly:               big.NewInt(0),
+			GlobalDaily:           big.NewInt(0),
+			HeldDeposits:          big.NewInt(0),
+			SinceLogin:            3600,
+			SinceCredentialChange: -1,
+		}
+	}
+
+	cases := []struct {
+		name  string
+		value int64
+		edit  func(u *db.WithdrawUsage)
+		code  terrors.TError
+	}{
+		{"allowed", 500, nil, terrors.Success},
+		{"below minimum", 5, nil, terrors.WithdrawBelowMinimum},
+		{"above maximum", 1001, nil, terrors.WithdrawAboveMaximum},
+		{"login cooldown", 500, func(u *db.WithdrawUsage) { u.SinceLogin = 60 }, terrors.WithdrawCooldown},
+		{"credential cooldown", 500, func(u *db.WithdrawUsage) { u.SinceCredentialChange = 3600 }, terrors.WithdrawCooldown},
+		{"daily limit", 600, func(u *db.WithdrawUsage) { u.Daily.SetInt64(1000) }, terrors.WithdrawDailyLimit},
+		{"monthly limit", 600, func(u *db.WithdrawUsage) { u.Monthly.SetInt64(4500) }, terrors.WithdrawMonthlyLimit},
+		{"global limit", 600, func(u *db.WithdrawUsage) { u.GlobalDaily.SetInt64(99500) }, terrors.WithdrawGlobalLimit},
+		{"deposit held", 600, func(u *db.WithdrawUsage) { u.HeldDeposits.SetInt64(2500) }, terrors.WithdrawDepositHeld},
+		{"deposit partly held", 500, func(u *db.WithdrawUsage) { u.HeldDeposits.SetInt64(2500) }, terrors.Success},
+	}
+
+	for _, c := range cases {
+		u := usage()
+		if c.edit != nil {
+			c.edit(u)
+		}
+
+		err := checkWithdrawPolicy(policy, u, big.NewInt(c.value))
+		if c.code == terrors.Success {
+			if err != nil {
+				t.Errorf("%s: unexpected err %s", c.name, err.Error())
+			}
+			continue
+		}
+
+		webErr, ok := err.(*api.ErrWeb)
+		if !ok || webErr.Code != c.code.Int() {
+			t.Errorf("%s: err is %v, want code %d", c.name, err, c.code.Int())
+		}
+	}
+
+	if err := checkWithdrawPolicy(&types.WithdrawPolicy{}, usage(), big.NewInt(1)); err != nil {
+		t.Errorf("an empty policy should allow any withdrawal, err:%s", err.Error())
+	}
+}
+
+func TestValidateWithdrawPolicy(t *testing.T) {
+	if err := ValidateWithdrawPolicy(&types.WithdrawPolicy{MaxValue: "1000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ValidateWithdrawPolicy(&types.WithdrawPolicy{DailyLimit: "-1"}); err == nil {
+		t.Errorf("ValidateWithdrawPolicy should fail with a negative limit")
+	}
+
+	if err := ValidateWithdrawPolicy(&types.WithdrawPolicy{LoginCooldown: -1}); err == nil {
+		t.Errorf("ValidateWithdrawPolicy should fail with a negative cooldown")
+	}
+}
@@ -1,15 +1,15 @@
 package exchange
 
 import (
+	"fmt"
+
 	"github.com/LMF709268224/titan-vps/api/types"
 	"github.com/LMF709268224/titan-vps/lib/trxbridge/core"
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
-	"github.com/LMF709268224/titan-vps/node/transaction"
-	"github.com/LMF709268224/titan-vps/node/utils"
-	"github.com/filecoin-project/pubsub"
 	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
 )
 
 var log = logging.Logger("exchange")
@@ -17,92 +17,155 @@ var log = logging.Logger("exchange")
 // RechargeManager manages recharge orders
 type RechargeManager struct {
 	*db.SQLDB
-	cfg          config.MallCfg
-	notification *pubsub.PubSub
-	tMgr         *transaction.Manager
+	cfg config.MallCfg
 }
 
-// NewRechargeManager creates a new manager instance for handling recharge orders
-func NewRechargeManager(sdb *db.SQLDB, pb *pubsub.PubSub, getCfg dtypes.GetMallConfigFunc, fm *transaction.Manager) (*RechargeManager, error) {
+// NewRechargeManager creates a new manager instance for handling recharge orders,
+// the deposits are handed to it by the ChainEventManager.
+func NewRechargeManager(sdb *db.SQLDB, getCfg dtypes.GetMallConfigFunc) (*RechargeManager, error) {
 	cfg, err := getCfg()
 	if err != nil {
 		return nil, err
 	}
 
 	m := &RechargeManager{
-		SQLDB:        sdb,
-		notification: pb,
-		cfg:          cfg,
-		tMgr:         fm,
+		SQLDB: sdb,
+		cfg:   cfg,
 	}
 
-	go m.subscribeEvents()
-
 	return m, nil
 }
 
-// subscribeEvents subscribes to Tron transfer events and processes them
-func (m *RechargeManager) subscribeEvents() {
-	subTransfer := m.notification.Sub(types.EventTronTransferWatch.String())
-	defer m.notification.Unsub(subTransfer)
-
-	for {
-		select {
-		case u := <-subTransfer:
-			tr := u.(*types.TronTransferWatch)
-
-			m.handleTronTransfer(tr)
-		}
-	}
-}
-
-// handleTronTransfer handles Tron transfer events and updates user balances
-func (m *RechargeManager) handleTronTransfer(tr *types.TronTransferWatch) {
+// handleTronTransfer handles Tron transfer events and saves them as pending recharge records,
+// an error is returned if the event should be handled again.
+func (m *RechargeManager) handleTronTransfer(tr *types.TronTransferWatch) error {
 	if tr.State != core.Transaction_Result_SUCCESS {
 		// If the transaction state is not successful, skip processing.
-		return
+		return nil
 	}
 
 	// Check if the recharge record already exists for this transaction.
 	exist, err := m.RechargeRecordExists(tr.TxHash)
 	if err != nil {
-		log.Errorf("RechargeRecordExists error: %v", err)
-		return
+		return xerrors.Errorf("RechargeRecordExists err:%s", err.Error())
 	}
 
 	if exist {
 		// If the recharge record already exists, skip processing.
-		return
+		return nil
 	}
 
 	userID := tr.UserID
 
-	// Load the original user balance.
-	original, err := m.LoadUserBalance(userID)
+	info := &types.RechargeRecord{
+		OrderID:     tr.TxHash,
+		Chain:       types.ChainTron,
+		UserID:      userID,
+		Value:       tr.Value,
+		From:        tr.From,
+		State:       types.RechargeCreate,
+		To:          tr.To,
+		Token:       tr.Token,
+		RawValue:    tr.RawValue,
+		Rate:        tr.Rate,
+		BlockNumber: tr.BlockNumber,
+		BlockHash:   tr.BlockHash,
+	}
+
+	// Save the pending recharge record, the user balance is credited once its block is confirmed.
+	err = m.SaveRechargeRecord(info)
 	if err != nil {
-		log.Errorf("%s LoadUserBalance error: %s", userID, err.Error())
-		return
+		return xerrors.Errorf("%s SaveRechargeRecord err:%s", info.OrderID, err.Error())
 	}
 
-	info := &types.RechargeRecord{
-		OrderID: tr.TxHash,
-		UserID:  userID,
-		Value:   tr.Value,
-		From:    tr.From,
-		State:   types.RechargeDone,
-		To:      tr.To,
+	return nil
+}
+
+// handleTronDeposit saves the block or the state of a pending Tron recharge record reported by the transaction node,
+// it is credited once its block is confirmed. An error is returned if the record is not saved yet.
+func (m *RechargeManager) handleTronDeposit(deposit *types.TronDeposit) error {
+	info, err := m.LoadRechargeRecord(deposit.TxHash)
+	if err != nil {
+		return xerrors.Errorf("%s LoadRechargeRecord err:%s", deposit.TxHash, err.Error())
+	}
+
+	// a deposit handled again is saved already
+	if info.State != types.RechargeCreate {
+		return nil
+	}
+
+	switch deposit.State {
+	case types.RechargeCreate:
+		err = m.UpdateRechargeRecordBlock(info.OrderID, deposit.BlockNumber, deposit.BlockHash)
+		if err != nil {
+			return xerrors.Errorf("%s UpdateRechargeRecordBlock err:%s", info.OrderID, err.Error())
+		}
+	case types.RechargeDone:
+		err = m.ConfirmRechargeRecord(info, types.RechargeCreate)
+		if err != nil {
+			return xerrors.Errorf("%s ConfirmRechargeRecord err:%s", info.OrderID, err.Error())
+		}
+	default:
+		err = m.UpdateRechargeRecordState(info.OrderID, deposit.State, types.RechargeCreate)
+		if err != nil {
+			return xerrors.Errorf("%s UpdateRechargeRecordState err:%s", info.OrderID, err.Error())
+		}
 	}
 
-	// Calculate the new user balance.
-	value, err := utils.AddBigInt(original, tr.Value)
+	return nil
+}
+
+// handleFvmTransfer handles FEVM transfer events, their blocks are confirmed and they are credited at once
+func (m *RechargeManager) handleFvmTransfer(tr *types.FvmTransferWatch) error {
+	return m.creditConfirmedTransfer(&types.RechargeRecord{
+		// a transaction can transfer to several deposit addresses
+		OrderID:     fmt.Sprintf("%s-%d", tr.TxHash, tr.LogIndex),
+		Chain:       types.ChainFvm,
+		UserID:      tr.UserID,
+		Value:       tr.Value,
+		From:        tr.From,
+		To:          tr.To,
+		Token:       tr.Token,
+		RawValue:    tr.RawValue,
+		Rate:        "1",
+		BlockNumber: tr.BlockNumber,
+		BlockHash:   tr.BlockHash,
+	})
+}
+
+// handleEvmTransfer handles ERC-20 transfer events of the EVM chains, their blocks are confirmed and they are credited at once
+func (m *RechargeManager) handleEvmTransfer(tr *types.EvmTransferWatch) error {
+	return m.creditConfirmedTransfer(&types.RechargeRecord{
+		OrderID:     fmt.Sprintf("%s-%s-%d", tr.Chain, tr.TxHash, tr.LogIndex),
+		Chain:       tr.Chain,
+		UserID:      tr.UserID,
+		Value:       tr.Value,
+		From:        tr.From,
+		To:          tr.To,
+		Token:       tr.Token,
+		RawValue:    tr.RawValue,
+		Rate:        "1",
+		BlockNumber: tr.BlockNumber,
+		BlockHash:   tr.BlockHash,
+	})
+}
+
+// creditConfirmedTransfer saves a confirmed transfer as a done recharge record and credits the user balance
+func (m *RechargeManager) creditConfirmedTransfer(info *types.RechargeRecord) error {
+	exist, err := m.RechargeRecordExists(info.OrderID)
 	if err != nil {
-		log.Errorf("%s BigIntAdd error: %s", userID, err.Error())
-		return
+		return xerrors.Errorf("RechargeRecordExists err:%s", err.Error())
 	}
 
-	// Save the recharge record and update the user balance.
-	err = m.SaveRechargeRecordAndUserBalance(info, value, original)
+	if exist {
+		return nil
+	}
+
+	// the order id is unique, a transfer handled again is not credited twice
+	err = m.CreditRechargeRecord(info)
 	if err != nil {
-		log.Errorf("%s SaveRechargeRecordAndUserBalance error: %s", info.OrderID, err.Error())
+		return xerrors.Errorf("%s CreditRechargeRecord err:%s", info.OrderID, err.Error())
 	}
+
+	return nil
 }
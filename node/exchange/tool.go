@@ -16,14 +16,8 @@ func getGrpcClient(addr string) (*trxbridge.GrpcClient, error) {
 	return node, nil
 }
 
-// GetTronHeight retrieves the current block height of a Tron node at the specified address.
-func getTronHeight(addr string) int64 {
-	client, err := getGrpcClient(addr)
-	if err != nil {
-		log.Errorln("getGrpcClient err :", err.Error())
-		return 0
-	}
-
+// GetTronHeight retrieves the current block height of the Tron nodes of a pool.
+func getTronHeight(client *trxbridge.Pool) int64 {
 	block, err := client.GetNowBlock()
 	if err != nil {
 		log.Errorln("GetNowBlock err :", err.Error())
@@ -1,57 +1,95 @@
 package exchange
 
 import (
+	"context"
+	"math/big"
+
 	"github.com/LMF709268224/titan-vps/api"
 	"github.com/LMF709268224/titan-vps/api/terrors"
 	"github.com/LMF709268224/titan-vps/api/types"
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
-	"github.com/LMF709268224/titan-vps/node/transaction"
-	"github.com/LMF709268224/titan-vps/node/utils"
-	"github.com/filecoin-project/pubsub"
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 )
 
 // WithdrawManager manages withdrawal orders
 type WithdrawManager struct {
 	*db.SQLDB
-	cfg          config.MallCfg
-	notification *pubsub.PubSub
-	tMgr         *transaction.Manager
+	cfg   config.MallCfg
+	chain api.Transaction
 }
 
 // NewWithdrawManager creates a new manager instance for handling withdrawal orders
-func NewWithdrawManager(sdb *db.SQLDB, pb *pubsub.PubSub, getCfg dtypes.GetMallConfigFunc, fm *transaction.Manager) (*WithdrawManager, error) {
+func NewWithdrawManager(sdb *db.SQLDB, getCfg dtypes.GetMallConfigFunc, chain api.Transaction) (*WithdrawManager, error) {
 	cfg, err := getCfg()
 	if err != nil {
 		return nil, err
 	}
 
 	m := &WithdrawManager{
-		SQLDB:        sdb,
-		notification: pb,
-		cfg:          cfg,
-		tMgr:         fm,
+		SQLDB: sdb,
+		cfg:   cfg,
+		chain: chain,
 	}
 
 	return m, nil
 }
 
-// CreateWithdrawOrder creates a withdrawal order for a user
-func (m *WithdrawManager) CreateWithdrawOrder(userID, withdrawAddr, value string) error {
-	// Load the original user balance.
-	original, err := m.LoadUserBalance(userID)
+// PreviewWithdraw returns the fee charged on a withdrawal and the value paid out.
+func (m *WithdrawManager) PreviewWithdraw(value string) (*types.WithdrawPreview, error) {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok || v.Sign() <= 0 {
+		return nil, &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "invalid value " + value}
+	}
+
+	info, err := m.chain.GetChainInfo(context.Background())
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+	}
+
+	fee, err := withdrawFee(m.cfg.WithdrawFees, info.WithdrawContract, v)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+	}
+
+	net := new(big.Int).Sub(v, fee)
+	if net.Sign() <= 0 {
+		return nil, &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "value does not cover the fee " + fee.String()}
+	}
+
+	return &types.WithdrawPreview{Value: v.String(), Fee: fee.String(), NetValue: net.String()}, nil
+}
+
+// CreateWithdrawOrder creates a withdrawal order for a user, it is rejected if it violates the withdraw policy
+// or if the user is in the whitelist-only mode and the address is not usable in the address book.
+// The value is held on the user balance and the fee is charged on it, the id of the order is returned.
+// The creator is the user or the admin creating a manual withdrawal for the user, it can not approve the withdrawal.
+func (m *WithdrawManager) CreateWithdrawOrder(userID, creator, withdrawAddr, value string) (string, error) {
+	preview, err := m.PreviewWithdraw(value)
 	if err != nil {
-		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		return "", err
 	}
 
-	// Reduce the user's balance to cover the withdrawal amount.
-	newValue, err := utils.ReduceBigInt(original, value)
+	whitelistOnly, err := m.WithdrawWhitelistOnly(userID)
 	if err != nil {
-		return &api.ErrWeb{Code: terrors.InsufficientBalance.Int(), Message: terrors.InsufficientBalance.String()}
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
+	if whitelistOnly {
+		usable, err := m.WithdrawAddressUsable(userID, withdrawAddr)
+		if err != nil {
+			return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		if !usable {
+			return "", &api.ErrWeb{Code: terrors.WithdrawAddrNotWhitelisted.Int(), Message: terrors.WithdrawAddrNotWhitelisted.String()}
+		}
+	}
+
+	v, _ := new(big.Int).SetString(preview.Value, 10)
+
 	// Generate a unique order ID.
 	orderID := uuid.NewString()
 
@@ -60,15 +98,46 @@ func (m *WithdrawManager) CreateWithdrawOrder(userID, withdrawAddr, value string
 		OrderID:      orderID,
 		UserID:       userID,
 		WithdrawAddr: withdrawAddr,
-		Value:        value,
+		Value:        preview.Value,
+		Fee:          preview.Fee,
+		NetValue:     preview.NetValue,
+		Required:     requiredApprovals(m.cfg.WithdrawApprovalTiers, v),
 		State:        types.WithdrawCreate,
+		Creator:      creator,
+	}
+
+	// Save the withdrawal record and debit the user balance.
+	err = m.SaveWithdrawInfoAndUserBalance(info, func(policy *types.WithdrawPolicy, usage *db.WithdrawUsage) error {
+		return checkWithdrawPolicy(policy, usage, v)
+	})
+	if xerrors.Is(err, db.ErrInsufficientBalance) {
+		return "", &api.ErrWeb{Code: terrors.InsufficientBalance.Int(), Message: terrors.InsufficientBalance.String()}
 	}
 
-	// Save the withdrawal record and update the user balance.
-	err = m.SaveWithdrawInfoAndUserBalance(info, newValue, original)
+	var webErr *api.ErrWeb
+	if xerrors.As(err, &webErr) {
+		return "", webErr
+	}
 	if err != nil {
-		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return orderID, nil
+}
+
+// requiredApprovals returns the approvals needed by a withdrawal, the highest matching tier applies.
+func requiredApprovals(tiers []config.ApprovalTierCfg, value *big.Int) int64 {
+	required := int64(1)
+	for _, tier := range tiers {
+		min, ok := new(big.Int).SetString(tier.MinValue, 10)
+		if !ok || value.Cmp(min) < 0 {
+			continue
+		}
+
+		if tier.Approvals > required {
+			required = tier.Approvals
+		}
 	}
 
-	return nil
+	return required
 }
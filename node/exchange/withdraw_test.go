@@ -0,0 +1,30 @@
+package exchange
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/node/config"
+)
+
+func TestRequiredApprovals(t *testing.T) {
+	tiers := []config.ApprovalTierCfg{
+		{MinValue: "1000000000", Approvals: 2},
+		{MinValue: "10000000000", Approvals: 3},
+	}
+
+	cases := []struct {
+		value int64
+		want  int64
+	}{
+		{999999999, 1},
+		{1000000000, 2},
+		{20000000000, 3},
+	}
+
+	for _, c := range cases {
+		if got := requiredApprovals(tiers, big.NewInt(c.value)); got != c.want {
+			t.Errorf("withdraw of %d needs %d approvals, want %d", c.value, got, c.want)
+		}
+	}
+}
@@ -18,8 +18,13 @@ type (
 	ID struct{}
 	// LoginType filecoin tron eth ...
 	LoginType struct{}
+	// IdempotencyKey client idempotency key of the request
+	IdempotencyKey struct{}
 )
 
+// IdempotencyKeyHeader is the http header carrying the client idempotency key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 // Handler represents an HTTP handler that also adds remote client address and node ID to the request context
 type Handler struct {
 	// handler *auth.Handler
@@ -56,6 +61,16 @@ func GetLoginType(ctx context.Context) types.LoginType {
 	return v
 }
 
+// GetIdempotencyKey returns the idempotency key of the request, it is empty if the client did not send one
+func GetIdempotencyKey(ctx context.Context) string {
+	v, ok := ctx.Value(IdempotencyKey{}).(string)
+	if !ok {
+		return ""
+	}
+
+	return v
+}
+
 // New returns a new HTTP handler with the given auth handler and additional request context fields
 func New(verify func(ctx context.Context, token string) (*types.JWTPayload, error), next http.HandlerFunc) http.Handler {
 	return &Handler{verify, next}
@@ -71,6 +86,10 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	ctx = context.WithValue(ctx, RemoteAddr{}, remoteAddr)
 
+	if key := strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader)); key != "" {
+		ctx = context.WithValue(ctx, IdempotencyKey{}, key)
+	}
+
 	token := r.Header.Get("Authorization")
 
 	if token == "" {
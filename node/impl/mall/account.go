@@ -51,6 +51,11 @@ func (m *Mall) loginEmail(request *types.AccountRequest) (*types.AccountLoginRes
 		return nil, err
 	}
 
+	err = m.SaveSecurityEvent(email, types.SecurityEventLogin)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
 	rsp := &types.AccountLoginResponse{}
 	rsp.UserID = email
 	rsp.Token = string(tk)
@@ -91,6 +96,11 @@ func (m *Mall) loginMetaMask(request *types.AccountRequest) (*types.AccountLogin
 		return nil, err
 	}
 
+	err = m.SaveSecurityEvent(address, types.SecurityEventLogin)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
 	rsp := &types.AccountLoginResponse{}
 	rsp.UserID = address
 	rsp.Token = string(tk)
@@ -134,6 +144,11 @@ func (m *Mall) GetVerifyMessage(ctx context.Context, id string, loginType types.
 }
 
 func (m *Mall) getVerifyCode(email string) error {
+	return m.sendVerifyCode(email, email)
+}
+
+// sendVerifyCode mails a verify code checked with the key.
+func (m *Mall) sendVerifyCode(key, email string) error {
 	cfg, err := m.GetMallConfigFunc()
 	if err != nil {
 		return err
@@ -141,7 +156,7 @@ func (m *Mall) getVerifyCode(email string) error {
 
 	randNew := rand.New(rand.NewSource(time.Now().UnixNano()))
 	verifyCode := fmt.Sprintf("%06d", randNew.Intn(1000000))
-	err = m.Cache.Set(email, verifyCode)
+	err = m.Cache.Set(key, verifyCode)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,282 @@
+package mall
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/handler"
+	"github.com/smirkcat/hdwallet"
+)
+
+// AddWithdrawAddress adds an address to the withdrawal address book of the user and mails the verify code confirming it,
+// the code is mailed again if the address is pending.
+func (m *Mall) AddWithdrawAddress(ctx context.Context, addr, label string) error {
+	userID := handler.GetID(ctx)
+
+	if _, err := hdwallet.DecodeCheck(addr); err != nil {
+		return &api.ErrWeb{Code: terrors.WithdrawAddrError.Int(), Message: err.Error()}
+	}
+
+	email, err := m.userEmail(userID)
+	if err != nil {
+		return err
+	}
+
+	info, err := m.LoadWithdrawAddress(userID, addr)
+	if err != nil && err != sql.ErrNoRows {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if info != nil && info.State != types.WithdrawAddressPending {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
+
+	if info == nil {
+		err = m.SaveWithdrawAddress(&types.WithdrawAddress{UserID: userID, Addr: addr, Label: label, State: types.WithdrawAddressPending})
+		if err != nil {
+			return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+	}
+
+	return m.sendVerifyCode(withdrawAddressCodeKey(userID, addr), email)
+}
+
+// ConfirmWithdrawAddress confirms a pending address of the withdrawal address book with the mailed verify code,
+// it is usable after the configured lock period.
+func (m *Mall) ConfirmWithdrawAddress(ctx context.Context, addr, code string) error {
+	userID := handler.GetID(ctx)
+
+	err := m.Cache.Check(withdrawAddressCodeKey(userID, addr), code)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := m.GetMallConfigFunc()
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+	}
+
+	lock := int64(time.Duration(cfg.WithdrawAddressLock) / time.Second)
+
+	err = m.SQLDB.ConfirmWithdrawAddress(userID, addr, lock)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// RemoveWithdrawAddress removes an address from the withdrawal address book of the user.
+func (m *Mall) RemoveWithdrawAddress(ctx context.Context, addr string) error {
+	userID := handler.GetID(ctx)
+
+	err := m.SQLDB.RemoveWithdrawAddress(userID, addr)
+	if err == sql.ErrNoRows {
+		return &api.ErrWeb{Code: terrors.NotFoundAddress.Int(), Message: terrors.NotFoundAddress.String()}
+	}
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// GetWithdrawAddresses returns the withdrawal address book of the user.
+func (m *Mall) GetWithdrawAddresses(ctx context.Context) ([]*types.WithdrawAddress, error) {
+	userID := handler.GetID(ctx)
+
+	list, err := m.LoadWithdrawAddresses(userID)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return list, nil
+}
+
+// GetWithdrawAddressLogs returns the changes of the withdrawal address book of the user with pagination.
+func (m *Mall) GetWithdrawAddressLogs(ctx context.Context, limit, page int64) (*types.WithdrawAddressLogResponse, error) {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadWithdrawAddressLogs(userID, limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// GetWithdrawWhitelistOnly checks if the user can only withdraw to the usable addresses of the address book.
+func (m *Mall) GetWithdrawWhitelistOnly(ctx context.Context) (bool, error) {
+	userID := handler.GetID(ctx)
+
+	enabled, err := m.WithdrawWhitelistOnly(userID)
+	if err != nil {
+		return false, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return enabled, nil
+}
+
+// SendWithdrawWhitelistCode mails the verify code needed to turn off the whitelist-only mode.
+func (m *Mall) SendWithdrawWhitelistCode(ctx context.Context) error {
+	userID := handler.GetID(ctx)
+
+	email, err := m.userEmail(userID)
+	if err != nil {
+		return err
+	}
+
+	return m.sendVerifyCode(withdrawWhitelistCodeKey(userID), email)
+}
+
+// SetWithdrawWhitelistOnly turns the whitelist-only mode of the user on or off,
+// turning it off needs the verify code mailed by SendWithdrawWhitelistCode.
+func (m *Mall) SetWithdrawWhitelistOnly(ctx context.Context, enabled bool, code string) error {
+	userID := handler.GetID(ctx)
+
+	if !enabled {
+		err := m.Cache.Check(withdrawWhitelistCodeKey(userID), code)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := m.SaveWithdrawWhitelistOnly(userID, enabled)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// GetAccountEmail returns the verified e-mail the verify codes of the user are mailed to, it is empty if the user has none.
+func (m *Mall) GetAccountEmail(ctx context.Context) (string, error) {
+	userID := handler.GetID(ctx)
+
+	return m.verifiedEmail(userID)
+}
+
+// SendAccountEmailCode mails the verify code confirming the new account e-mail to the e-mail,
+// the code authorizing the change is mailed to the current account e-mail if the user has one.
+func (m *Mall) SendAccountEmailCode(ctx context.Context, email string) error {
+	userID := handler.GetID(ctx)
+
+	if !strings.Contains(email, "@") {
+		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "invalid e-mail"}
+	}
+
+	old, err := m.verifiedEmail(userID)
+	if err != nil {
+		return err
+	}
+
+	if old == email {
+		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "the e-mail is the account e-mail"}
+	}
+
+	if old != "" {
+		err = m.sendVerifyCode(accountEmailOldCodeKey(userID), old)
+		if err != nil {
+			return err
+		}
+	}
+
+	return m.sendVerifyCode(accountEmailCodeKey(userID, email), email)
+}
+
+// SetAccountEmail changes the account e-mail with the verify codes mailed by SendAccountEmailCode,
+// oldCode is only checked if the user has an account e-mail. The change starts the credential cooldown of the withdrawals
+// and locks the confirmed addresses of the address book again for the configured lock period.
+func (m *Mall) SetAccountEmail(ctx context.Context, email, code, oldCode string) error {
+	userID := handler.GetID(ctx)
+
+	old, err := m.verifiedEmail(userID)
+	if err != nil {
+		return err
+	}
+
+	if old != "" {
+		err = m.Cache.Check(accountEmailOldCodeKey(userID), oldCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = m.Cache.Check(accountEmailCodeKey(userID, email), code)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := m.GetMallConfigFunc()
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+	}
+
+	lock := int64(time.Duration(cfg.WithdrawAddressLock) / time.Second)
+
+	err = m.SaveAccountEmail(userID, email, lock)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	err = m.SaveSecurityEvent(userID, types.SecurityEventEmail)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// userEmail returns the verified e-mail the verify codes of a user are mailed to.
+func (m *Mall) userEmail(userID string) (string, error) {
+	email, err := m.verifiedEmail(userID)
+	if err != nil {
+		return "", err
+	}
+
+	if email == "" {
+		return "", &api.ErrWeb{Code: terrors.NotFoundEmail.Int(), Message: terrors.NotFoundEmail.String()}
+	}
+
+	return email, nil
+}
+
+// verifiedEmail returns the account e-mail set by the user with a verify code,
+// the user id is used if the user logged in with an e-mail code and has not set one.
+func (m *Mall) verifiedEmail(userID string) (string, error) {
+	email, err := m.LoadAccountEmail(userID)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if email != "" {
+		return email, nil
+	}
+
+	if strings.Contains(userID, "@") {
+		return userID, nil
+	}
+
+	return "", nil
+}
+
+func withdrawAddressCodeKey(userID, addr string) string {
+	return "withdraw_address:" + userID + ":" + addr
+}
+
+func withdrawWhitelistCodeKey(userID string) string {
+	return "withdraw_whitelist:" + userID
+}
+
+func accountEmailCodeKey(userID, email string) string {
+	return "account_email:" + userID + ":" + email
+}
+
+func accountEmailOldCodeKey(userID string) string {
+	return "account_email_old:" + userID
+}
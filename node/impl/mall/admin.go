@@ -2,6 +2,7 @@ package mall
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
@@ -9,11 +10,12 @@ import (
 	"github.com/LMF709268224/titan-vps/api"
 	"github.com/LMF709268224/titan-vps/api/terrors"
 	"github.com/LMF709268224/titan-vps/api/types"
-	"github.com/LMF709268224/titan-vps/lib/aliyun"
+	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/LMF709268224/titan-vps/node/exchange"
 	"github.com/LMF709268224/titan-vps/node/handler"
-	"github.com/LMF709268224/titan-vps/node/utils"
 	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/gbrlsnchs/jwt/v3"
+	"golang.org/x/xerrors"
 )
 
 // GetAdminSignCode generates a sign code for an admin user.
@@ -90,7 +92,8 @@ func (m *Mall) GetRechargeAddresses(ctx context.Context, limit, page int64) (*ty
 func (m *Mall) GetWithdrawalRecords(ctx context.Context, req *types.GetWithdrawRequest) (*types.GetWithdrawResponse, error) {
 	statuses := make([]types.WithdrawState, 0)
 	if req.State == "" {
-		statuses = []types.WithdrawState{types.WithdrawCreate, types.WithdrawDone, types.WithdrawRefund}
+		statuses = []types.WithdrawState{types.WithdrawCreate, types.WithdrawDone, types.WithdrawRefund,
+			types.WithdrawApproved, types.WithdrawBroadcast, types.WithdrawFailed}
 	} else {
 		s2, err := strconv.Atoi(req.State)
 		if err != nil {
@@ -108,7 +111,25 @@ func (m *Mall) GetWithdrawalRecords(ctx context.Context, req *types.GetWithdrawR
 	return info, nil
 }
 
-// ApproveUserWithdrawal approves a user withdrawal request.
+// CreateUserWithdrawal creates a manual withdrawal for a user and returns its id,
+// it needs the approvals of its tier and the admin creating it can not approve it.
+func (m *Mall) CreateUserWithdrawal(ctx context.Context, userID, withdrawAddr, value string) (string, error) {
+	exist, err := m.UserExists(userID)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if !exist {
+		return "", &api.ErrWeb{Code: terrors.NotFoundUser.Int(), Message: terrors.NotFoundUser.String()}
+	}
+
+	return m.withdraw(ctx, userID, withdrawAddr, value)
+}
+
+// ApproveUserWithdrawal records the approval of an admin on a user withdrawal request, it proceeds once it has the approvals of its tier
+// and an admin can not approve a withdrawal requested or created by the admin itself.
+// It is paid out by the executor if it is enabled and no withdrawHash is given, otherwise the final approval needs the withdrawHash of the manual payout.
+// A failed payout is completed by hand with the withdrawHash of the manual payout.
 func (m *Mall) ApproveUserWithdrawal(ctx context.Context, orderID, withdrawHash string) error {
 	userID := handler.GetID(ctx)
 
@@ -117,14 +138,51 @@ func (m *Mall) ApproveUserWithdrawal(ctx context.Context, orderID, withdrawHash
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
-	if info.State != types.WithdrawCreate {
-		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: string(info.State)}
+	if info.State != types.WithdrawCreate && info.State != types.WithdrawFailed {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
+
+	if info.UserID == userID || info.Creator == userID {
+		return &api.ErrWeb{Code: terrors.WithdrawSelfApproval.Int(), Message: terrors.WithdrawSelfApproval.String()}
+	}
+
+	if info.State == types.WithdrawCreate {
+		if withdrawHash != "" && info.Approvals+1 < info.Required {
+			return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: fmt.Sprintf("withdraw needs %d approvals before it is paid out", info.Required)}
+		}
+
+		queue := false
+		if withdrawHash == "" {
+			chain, err := m.TransactionAPI.GetChainInfo(ctx)
+			if err != nil {
+				return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+			}
+
+			queue = chain.WithdrawEnabled
+			if !queue && info.Approvals+1 >= info.Required {
+				return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "withdraw hash is required, the withdraw executor is not enabled"}
+			}
+		}
+
+		_, err = m.ApproveWithdrawal(orderID, userID, withdrawHash, queue)
+		if xerrors.Is(err, db.ErrWithdrawNotPending) {
+			return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: err.Error()}
+		}
+		if err != nil {
+			return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		return nil
+	}
+
+	if withdrawHash == "" {
+		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "withdraw hash is required"}
 	}
 
 	info.WithdrawHash = withdrawHash
 	info.Executor = userID
 
-	err = m.UpdateWithdrawRecord(info, types.WithdrawDone)
+	err = m.ApproveWithdrawRecord(info)
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
@@ -132,7 +190,8 @@ func (m *Mall) ApproveUserWithdrawal(ctx context.Context, orderID, withdrawHash
 	return nil
 }
 
-// RejectUserWithdrawal rejects a user withdrawal request.
+// RejectUserWithdrawal records the rejection of an admin on a user withdrawal request, it is refunded once it has the rejections of its tier.
+// A failed payout is rejected only if its last transaction paid nothing, otherwise it is retried or completed with its hash.
 func (m *Mall) RejectUserWithdrawal(ctx context.Context, orderID string) error {
 	userID := handler.GetID(ctx)
 
@@ -141,28 +200,59 @@ func (m *Mall) RejectUserWithdrawal(ctx context.Context, orderID string) error {
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
-	if info.State != types.WithdrawCreate {
-		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: string(info.State)}
+	if info.State != types.WithdrawCreate && info.State != types.WithdrawFailed {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
 	}
 
-	info.Executor = userID
+	if info.State == types.WithdrawFailed && info.WithdrawHash != "" {
+		failed, err := m.TransactionAPI.TronPayoutFailed(ctx, info.WithdrawHash, info.TxExpiration)
+		if err != nil {
+			return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+		}
+
+		if !failed {
+			return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: fmt.Sprintf("payout %s may have been paid, retry it or complete it with its hash", info.WithdrawHash)}
+		}
+	}
 
-	err = m.UpdateWithdrawRecord(info, types.WithdrawRefund)
+	_, err = m.RejectWithdrawal(orderID, userID, info.WithdrawHash)
+	if xerrors.Is(err, db.ErrWithdrawNotPending) {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: err.Error()}
+	}
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
-	original, err := m.LoadUserBalance(info.UserID)
+	return nil
+}
+
+// RetryUserWithdrawal hands a failed payout back to the executor.
+func (m *Mall) RetryUserWithdrawal(ctx context.Context, orderID string) error {
+	chain, err := m.TransactionAPI.GetChainInfo(ctx)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+	}
+
+	if !chain.WithdrawEnabled {
+		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: "withdraw executor is not enabled"}
+	}
+
+	info, err := m.LoadWithdrawRecord(orderID)
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
-	newValue, err := utils.AddBigInt(original, info.Value)
+	if info.State != types.WithdrawFailed {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
+
+	// the transaction node keeps the attempts of the payout, it is paid again with all its attempts
+	err = m.TransactionAPI.RetryPayout(ctx, orderID)
 	if err != nil {
-		return err
+		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
 	}
 
-	err = m.UpdateUserBalance(info.UserID, newValue, original)
+	err = m.RetryWithdrawRecord(orderID)
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
@@ -182,41 +272,55 @@ func (m *Mall) AddAdminUser(ctx context.Context, userID, nickName string) error
 
 // SupplementRechargeOrder supplements a recharge order.
 func (m *Mall) SupplementRechargeOrder(ctx context.Context, hash string) error {
-	return m.TransactionMgr.SupplementOrder(hash)
+	return m.TransactionAPI.SupplementTronOrder(ctx, hash)
 }
 
-// RefundInstance is a method that handles refund request for a specific instance
-func (m *Mall) RefundInstance(ctx context.Context, instanceID string) (int64, error) {
-	userID := handler.GetID(ctx)
+// GetReviewRecharges returns the confirmed recharges parked for review.
+func (m *Mall) GetReviewRecharges(ctx context.Context) ([]*types.RechargeRecord, error) {
+	list, err := m.LoadRechargeRecords(types.RechargeReview)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
 
-	accessKeyID, accessKeySecret := m.getAliAccessKeys()
+	return list, nil
+}
 
-	oID, err := aliyun.RefundInstance(accessKeyID, accessKeySecret, instanceID)
+// ApproveRechargeReview credits a recharge parked for review to the user balance.
+func (m *Mall) ApproveRechargeReview(ctx context.Context, orderID string) error {
+	info, err := m.LoadRechargeRecord(orderID)
 	if err != nil {
-		return 0, err
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if info.State != types.RechargeReview {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
 	}
 
-	err = m.UpdateInstanceState(instanceID, "")
+	err = m.ConfirmRechargeRecord(info, types.RechargeReview)
 	if err != nil {
-		return 0, err
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
-	err = m.SaveInstanceRefundInfo(instanceID, userID)
-	return oID, err
+	return nil
 }
 
-// InquiryPriceRefundInstance is a method that inquires the price of refunding a specific instance
-func (m *Mall) InquiryPriceRefundInstance(ctx context.Context, instanceID string) (float32, error) {
-	accessKeyID, accessKeySecret := m.getAliAccessKeys()
-
-	amount, err := aliyun.InquiryPriceRefundInstance(accessKeyID, accessKeySecret, instanceID)
+// RejectRechargeReview rejects a recharge parked for review, it is not credited.
+func (m *Mall) RejectRechargeReview(ctx context.Context, orderID string) error {
+	info, err := m.LoadRechargeRecord(orderID)
 	if err != nil {
-		return 0, err
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
-	usdRate := utils.GetUSDRate()
+	if info.State != types.RechargeReview {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
 
-	return float32(amount) / usdRate, err
+	err = m.UpdateRechargeRecordState(orderID, types.RechargeRejected, types.RechargeReview)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
 }
 
 // GetInstanceRecords is a method that retrieves the records of instances
@@ -246,19 +350,8 @@ func (m *Mall) GetInstanceRecords(ctx context.Context, limit, page int64) (*type
 			info.RefundTime = rInfo.RefundTime
 		}
 
-		orders, err := m.LoadOrderRecordsByVpsID(info.ID, types.Done, types.OrderDoneStateSuccess)
+		tradePrice, err := m.loadInstanceTradePrice(info.ID)
 		if err == nil {
-			tradePrice := "0"
-			for _, order := range orders {
-				price, err := utils.AddBigInt(tradePrice, order.Value)
-				if err != nil {
-					log.Errorf("AddBigInt %s,%s ,%s", tradePrice, order.Value, err.Error())
-					continue
-				}
-
-				tradePrice = price
-			}
-
 			info.Value = tradePrice
 		}
 
@@ -267,3 +360,126 @@ func (m *Mall) GetInstanceRecords(ctx context.Context, limit, page int64) (*type
 
 	return out, nil
 }
+
+// GetSweepRecords retrieves the sweeps of the deposit addresses with pagination.
+func (m *Mall) GetSweepRecords(ctx context.Context, limit, page int64) (*types.SweepRecordResponse, error) {
+	info, err := m.LoadSweepRecords(limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// SweepDepositAddresses starts sweeping the deposit addresses into the collection address.
+func (m *Mall) SweepDepositAddresses(ctx context.Context) error {
+	err := m.TransactionAPI.SweepDepositAddresses(ctx)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// GetWithdrawPolicy returns the risk policy checked when a withdrawal is created.
+func (m *Mall) GetWithdrawPolicy(ctx context.Context) (*types.WithdrawPolicy, error) {
+	info, err := m.LoadWithdrawPolicy()
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// SetWithdrawPolicy replaces the risk policy checked when a withdrawal is created.
+func (m *Mall) SetWithdrawPolicy(ctx context.Context, policy *types.WithdrawPolicy) error {
+	if policy == nil {
+		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: terrors.ParametersWrong.String()}
+	}
+
+	err := exchange.ValidateWithdrawPolicy(policy)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: err.Error()}
+	}
+
+	err = m.SaveWithdrawPolicy(policy)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// GetWithdrawApprovals returns the admin decisions on a withdrawal.
+func (m *Mall) GetWithdrawApprovals(ctx context.Context, orderID string) ([]*types.WithdrawApproval, error) {
+	list, err := m.LoadWithdrawApprovals(orderID)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return list, nil
+}
+
+// GetOutboxEvents returns the events of a topic of the mall outbox from an id, they are kept for the consumers outside the mall.
+func (m *Mall) GetOutboxEvents(ctx context.Context, topic string, from, limit int64) (*types.OutboxEventsResponse, error) {
+	info, err := m.LoadOutboxEvents([]string{topic}, from, limit)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// GetOutboxDeliveries returns the events a consumer failed to handle in a state, all the consumers are returned if consumer is empty.
+func (m *Mall) GetOutboxDeliveries(ctx context.Context, consumer string, state types.OutboxDeliveryState, limit, page int64) (*types.OutboxDeliveryResponse, error) {
+	info, err := m.LoadOutboxDeliveries(consumer, state, limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// RedriveOutboxDelivery hands a dead-lettered event to its consumer again.
+func (m *Mall) RedriveOutboxDelivery(ctx context.Context, consumer string, eventID int64) error {
+	err := m.SQLDB.RedriveOutboxDelivery(consumer, eventID)
+	if err == sql.ErrNoRows {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: "the event is not dead-lettered"}
+	}
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// GetDepositClaims returns the deposit claims in a state with pagination.
+func (m *Mall) GetDepositClaims(ctx context.Context, state types.DepositClaimState, limit, page int64) (*types.DepositClaimResponse, error) {
+	info, err := m.LoadDepositClaims(state, limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// RecheckDepositClaim verifies a deposit claim waiting for review on chain again.
+func (m *Mall) RecheckDepositClaim(ctx context.Context, claimID string) (*types.DepositClaim, error) {
+	adminID := handler.GetID(ctx)
+
+	return m.ClaimMgr.RecheckDepositClaim(ctx, claimID, adminID)
+}
+
+// AcceptDepositClaim credits a deposit claim waiting for review with the value checked by the admin and the reason of the credit.
+func (m *Mall) AcceptDepositClaim(ctx context.Context, claimID, value, msg string) error {
+	adminID := handler.GetID(ctx)
+
+	return m.ClaimMgr.AcceptDepositClaim(claimID, adminID, value, msg)
+}
+
+// RejectDepositClaim rejects a deposit claim waiting for review.
+func (m *Mall) RejectDepositClaim(ctx context.Context, claimID, msg string) error {
+	adminID := handler.GetID(ctx)
+
+	return m.ClaimMgr.RejectDepositClaim(claimID, adminID, msg)
+}
@@ -0,0 +1,88 @@
+package mall
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/handler"
+)
+
+// maxIdempotencyKeyLen is the length of the idempotency key column
+const maxIdempotencyKeyLen = 128
+
+// withIdempotency runs fn once per client idempotency key of the request,
+// replays of the key return the result of the first call. Requests without a key always run fn.
+func (m *Mall) withIdempotency(ctx context.Context, method string, params interface{}, fn func() (string, error)) (string, error) {
+	key := handler.GetIdempotencyKey(ctx)
+	if key == "" {
+		return fn()
+	}
+
+	if len(key) > maxIdempotencyKeyLen {
+		return "", &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: fmt.Sprintf("idempotency key is longer than %d", maxIdempotencyKeyLen)}
+	}
+
+	userID := handler.GetID(ctx)
+
+	buf, err := json.Marshal(params)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: err.Error()}
+	}
+	hash := sha256.Sum256(buf)
+
+	record := &types.IdempotencyRecord{
+		UserID:      userID,
+		IdemKey:     key,
+		Method:      method,
+		RequestHash: hex.EncodeToString(hash[:]),
+	}
+
+	reserved, err := m.ReserveIdempotencyKey(record)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if !reserved {
+		return m.replayIdempotencyRecord(record)
+	}
+
+	out, err := fn()
+	if err != nil {
+		// the request failed, the client may retry it with the same key
+		if dErr := m.DeleteIdempotencyRecord(userID, key); dErr != nil {
+			log.Errorf("DeleteIdempotencyRecord err:%s", dErr.Error())
+		}
+		return "", err
+	}
+
+	err = m.CompleteIdempotencyRecord(userID, key, out)
+	if err != nil {
+		log.Errorf("CompleteIdempotencyRecord err:%s", err.Error())
+	}
+
+	return out, nil
+}
+
+// replayIdempotencyRecord returns the result saved for an idempotency key
+func (m *Mall) replayIdempotencyRecord(record *types.IdempotencyRecord) (string, error) {
+	saved, err := m.LoadIdempotencyRecord(record.UserID, record.IdemKey)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if saved.Method != record.Method || saved.RequestHash != record.RequestHash {
+		return "", &api.ErrWeb{Code: terrors.IdempotencyKeyConflict.Int(), Message: terrors.IdempotencyKeyConflict.String()}
+	}
+
+	if !saved.Done {
+		return "", &api.ErrWeb{Code: terrors.IdempotencyKeyConflict.Int(), Message: "request with this idempotency key is in progress"}
+	}
+
+	return saved.Response, nil
+}
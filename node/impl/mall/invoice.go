@@ -0,0 +1,87 @@
+package mall
+
+import (
+	"context"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/handler"
+	"github.com/LMF709268224/titan-vps/node/invoice"
+)
+
+// SetBillingInfo sets the company billing details printed on the user's future invoices.
+func (m *Mall) SetBillingInfo(ctx context.Context, info *types.BillingInfo) error {
+	info.UserID = handler.GetID(ctx)
+
+	err := m.SaveBillingInfo(info)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// GetBillingInfo retrieves the company billing details of the user.
+func (m *Mall) GetBillingInfo(ctx context.Context) (*types.BillingInfo, error) {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadBillingInfo(userID)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// GetUserInvoices retrieves user's invoices with pagination.
+func (m *Mall) GetUserInvoices(ctx context.Context, limit, page int64) (*types.InvoiceResponse, error) {
+	userID := handler.GetID(ctx)
+
+	out, err := m.LoadInvoicesByUser(userID, limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	for _, info := range out.List {
+		if err := invoice.DecodeInvoice(info); err != nil {
+			log.Errorf("DecodeInvoice %d err:%s", info.InvoiceNo, err.Error())
+		}
+	}
+
+	return out, nil
+}
+
+// GetInvoice retrieves an invoice, users can only see their own invoices.
+func (m *Mall) GetInvoice(ctx context.Context, invoiceNo int64) (*types.Invoice, error) {
+	info, err := m.LoadInvoice(invoiceNo)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if !api.HasPerm(ctx, api.RoleDefault, api.RoleAdmin) && info.UserID != handler.GetID(ctx) {
+		return nil, &api.ErrWeb{Code: terrors.UserMismatch.Int(), Message: terrors.UserMismatch.String()}
+	}
+
+	err = invoice.DecodeInvoice(info)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DecodingError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// RenderInvoice renders an invoice as html or pdf.
+func (m *Mall) RenderInvoice(ctx context.Context, invoiceNo int64, format string) ([]byte, error) {
+	info, err := m.GetInvoice(ctx, invoiceNo)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := invoice.Render(info, format)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: err.Error()}
+	}
+
+	return out, nil
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/LMF709268224/titan-vps/api"
 	"github.com/LMF709268224/titan-vps/node/account"
 	"github.com/LMF709268224/titan-vps/node/exchange"
+	"github.com/LMF709268224/titan-vps/node/invoice"
+	"github.com/LMF709268224/titan-vps/node/statement"
 	"github.com/LMF709268224/titan-vps/node/user"
 	"github.com/LMF709268224/titan-vps/node/utils"
 	"github.com/LMF709268224/titan-vps/node/vps"
@@ -24,8 +26,6 @@ import (
 	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
 	"github.com/LMF709268224/titan-vps/node/orders"
-	"github.com/LMF709268224/titan-vps/node/transaction"
-	"github.com/filecoin-project/pubsub"
 	logging "github.com/ipfs/go-log/v2"
 
 	"go.uber.org/fx"
@@ -38,17 +38,20 @@ var log = logging.Logger("mall")
 type Mall struct {
 	fx.In
 	*common.CommonAPI
-	TransactionMgr *transaction.Manager
+	TransactionAPI api.Transaction
 	*exchange.RechargeManager
 	*exchange.WithdrawManager
-	Notify *pubsub.PubSub
+	ChainEventMgr *exchange.ChainEventManager
+	ClaimMgr      *exchange.ClaimManager
 	*db.SQLDB
 	*account.Cache
 	OrderMgr *orders.Manager
 	dtypes.GetMallConfigFunc
-	UserMgr    *user.Manager
-	VpsMgr     *vps.Manager
-	AccountMgr *account.Manager
+	UserMgr      *user.Manager
+	VpsMgr       *vps.Manager
+	AccountMgr   *account.Manager
+	InvoiceMgr   *invoice.Manager
+	StatementMgr *statement.Manager
 }
 
 // getAliAccessKeys retrieves Aliyun access keys from the configuration.
@@ -153,6 +156,7 @@ func (m *Mall) DescribePrice(ctx context.Context, priceReq *types.DescribePriceR
 
 	usdRate := utils.GetUSDRate()
 	price.USDPrice = price.USDPrice / usdRate
+	price.USDRate = usdRate
 
 	return price, nil
 }
@@ -15,9 +15,15 @@ import (
 	"github.com/LMF709268224/titan-vps/api/terrors"
 	"github.com/LMF709268224/titan-vps/api/types"
 	"github.com/LMF709268224/titan-vps/node/handler"
+	"github.com/LMF709268224/titan-vps/node/utils"
+	"github.com/LMF709268224/titan-vps/node/vps"
 )
 
-const decimal = 1000000
+const (
+	decimal = 1000000
+	// maxOrderAmount is the maximum number of instances bought by one order
+	maxOrderAmount = 20
+)
 
 func countEndDate(unit string, period int) time.Time {
 	tt := time.Now()
@@ -36,8 +42,27 @@ func countEndDate(unit string, period int) time.Time {
 
 // CreateOrder creates a new order.
 func (m *Mall) CreateOrder(ctx context.Context, req types.CreateOrderReq) (string, error) {
+	return m.withIdempotency(ctx, "CreateOrder", req, func() (string, error) {
+		return m.createOrder(ctx, req)
+	})
+}
+
+func (m *Mall) createOrder(ctx context.Context, req types.CreateOrderReq) (string, error) {
 	userID := handler.GetID(ctx)
 
+	if req.Amount <= 0 {
+		req.Amount = 1
+	}
+
+	if req.Amount > maxOrderAmount {
+		return "", &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: fmt.Sprintf("amount can not be greater than %d", maxOrderAmount)}
+	}
+
+	userData, err := vps.ValidateUserData(req.UserData)
+	if err != nil {
+		return "", err
+	}
+
 	instanceDetails := &types.InstanceDetails{
 		RegionId:           req.RegionId,
 		InstanceType:       req.InstanceType,
@@ -54,6 +79,7 @@ func (m *Mall) CreateOrder(ctx context.Context, req types.CreateOrderReq) (strin
 		BandwidthIn:        req.InternetMaxBandwidthIn,
 		AutoRenew:          req.Renew,
 		State:              "Pending",
+		UserData:           userData,
 	}
 
 	// Marshal DataDisk if it's not empty
@@ -91,27 +117,39 @@ func (m *Mall) CreateOrder(ctx context.Context, req types.CreateOrderReq) (strin
 	hash := uuid.NewString()
 	orderID := strings.Replace(hash, "-", "", -1)
 	instanceDetails.OrderID = orderID
-	instanceDetails.Value = newBalanceString
 
-	id, err := m.SaveInstanceInfoOfUser(instanceDetails)
+	// every instance of the order is priced and fulfilled as a separate item
+	values, err := utils.SplitBigInt(newBalanceString, int(req.Amount))
 	if err != nil {
-		log.Errorf("SaveVpsInstance:%v", err)
 		return "", err
 	}
 
+	items := make([]*types.OrderItem, 0, len(values))
+	for i, value := range values {
+		items = append(items, &types.OrderItem{
+			OrderID:   orderID,
+			ItemIndex: int64(i),
+			Value:     value,
+			Refund:    "0",
+			USDRate:   priceInfo.USDRate,
+			State:     types.OrderItemPending,
+		})
+	}
+
 	endDate := countEndDate(req.PeriodUnit, int(req.Period))
 
 	// Create an order record
 	info := &types.OrderRecord{
-		VpsID:     id,
 		OrderID:   orderID,
 		UserID:    userID,
 		Value:     newBalanceString,
 		OrderType: types.BuyVPS,
 		CycleTime: fmt.Sprintf("%s - %s", time.Now().Format("2006-01-02 15:04:05"), endDate.Format("2006-01-02 15:04:05")),
+		Amount:    int64(len(items)),
 	}
 
-	err = m.OrderMgr.CreatedOrder(info)
+	// the instances are saved with the items of the order
+	err = m.OrderMgr.CreatedOrder(info, instanceDetails, items)
 	if err != nil {
 		return "", err
 	}
@@ -121,6 +159,12 @@ func (m *Mall) CreateOrder(ctx context.Context, req types.CreateOrderReq) (strin
 
 // RenewOrder renews an existing order.
 func (m *Mall) RenewOrder(ctx context.Context, renewReq types.RenewOrderReq) (string, error) {
+	return m.withIdempotency(ctx, "RenewOrder", renewReq, func() (string, error) {
+		return m.renewOrder(ctx, renewReq)
+	})
+}
+
+func (m *Mall) renewOrder(ctx context.Context, renewReq types.RenewOrderReq) (string, error) {
 	userID := handler.GetID(ctx)
 
 	req, err := m.LoadUserInstanceInfoByInstanceID(renewReq.InstanceId)
@@ -184,9 +228,20 @@ func (m *Mall) RenewOrder(ctx context.Context, renewReq types.RenewOrderReq) (st
 		Value:     newBalanceString,
 		OrderType: types.RenewVPS,
 		CycleTime: fmt.Sprintf("%s - %s", eTime.Format("2006-01-02 15:04:05"), endDate.Format("2006-01-02 15:04:05")),
+		Amount:    1,
+	}
+
+	item := &types.OrderItem{
+		OrderID:    orderID,
+		VpsID:      req.ID,
+		InstanceID: req.InstanceId,
+		Value:      newBalanceString,
+		Refund:     "0",
+		USDRate:    priceInfo.USDRate,
+		State:      types.OrderItemPending,
 	}
 
-	err = m.OrderMgr.CreatedOrder(info)
+	err = m.OrderMgr.CreatedOrder(info, nil, []*types.OrderItem{item})
 	if err != nil {
 		return "", err
 	}
@@ -224,8 +279,101 @@ func (m *Mall) CancelUserOrder(ctx context.Context, orderID string) error {
 	return m.OrderMgr.CancelOrder(orderID, userID)
 }
 
+// GetUserOrderItems retrieves the items and their fulfilment state of a user's order.
+func (m *Mall) GetUserOrderItems(ctx context.Context, orderID string) ([]*types.OrderItem, error) {
+	userID := handler.GetID(ctx)
+
+	order, err := m.LoadOrderRecord(orderID, int64(m.OrderMgr.GetOrderTimeoutDurationMinutes()))
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if order.UserID != userID {
+		return nil, &api.ErrWeb{Code: terrors.UserMismatch.Int(), Message: terrors.UserMismatch.String()}
+	}
+
+	items, err := m.LoadOrderItems(orderID)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return items, nil
+}
+
+// GetOrderTimeline retrieves the state transitions of an order, users can only see their own orders.
+func (m *Mall) GetOrderTimeline(ctx context.Context, orderID string) ([]*types.OrderEvent, error) {
+	if !api.HasPerm(ctx, api.RoleDefault, api.RoleAdmin) {
+		userID := handler.GetID(ctx)
+
+		order, err := m.LoadOrderRecord(orderID, int64(m.OrderMgr.GetOrderTimeoutDurationMinutes()))
+		if err != nil {
+			return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		if order.UserID != userID {
+			return nil, &api.ErrWeb{Code: terrors.UserMismatch.Int(), Message: terrors.UserMismatch.String()}
+		}
+	}
+
+	events, err := m.LoadOrderEvents(orderID)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return events, nil
+}
+
 // PaymentUserOrder marks a user's order as paid.
 func (m *Mall) PaymentUserOrder(ctx context.Context, orderID string) error {
 	userID := handler.GetID(ctx)
-	return m.OrderMgr.PaymentCompleted(orderID, userID)
+
+	_, err := m.withIdempotency(ctx, "PaymentUserOrder", orderID, func() (string, error) {
+		return orderID, m.OrderMgr.PaymentCompleted(orderID, userID)
+	})
+
+	return err
+}
+
+// loadInstanceTradePrice sums the value paid for an instance by its fulfilled order items,
+// orders created before items were introduced are counted by their order value.
+func (m *Mall) loadInstanceTradePrice(vpsID int64) (string, error) {
+	items, err := m.LoadOrderItemsByVpsID(vpsID, types.OrderItemSucceeded)
+	if err != nil {
+		return "", err
+	}
+
+	tradePrice := "0"
+	itemOrders := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		itemOrders[item.OrderID] = struct{}{}
+
+		price, err := utils.AddBigInt(tradePrice, item.Value)
+		if err != nil {
+			log.Errorf("AddBigInt %s,%s ,%s", tradePrice, item.Value, err.Error())
+			continue
+		}
+
+		tradePrice = price
+	}
+
+	orders, err := m.LoadOrderRecordsByVpsID(vpsID, types.Done, types.OrderDoneStateSuccess)
+	if err != nil {
+		return "", err
+	}
+
+	for _, order := range orders {
+		if _, ok := itemOrders[order.OrderID]; ok {
+			continue
+		}
+
+		price, err := utils.AddBigInt(tradePrice, order.Value)
+		if err != nil {
+			log.Errorf("AddBigInt %s,%s ,%s", tradePrice, order.Value, err.Error())
+			continue
+		}
+
+		tradePrice = price
+	}
+
+	return tradePrice, nil
 }
@@ -0,0 +1,310 @@
+package mall
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/lib/aliyun"
+	"github.com/LMF709268224/titan-vps/node/handler"
+	"github.com/LMF709268224/titan-vps/node/utils"
+)
+
+// RequestInstanceRefund files a refund request for a user's instance, the refund is executed after an admin approves it.
+func (m *Mall) RequestInstanceRefund(ctx context.Context, instanceID, reason string) (string, error) {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadInstanceInfoByUser(userID, instanceID)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return m.createRefundRecord(info, reason)
+}
+
+// GetUserRefundRecords retrieves user's refund records with pagination.
+func (m *Mall) GetUserRefundRecords(ctx context.Context, limit, page int64) (*types.GetRefundResponse, error) {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadRefundRecords(userID, limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// GetRefundRecords retrieves refund records of all users with pagination.
+func (m *Mall) GetRefundRecords(ctx context.Context, limit, page int64) (*types.GetRefundResponse, error) {
+	info, err := m.LoadRefundRecords("", limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
+// ApproveInstanceRefund approves a refund request, refunds the instance at the provider and credits the user balance.
+// It can be called again to resume a refund that was interrupted after the approval.
+func (m *Mall) ApproveInstanceRefund(ctx context.Context, refundID string) error {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadRefundRecord(refundID)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return m.processRefund(info, userID)
+}
+
+// RejectInstanceRefund rejects a refund request.
+func (m *Mall) RejectInstanceRefund(ctx context.Context, refundID, msg string) error {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadRefundRecord(refundID)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if info.State != types.RefundRequested {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+	}
+
+	info.State = types.RefundRejected
+	info.Executor = userID
+	info.Msg = msg
+
+	err = m.UpdateRefundRecord(info, types.RefundRequested)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return nil
+}
+
+// RefundInstance is a method that handles refund request for a specific instance
+func (m *Mall) RefundInstance(ctx context.Context, instanceID string) (int64, error) {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadUserInstanceInfoByInstanceID(instanceID)
+	if err != nil {
+		return 0, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	refundID, err := m.createRefundRecord(info, "refund by admin")
+	if err != nil {
+		return 0, err
+	}
+
+	rInfo, err := m.LoadRefundRecord(refundID)
+	if err != nil {
+		return 0, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	err = m.processRefund(rInfo, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return rInfo.ProviderOrderID, nil
+}
+
+// InquiryPriceRefundInstance is a method that inquires the price of refunding a specific instance
+func (m *Mall) InquiryPriceRefundInstance(ctx context.Context, instanceID string) (float32, error) {
+	accessKeyID, accessKeySecret := m.getAliAccessKeys()
+
+	amount, err := aliyun.InquiryPriceRefundInstance(accessKeyID, accessKeySecret, instanceID)
+	if err != nil {
+		return 0, err
+	}
+
+	usdRate := utils.GetUSDRate()
+
+	return float32(amount) / usdRate, err
+}
+
+func (m *Mall) createRefundRecord(info *types.InstanceDetails, reason string) (string, error) {
+	if info.InstanceId == "" {
+		return "", &api.ErrWeb{Code: terrors.ThisInstanceNotSupportOperation.Int(), Message: terrors.ThisInstanceNotSupportOperation.String()}
+	}
+
+	hash := uuid.NewString()
+	refundID := strings.Replace(hash, "-", "", -1)
+
+	saved, err := m.SaveRefundRecord(&types.RefundRecord{
+		RefundID:   refundID,
+		UserID:     info.UserID,
+		InstanceID: info.InstanceId,
+		VpsID:      info.ID,
+		Reason:     reason,
+		State:      types.RefundRequested,
+		Value:      "0",
+	})
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if !saved {
+		return "", &api.ErrWeb{Code: terrors.RefundExists.Int(), Message: terrors.RefundExists.String()}
+	}
+
+	return refundID, nil
+}
+
+// processRefund moves a refund record forward until it is credited,
+// every step is saved so that an interrupted refund can be resumed.
+func (m *Mall) processRefund(info *types.RefundRecord, executor string) error {
+	if info.State == types.RefundRequested {
+		info.State = types.RefundApproved
+		info.Executor = executor
+
+		err := m.UpdateRefundRecord(info, types.RefundRequested)
+		if err != nil {
+			return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+	}
+
+	if info.State == types.RefundProviderRefunding {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: "the instance refund has been sent to the provider and its result is not saved"}
+	}
+
+	if info.State == types.RefundApproved {
+		err := m.refundAtProvider(info)
+		if err != nil {
+			return err
+		}
+	}
+
+	if info.State == types.RefundProviderRefunded {
+		err := m.CreditRefundRecord(info)
+		if err != nil {
+			return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		info.State = types.RefundCredited
+
+		err = m.InvoiceMgr.CreateRefundInvoice(info)
+		if err != nil {
+			log.Errorf("CreateRefundInvoice err:%s", err.Error())
+		}
+		return nil
+	}
+
+	return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: info.State.String()}
+}
+
+// refundAtProvider inquires the refund amount, refunds the instance at the provider
+// and converts the amount to the user currency at the rate of the order that paid the instance.
+// A refund whose provider result was not saved stays in RefundProviderRefunding.
+func (m *Mall) refundAtProvider(info *types.RefundRecord) error {
+	accessKeyID, accessKeySecret := m.getAliAccessKeys()
+
+	amount, err := aliyun.InquiryPriceRefundInstance(accessKeyID, accessKeySecret, info.InstanceID)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.AliApiGetFailed.Int(), Message: err.Error()}
+	}
+
+	rate := m.loadInstanceOrderRate(info.VpsID)
+
+	paid, err := m.loadInstanceTradePrice(info.VpsID)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	// the record is claimed before the provider is called, a concurrent or resumed approval never refunds twice
+	info.State = types.RefundProviderRefunding
+	info.Msg = ""
+	err = m.UpdateRefundRecord(info, types.RefundApproved)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: err.Error()}
+	}
+
+	oID, err := aliyun.RefundInstance(accessKeyID, accessKeySecret, info.InstanceID)
+	if err != nil {
+		info.State = types.RefundApproved
+		info.Msg = err.Error()
+		if uErr := m.UpdateRefundRecord(info, types.RefundProviderRefunding); uErr != nil {
+			log.Errorf("UpdateRefundRecord err:%s", uErr.Error())
+		}
+		return &api.ErrWeb{Code: terrors.AliApiGetFailed.Int(), Message: err.Error()}
+	}
+
+	info.State = types.RefundProviderRefunded
+	info.ProviderAmount = amount
+	info.ProviderOrderID = oID
+	info.USDRate = rate
+	info.Value = refundValue(amount, rate, paid)
+
+	err = m.UpdateRefundRecord(info, types.RefundProviderRefunding)
+	if err != nil {
+		log.Errorf("refund %s of instance %s provider order %d is not saved: %s", info.RefundID, info.InstanceID, oID, err.Error())
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	err = m.UpdateInstanceState(info.InstanceID, "")
+	if err != nil {
+		log.Errorf("UpdateInstanceState err:%s", err.Error())
+	}
+
+	err = m.SaveInstanceRefundInfo(info.InstanceID, info.Executor)
+	if err != nil {
+		log.Errorf("SaveInstanceRefundInfo err:%s", err.Error())
+	}
+
+	return nil
+}
+
+// loadInstanceOrderRate returns the usd rate of the latest order paying the instance,
+// the current rate is used for orders that did not record it.
+func (m *Mall) loadInstanceOrderRate(vpsID int64) float32 {
+	items, err := m.LoadOrderItemsByVpsID(vpsID, types.OrderItemSucceeded)
+	if err != nil {
+		log.Errorf("LoadOrderItemsByVpsID err:%s", err.Error())
+	}
+
+	var latest *types.OrderItem
+	for _, item := range items {
+		if item.USDRate <= 0 {
+			continue
+		}
+
+		if latest == nil || item.DoneTime.After(latest.DoneTime) {
+			latest = item
+		}
+	}
+
+	if latest == nil {
+		return utils.GetUSDRate()
+	}
+
+	return latest.USDRate
+}
+
+// refundValue converts the provider refund amount to the user currency,
+// the value never exceeds what the user paid for the instance.
+func refundValue(amount float64, rate float32, paid string) string {
+	if amount <= 0 || rate <= 0 {
+		return "0"
+	}
+
+	value := strconv.FormatFloat(math.Floor(amount/float64(rate)*decimal), 'f', 0, 64)
+
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return "0"
+	}
+
+	p, ok := new(big.Int).SetString(paid, 10)
+	if ok && v.Cmp(p) > 0 {
+		return p.String()
+	}
+
+	return v.String()
+}
@@ -0,0 +1,23 @@
+package mall
+
+import (
+	"context"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/handler"
+)
+
+// GetAccountStatement retrieves a page of the user's account statement, from and to are dates formatted as 2006-01-02,
+// the statement of the current month is returned if they are empty.
+func (m *Mall) GetAccountStatement(ctx context.Context, from, to string, cursor int64) (*types.AccountStatement, error) {
+	userID := handler.GetID(ctx)
+
+	info, err := m.StatementMgr.Statement(userID, from, to, cursor)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
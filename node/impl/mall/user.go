@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/LMF709268224/titan-vps/api"
-	"github.com/LMF709268224/titan-vps/lib/trxbridge"
 	"github.com/filecoin-project/go-jsonrpc/auth"
 	"github.com/gbrlsnchs/jwt/v3"
 
@@ -17,7 +16,7 @@ import (
 	"github.com/LMF709268224/titan-vps/node/utils"
 )
 
-// GetBalance retrieves user balance.
+// GetBalance retrieves the available, held and total balance of the user.
 func (m *Mall) GetBalance(ctx context.Context) (*types.UserInfo, error) {
 	userID := handler.GetID(ctx)
 
@@ -30,74 +29,109 @@ func (m *Mall) GetBalance(ctx context.Context) (*types.UserInfo, error) {
 
 	uInfo.Balance = balance
 
-	list, err := m.LoadWithdrawRecordsByUserAndState(userID, types.WithdrawCreate)
+	held, err := m.LoadHeldBalance(userID)
 	if err != nil {
 		return uInfo, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
-	lockBalance := "0"
-	for _, info := range list {
-		b, err := utils.AddBigInt(info.Value, lockBalance)
-		if err == nil {
-			lockBalance = b
-		}
+	uInfo.LockedBalance = held
+
+	total, err := utils.AddBigInt(balance, held)
+	if err != nil {
+		return uInfo, err
 	}
 
-	uInfo.LockedBalance = lockBalance
+	uInfo.TotalBalance = total
 
 	return uInfo, nil
 }
 
-// GetRechargeAddress retrieves user recharge address.
-func (m *Mall) GetRechargeAddress(ctx context.Context) (string, error) {
+// GetRechargeAddress retrieves the user deposit address of a chain, tron is used if the chain is empty.
+// An address is allocated if the user has none.
+func (m *Mall) GetRechargeAddress(ctx context.Context, chain string) (string, error) {
 	userID := handler.GetID(ctx)
 
-	address, err := m.LoadRechargeAddressByUser(userID)
+	switch chain {
+	case "", types.ChainTron:
+		address, err := m.LoadRechargeAddressByUser(userID)
+		if err != nil {
+			return address, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		if address == "" {
+			return m.TransactionAPI.AllocateTronAddress(ctx, userID)
+		}
+
+		return address, nil
+	case types.ChainFvm:
+		address, err := m.LoadPaymentAddressByUser(userID)
+		if err != nil {
+			return address, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+
+		if address == "" {
+			return m.TransactionAPI.AllocateFvmAddress(ctx, userID)
+		}
+
+		return address, nil
+	}
+
+	info, err := m.TransactionAPI.GetChainInfo(ctx)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+	}
+
+	exist := false
+	for _, name := range info.EvmChains {
+		exist = exist || name == chain
+	}
+
+	if !exist {
+		return "", &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "unknown chain " + chain}
+	}
+
+	address, err := m.LoadEvmAddressByUser(chain, userID)
 	if err != nil {
 		return address, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 
 	if address == "" {
-		_, err = m.TransactionMgr.AllocateTronAddress(userID)
-		if err != nil {
-			return "", err
-		}
+		return m.TransactionAPI.AllocateEvmAddress(ctx, chain, userID)
 	}
 
 	return address, nil
 }
 
-// Withdraw allows users to initiate a withdrawal.
-func (m *Mall) Withdraw(ctx context.Context, withdrawAddr, value string) error {
-	userID := handler.GetID(ctx)
+// Withdraw allows users to initiate a withdrawal and returns the id of the withdrawal order.
+func (m *Mall) Withdraw(ctx context.Context, withdrawAddr, value string) (string, error) {
+	params := []string{withdrawAddr, value}
+	return m.withIdempotency(ctx, "Withdraw", params, func() (string, error) {
+		return m.withdraw(ctx, handler.GetID(ctx), withdrawAddr, value)
+	})
+}
 
+// PreviewWithdraw returns the fee charged on a withdrawal and the value paid out before it is submitted.
+func (m *Mall) PreviewWithdraw(ctx context.Context, value string) (*types.WithdrawPreview, error) {
+	return m.WithdrawManager.PreviewWithdraw(value)
+}
+
+// withdraw creates a withdrawal of a user, the caller is saved as the creator of the withdrawal.
+func (m *Mall) withdraw(ctx context.Context, userID, withdrawAddr, value string) (string, error) {
 	if withdrawAddr == "" || value == "" {
-		return &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: terrors.ParametersWrong.String()}
+		return "", &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: terrors.ParametersWrong.String()}
 	}
 
 	_, err := utils.ReduceBigInt(value, "0")
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	cfg, err := m.GetMallConfigFunc()
+	err = m.TransactionAPI.CheckTronAddress(ctx, withdrawAddr)
 	if err != nil {
-		log.Errorf("get config err:%s", err.Error())
-		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
+		return "", &api.ErrWeb{Code: terrors.WithdrawAddrError.Int(), Message: err.Error()}
 	}
 
-	node := trxbridge.NewGrpcClient(cfg.TrxHTTPSAddr)
-	err = node.Start()
-	if err != nil {
-		return &api.ErrWeb{Code: terrors.ConfigError.Int(), Message: err.Error()}
-	}
-
-	_, err = node.GetAccount(withdrawAddr)
-	if err != nil {
-		return &api.ErrWeb{Code: terrors.WithdrawAddrError.Int(), Message: err.Error()}
-	}
-
-	return m.WithdrawManager.CreateWithdrawOrder(userID, withdrawAddr, value)
+	return m.WithdrawManager.CreateWithdrawOrder(userID, handler.GetID(ctx), withdrawAddr, value)
 }
 
 // GetUserRechargeRecords retrieves user recharge records with pagination.
@@ -124,6 +158,26 @@ func (m *Mall) GetUserWithdrawalRecords(ctx context.Context, limit, page int64)
 	return info, nil
 }
 
+// ClaimDeposit claims a deposit transaction that was not credited, it is credited if it pays
+// a deposit address of the user and checked by an admin otherwise.
+func (m *Mall) ClaimDeposit(ctx context.Context, chain, hash string) (*types.DepositClaim, error) {
+	userID := handler.GetID(ctx)
+
+	return m.ClaimMgr.ClaimDeposit(ctx, userID, chain, hash)
+}
+
+// GetUserDepositClaims retrieves user deposit claims with pagination.
+func (m *Mall) GetUserDepositClaims(ctx context.Context, limit, page int64) (*types.DepositClaimResponse, error) {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadDepositClaimsByUser(userID, limit, page)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return info, nil
+}
+
 // GetUserInstanceRecords retrieves user instance records with pagination.
 func (m *Mall) GetUserInstanceRecords(ctx context.Context, limit, page int64) (*types.GetInstanceResponse, error) {
 	userID := handler.GetID(ctx)
@@ -147,19 +201,8 @@ func (m *Mall) GetUserInstanceRecords(ctx context.Context, limit, page int64) (*
 			continue
 		}
 
-		orders, err := m.LoadOrderRecordsByVpsID(info.ID, types.Done, types.OrderDoneStateSuccess)
+		tradePrice, err := m.loadInstanceTradePrice(info.ID)
 		if err == nil {
-			tradePrice := "0"
-			for _, order := range orders {
-				price, err := utils.AddBigInt(tradePrice, order.Value)
-				if err != nil {
-					log.Errorf("AddBigInt %s,%s ,%s", tradePrice, order.Value, err.Error())
-					continue
-				}
-
-				tradePrice = price
-			}
-
 			info.Value = tradePrice
 		}
 
@@ -196,6 +239,19 @@ func (m *Mall) UpdateInstanceName(ctx context.Context, instanceID, instanceName
 	return nil
 }
 
+// ReinstallInstance reinstalls the system disk of a user's instance and reapplies its user data,
+// the progress is saved in the reinstall state of the instance details.
+func (m *Mall) ReinstallInstance(ctx context.Context, req *types.ReinstallInstanceReq) error {
+	userID := handler.GetID(ctx)
+
+	info, err := m.LoadInstanceInfoByUser(userID, req.InstanceID)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	return m.VpsMgr.ReinstallInstance(info, req)
+}
+
 // GetInstanceDefaultInfo retrieves default instance information with pagination.
 func (m *Mall) GetInstanceDefaultInfo(ctx context.Context, req *types.InstanceTypeFromBaseReq) (*types.InstanceTypeResponse, error) {
 	req.Offset = req.Limit * (req.Page - 1)
@@ -262,16 +318,21 @@ func (m *Mall) Login(ctx context.Context, user *types.UserReq) (*types.LoginResp
 	rsp := &types.LoginResponse{}
 	rsp.UserId = address
 	rsp.Token = string(tk)
-	err = m.initUser(address)
+	err = m.initUser(ctx, address)
 	if err != nil {
 		return nil, err
 	}
 
+	err = m.SaveSecurityEvent(address, types.SecurityEventLogin)
+	if err != nil {
+		return nil, &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
 	return rsp, nil
 }
 
 // initUser initializes a user's data if it doesn't exist.
-func (m *Mall) initUser(userID string) error {
+func (m *Mall) initUser(ctx context.Context, userID string) error {
 	exist, err := m.UserExists(userID)
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
@@ -288,7 +349,7 @@ func (m *Mall) initUser(userID string) error {
 		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
 	}
 	if addr == "" {
-		_, err = m.TransactionMgr.AllocateTronAddress(userID)
+		_, err = m.TransactionAPI.AllocateTronAddress(ctx, userID)
 		if err != nil {
 			return err
 		}
@@ -4,7 +4,9 @@ import (
 	"context"
 
 	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/types"
 	"github.com/LMF709268224/titan-vps/node/common"
+	chain "github.com/LMF709268224/titan-vps/node/transaction"
 	logging "github.com/ipfs/go-log/v2"
 	"go.uber.org/fx"
 )
@@ -16,12 +18,77 @@ type Transaction struct {
 	fx.In
 
 	*common.CommonAPI
+	ChainMgr *chain.Manager
 }
 
 func (m *Transaction) Hello(ctx context.Context) error {
-	// TODO implement me
-	log.Infoln("implement me")
+	log.Infoln("hello")
 	return nil
 }
 
+// GetChainInfo describes the served chains
+func (m *Transaction) GetChainInfo(ctx context.Context) (*types.ChainInfo, error) {
+	return m.ChainMgr.ChainInfo(), nil
+}
+
+// GetChainEvents returns the deposit and payout events of the outbox from an id
+func (m *Transaction) GetChainEvents(ctx context.Context, from, limit int64) (*types.OutboxEventsResponse, error) {
+	return m.ChainMgr.ChainEvents(from, limit)
+}
+
+// AllocateTronAddress allocates a tron deposit address for a user
+func (m *Transaction) AllocateTronAddress(ctx context.Context, userID string) (string, error) {
+	return m.ChainMgr.AllocateTronAddress(userID)
+}
+
+// AllocateFvmAddress allocates a FEVM deposit address for a user
+func (m *Transaction) AllocateFvmAddress(ctx context.Context, userID string) (string, error) {
+	return m.ChainMgr.AllocateFvmAddress(userID)
+}
+
+// AllocateEvmAddress allocates a deposit address of an EVM chain for a user
+func (m *Transaction) AllocateEvmAddress(ctx context.Context, chain, userID string) (string, error) {
+	return m.ChainMgr.AllocateEvmAddress(chain, userID)
+}
+
+// CheckTronAddress checks if a tron address can be paid to
+func (m *Transaction) CheckTronAddress(ctx context.Context, addr string) error {
+	return m.ChainMgr.CheckTronAddress(addr)
+}
+
+// SupplementTronOrder publishes the deposits of a tron transaction missed by the watcher
+func (m *Transaction) SupplementTronOrder(ctx context.Context, hash string) error {
+	return m.ChainMgr.SupplementOrder(hash)
+}
+
+// GetTronDeposits returns the transfers of a tron transaction to the deposit addresses without publishing them
+func (m *Transaction) GetTronDeposits(ctx context.Context, hash string) (*types.TronDeposits, error) {
+	return m.ChainMgr.TronDeposits(hash)
+}
+
+// TronPayoutFailed checks if a tron payout transaction failed or was dropped, so it paid nothing
+func (m *Transaction) TronPayoutFailed(ctx context.Context, hash string, expiration int64) (bool, error) {
+	return m.ChainMgr.TronPayoutFailed(hash, expiration)
+}
+
+// SubmitPayout queues the payout of an approved withdrawal, a payout submitted before is kept
+func (m *Transaction) SubmitPayout(ctx context.Context, orderID, withdrawAddr, value string) error {
+	return m.ChainMgr.SubmitPayout(orderID, withdrawAddr, value)
+}
+
+// RetryPayout pays a failed payout again
+func (m *Transaction) RetryPayout(ctx context.Context, orderID string) error {
+	return m.ChainMgr.RetryPayout(orderID)
+}
+
+// SweepDepositAddresses starts sweeping the deposit addresses
+func (m *Transaction) SweepDepositAddresses(ctx context.Context) error {
+	return m.ChainMgr.SweepDepositAddresses()
+}
+
+// SimulateDeposit publishes a confirmed deposit of a user on a stand-in chain
+func (m *Transaction) SimulateDeposit(ctx context.Context, userID, value string) (string, error) {
+	return m.ChainMgr.SimulateDeposit(userID, value)
+}
+
 var _ api.Transaction = &Transaction{}
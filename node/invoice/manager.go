@@ -0,0 +1,223 @@
+package invoice
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/LMF709268224/titan-vps/node/utils"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("invoice")
+
+const (
+	// currency is the currency of the balance values
+	currency = "USD"
+	// decimal is the number of balance units in one currency unit
+	decimal = 1000000
+)
+
+// Manager creates invoices for successful orders and credited refunds.
+type Manager struct {
+	*db.SQLDB
+}
+
+// NewManager creates a new invoice manager instance.
+func NewManager(sdb *db.SQLDB) *Manager {
+	return &Manager{SQLDB: sdb}
+}
+
+// CreateOrderInvoice creates the invoice of a successful order, it does nothing if the invoice exists.
+func (m *Manager) CreateOrderInvoice(order *types.OrderRecord) error {
+	exist, err := m.InvoiceExists(types.InvoiceOrder, order.OrderID)
+	if err != nil || exist {
+		return err
+	}
+
+	items, err := m.LoadOrderItems(order.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// orders created before items were introduced are invoiced as a single item
+	if len(items) == 0 {
+		items = append(items, &types.OrderItem{OrderID: order.OrderID, VpsID: order.VpsID, Value: order.Value, State: types.OrderItemSucceeded})
+	}
+
+	total := "0"
+	rate := float32(0)
+	lines := make([]*types.InvoiceLine, 0)
+	for _, item := range items {
+		if item.State != types.OrderItemSucceeded {
+			continue
+		}
+
+		if rate == 0 {
+			rate = item.USDRate
+		}
+
+		total, err = utils.AddBigInt(total, item.Value)
+		if err != nil {
+			return err
+		}
+
+		vInfo, err := m.LoadInstanceInfoByID(item.VpsID)
+		if err != nil {
+			return err
+		}
+
+		lines = append(lines, instanceLines(order.OrderType, vInfo, item.Value)...)
+	}
+
+	if len(lines) == 0 {
+		return xerrors.Errorf("order %s has no fulfilled item", order.OrderID)
+	}
+
+	return m.saveInvoice(types.InvoiceOrder, order.OrderID, order.UserID, rate, total, lines)
+}
+
+// CreateRefundInvoice creates the credit note of a credited refund, it does nothing if the credit note exists.
+func (m *Manager) CreateRefundInvoice(refund *types.RefundRecord) error {
+	exist, err := m.InvoiceExists(types.InvoiceRefund, refund.RefundID)
+	if err != nil || exist {
+		return err
+	}
+
+	lines := []*types.InvoiceLine{{
+		Description: fmt.Sprintf("Refund of instance %s", refund.InstanceID),
+		Quantity:    1,
+		Amount:      refund.Value,
+	}}
+
+	return m.saveInvoice(types.InvoiceRefund, refund.RefundID, refund.UserID, refund.USDRate, refund.Value, lines)
+}
+
+// DecodeInvoice decodes the billing details and the lines saved with an invoice.
+func DecodeInvoice(info *types.Invoice) error {
+	info.Billing = &types.BillingInfo{}
+	if info.BillingString != "" {
+		if err := json.Unmarshal([]byte(info.BillingString), info.Billing); err != nil {
+			return err
+		}
+	}
+
+	info.Lines = make([]*types.InvoiceLine, 0)
+	if info.LinesString != "" {
+		if err := json.Unmarshal([]byte(info.LinesString), &info.Lines); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) saveInvoice(invoiceType types.InvoiceType, refID, userID string, rate float32, total string, lines []*types.InvoiceLine) error {
+	// the billing details are copied into the invoice so later changes do not alter it
+	billing, err := m.LoadBillingInfo(userID)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			return err
+		}
+		billing = &types.BillingInfo{UserID: userID}
+	}
+
+	billingBuf, err := json.Marshal(billing)
+	if err != nil {
+		return err
+	}
+
+	linesBuf, err := json.Marshal(lines)
+	if err != nil {
+		return err
+	}
+
+	info := &types.Invoice{
+		InvoiceType:   invoiceType,
+		RefID:         refID,
+		UserID:        userID,
+		Currency:      currency,
+		USDRate:       rate,
+		Total:         total,
+		BillingString: string(billingBuf),
+		LinesString:   string(linesBuf),
+	}
+
+	err = m.SaveInvoice(info)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("invoice %d created for %s %s", info.InvoiceNo, invoiceType.String(), refID)
+	return nil
+}
+
+// instanceLines returns the invoice lines of an instance, the whole item value is charged on the instance line
+// and the disks and bandwidth are listed as included.
+func instanceLines(orderType types.OrderType, info *types.InstanceDetails, value string) []*types.InvoiceLine {
+	action := "Instance"
+	if orderType == types.RenewVPS {
+		action = "Instance renewal"
+	}
+
+	lines := []*types.InvoiceLine{{
+		Description: fmt.Sprintf("%s %s (%s), %d %s", action, info.InstanceType, info.RegionId, info.Period, info.PeriodUnit),
+		Quantity:    1,
+		Amount:      value,
+	}}
+
+	if info.SystemDiskSize > 0 {
+		lines = append(lines, &types.InvoiceLine{
+			Description: fmt.Sprintf("System disk %s %dGB", info.SystemDiskCategory, info.SystemDiskSize),
+			Quantity:    1,
+		})
+	}
+
+	if info.DataDiskString != "" {
+		var disks []types.DescribePriceRequestDataDisk
+		if err := json.Unmarshal([]byte(info.DataDiskString), &disks); err != nil {
+			log.Errorf("Unmarshal DataDisk err:%s", err.Error())
+		}
+
+		for _, disk := range disks {
+			lines = append(lines, &types.InvoiceLine{
+				Description: fmt.Sprintf("Data disk %s %dGB", disk.Category, disk.Size),
+				Quantity:    1,
+			})
+		}
+	}
+
+	if info.BandwidthOut > 0 {
+		lines = append(lines, &types.InvoiceLine{
+			Description: fmt.Sprintf("Bandwidth %dMbps %s", info.BandwidthOut, info.InternetChargeType),
+			Quantity:    1,
+		})
+	}
+
+	return lines
+}
+
+// FormatAmount formats a balance value in the invoice currency, an empty value is an included line.
+func FormatAmount(value string) string {
+	if value == "" {
+		return "included"
+	}
+
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return value
+	}
+
+	sign := ""
+	if n.Sign() < 0 {
+		sign = "-"
+		n.Neg(n)
+	}
+
+	q, r := new(big.Int).QuoRem(n, big.NewInt(decimal), new(big.Int))
+	return fmt.Sprintf("%s%s.%06d %s", sign, q.String(), r.Int64(), currency)
+}
@@ -0,0 +1,150 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+const (
+	// FormatHTML renders the invoice as an html page
+	FormatHTML = "html"
+	// FormatPDF renders the invoice as a pdf document
+	FormatPDF = "pdf"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"amount": FormatAmount,
+	"number": Number,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.InvoiceType.String}} {{number .}}</title></head>
+<body>
+<h1>{{.InvoiceType.String}} {{number .}}</h1>
+<p>Date: {{.CreatedTime.Format "2006-01-02"}}<br>Reference: {{.RefID}}</p>
+<p>Bill to:<br>{{with .Billing}}{{.CompanyName}}<br>{{.Address}}<br>{{.Country}}<br>Tax ID: {{.TaxID}}<br>{{.Email}}<br>{{end}}Account: {{.UserID}}</p>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Description</th><th>Quantity</th><th>Amount</th></tr>
+{{range .Lines}}<tr><td>{{.Description}}</td><td>{{.Quantity}}</td><td>{{amount .Amount}}</td></tr>
+{{end}}<tr><td colspan="2"><b>Total</b></td><td><b>{{amount .Total}}</b></td></tr>
+</table>
+<p>Currency: {{.Currency}}, exchange rate: 1 {{.Currency}} = {{.USDRate}} CNY</p>
+</body>
+</html>
+`))
+
+// Number returns the printed number of an invoice
+func Number(info *types.Invoice) string {
+	return fmt.Sprintf("INV-%08d", info.InvoiceNo)
+}
+
+// Render renders a decoded invoice in the given format
+func Render(info *types.Invoice, format string) ([]byte, error) {
+	switch format {
+	case FormatHTML:
+		return RenderHTML(info)
+	case FormatPDF:
+		return RenderPDF(info), nil
+	}
+
+	return nil, fmt.Errorf("unsupported invoice format %s", format)
+}
+
+// RenderHTML renders a decoded invoice as an html page
+func RenderHTML(info *types.Invoice) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := htmlTemplate.Execute(buf, info); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderPDF renders a decoded invoice as a pdf document
+func RenderPDF(info *types.Invoice) []byte {
+	lines := []string{
+		fmt.Sprintf("%s %s", info.InvoiceType.String(), Number(info)),
+		"",
+		fmt.Sprintf("Date: %s", info.CreatedTime.Format("2006-01-02")),
+		fmt.Sprintf("Reference: %s", info.RefID),
+		"",
+		"Bill to:",
+	}
+
+	if b := info.Billing; b != nil {
+		for _, s := range []string{b.CompanyName, b.Address, b.Country, "Tax ID: " + b.TaxID, b.Email} {
+			lines = append(lines, "  "+s)
+		}
+	}
+	lines = append(lines, "  Account: "+info.UserID, "")
+
+	for _, line := range info.Lines {
+		lines = append(lines, fmt.Sprintf("%-60s x%-4d %s", line.Description, line.Quantity, FormatAmount(line.Amount)))
+	}
+
+	lines = append(lines,
+		"",
+		fmt.Sprintf("%-66s %s", "Total", FormatAmount(info.Total)),
+		"",
+		fmt.Sprintf("Currency: %s, exchange rate: 1 %s = %v CNY", info.Currency, info.Currency, info.USDRate),
+	)
+
+	return textPDF(lines)
+}
+
+// textPDF writes the lines as a single page pdf document using a monospaced standard font
+func textPDF(lines []string) []byte {
+	content := new(bytes.Buffer)
+	content.WriteString("BT\n/F1 9 Tf\n11 TL\n40 800 Td\n")
+	for _, line := range lines {
+		fmt.Fprintf(content, "(%s) Tj T*\n", escapePDF(line))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	out := new(bytes.Buffer)
+	out.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// escapePDF escapes a pdf string literal, characters outside of ascii are replaced
+func escapePDF(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteRune('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
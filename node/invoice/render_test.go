@@ -0,0 +1,61 @@
+package invoice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+func TestFormatAmount(t *testing.T) {
+	cases := map[string]string{
+		"":         "included",
+		"0":        "0.000000 USD",
+		"1500000":  "1.500000 USD",
+		"12":       "0.000012 USD",
+		"-2000001": "-2.000001 USD",
+	}
+
+	for in, want := range cases {
+		if got := FormatAmount(in); got != want {
+			t.Errorf("FormatAmount(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	info := &types.Invoice{
+		InvoiceNo:   7,
+		InvoiceType: types.InvoiceOrder,
+		RefID:       "order",
+		UserID:      "user",
+		Currency:    currency,
+		USDRate:     7.2,
+		Total:       "1000000",
+		Billing:     &types.BillingInfo{CompanyName: "ACME <Ltd> (HK)"},
+		Lines:       []*types.InvoiceLine{{Description: "Instance ecs.t5", Quantity: 1, Amount: "1000000"}},
+	}
+
+	page, err := Render(info, FormatHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(page), "INV-00000007") || !strings.Contains(string(page), "ACME &lt;Ltd&gt;") {
+		t.Errorf("unexpected html invoice: %s", page)
+	}
+
+	doc, err := Render(info, FormatPDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(doc, []byte("%PDF-")) || !bytes.Contains(doc, []byte(`ACME <Ltd> \(HK\)`)) {
+		t.Errorf("unexpected pdf invoice: %s", doc)
+	}
+
+	if _, err = Render(info, "doc"); err == nil {
+		t.Errorf("Render should fail with an unsupported format")
+	}
+}
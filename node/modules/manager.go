@@ -2,19 +2,25 @@ package modules
 
 import (
 	"context"
+	"net/http"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/client"
 	"github.com/LMF709268224/titan-vps/node/account"
+	impltransaction "github.com/LMF709268224/titan-vps/node/impl/transaction"
 
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/LMF709268224/titan-vps/node/invoice"
 	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
 	"github.com/LMF709268224/titan-vps/node/modules/helpers"
 	"github.com/LMF709268224/titan-vps/node/orders"
 	"github.com/LMF709268224/titan-vps/node/repo"
 	"github.com/LMF709268224/titan-vps/node/transaction"
 	"github.com/LMF709268224/titan-vps/node/vps"
-	"github.com/filecoin-project/pubsub"
 	logging "github.com/ipfs/go-log/v2"
 	"go.uber.org/fx"
+	"golang.org/x/xerrors"
 )
 
 var log = logging.Logger("modules")
@@ -79,16 +85,75 @@ func NewGetMallConfigFunc(r repo.LockedRepo) func() (config.MallCfg, error) {
 	}
 }
 
-// NewPubSub returns a new pubsub instance with a buffer of 50
-func NewPubSub() *pubsub.PubSub {
-	return pubsub.New(50)
-}
-
 // NewDB returns an *sqlx.DB instance
 func NewDB(cfg *config.MallCfg) (*db.SQLDB, error) {
 	return db.NewSQLDB(cfg.DatabaseAddress)
 }
 
+// NewTransactionDB returns the database of the transaction node, its tables are created and migrated by the mall
+func NewTransactionDB(cfg *config.TransactionCfg) (*db.SQLDB, error) {
+	return db.OpenSQLDB(cfg.DatabaseAddress)
+}
+
+// NewChainManager returns the chain manager of the transaction node
+func NewChainManager(cfg *config.TransactionCfg, sdb *db.SQLDB) (*transaction.Manager, error) {
+	return transaction.NewManager(cfg.ChainCfg, sdb)
+}
+
+// NewTransactionAPI connects the mall to its transaction node, the chain is served in process if it is configured for development.
+// The transaction node shares the database of the mall but keeps its own tables, the recharge and withdraw records
+// are written by the mall from the chain events. The mall fails to start if the transaction node can not be reached
+// or uses another database.
+func NewTransactionAPI(mctx helpers.MetricsCtx, lc fx.Lifecycle, cfg *config.MallCfg, sdb *db.SQLDB) (api.Transaction, error) {
+	if cfg.TransactionAPI.InProcess {
+		log.Warn("the chain is served in process, it is meant for development only")
+
+		mgr, err := transaction.NewManager(cfg.ChainCfg, sdb)
+		if err != nil {
+			return nil, err
+		}
+
+		return &impltransaction.Transaction{ChainMgr: mgr}, nil
+	}
+
+	if cfg.TransactionAPI.Address == "" {
+		return nil, xerrors.New("no transaction node is configured, set TransactionAPI.Address or TransactionAPI.InProcess for development")
+	}
+
+	headers := http.Header{}
+	headers.Add("Authorization", "Bearer "+cfg.TransactionAPI.Token)
+
+	ctx := helpers.LifecycleCtx(mctx, lc)
+	tAPI, closer, err := client.NewTransaction(ctx, cfg.TransactionAPI.Address, headers)
+	if err != nil {
+		return nil, xerrors.Errorf("NewTransaction err:%s", err.Error())
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			closer()
+			return nil
+		},
+	})
+
+	info, err := tAPI.GetChainInfo(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("GetChainInfo err:%s", err.Error())
+	}
+
+	id, err := sdb.DatabaseID()
+	if err != nil {
+		return nil, xerrors.Errorf("DatabaseID err:%s", err.Error())
+	}
+
+	if info.DatabaseID != id {
+		return nil, xerrors.Errorf("transaction node %s uses database %s and the mall uses database %s, they must share one database",
+			cfg.TransactionAPI.Address, info.DatabaseID, id)
+	}
+
+	return tAPI, nil
+}
+
 // NewCache returns an *cache instance
 func NewCache() (*account.Cache, error) {
 	return account.NewCache()
@@ -107,10 +172,9 @@ type StorageManagerParams struct {
 	MetricsCtx helpers.MetricsCtx
 	MetadataDS dtypes.MetadataDS
 	*db.SQLDB
-	*pubsub.PubSub
 	dtypes.GetMallConfigFunc
-	TMgr *transaction.Manager
 	VMgr *vps.Manager
+	IMgr *invoice.Manager
 }
 
 // Datastore returns a new metadata datastore
@@ -125,14 +189,13 @@ func NewStorageManager(params StorageManagerParams) (*orders.Manager, error) {
 		lc   = params.Lifecycle
 		ds   = params.MetadataDS
 		sdb  = params.SQLDB
-		pb   = params.PubSub
 		gc   = params.GetMallConfigFunc
-		fm   = params.TMgr
 		vm   = params.VMgr
+		im   = params.IMgr
 	)
 
 	ctx := helpers.LifecycleCtx(mctx, lc)
-	m, err := orders.NewManager(ds, sdb, pb, gc, fm, vm)
+	m, err := orders.NewManager(ds, sdb, gc, vm, im)
 	if err != nil {
 		return nil, err
 	}
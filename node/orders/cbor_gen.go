@@ -26,7 +26,7 @@ func (t *OrderInfo) MarshalCBOR(w io.Writer) error {
 
 	cw := cbg.NewCborWriter(w)
 
-	if _, err := cw.Write([]byte{170}); err != nil {
+	if _, err := cw.Write([]byte{171}); err != nil {
 		return err
 	}
 
@@ -143,6 +143,28 @@ func (t *OrderInfo) MarshalCBOR(w io.Writer) error {
 		}
 	}
 
+	// t.Amount (int64) (int64)
+	if len("Amount") > cbg.MaxLength {
+		return xerrors.Errorf("Value in field \"Amount\" was too long")
+	}
+
+	if err := cw.WriteMajorTypeHeader(cbg.MajTextString, uint64(len("Amount"))); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, string("Amount")); err != nil {
+		return err
+	}
+
+	if t.Amount >= 0 {
+		if err := cw.WriteMajorTypeHeader(cbg.MajUnsignedInt, uint64(t.Amount)); err != nil {
+			return err
+		}
+	} else {
+		if err := cw.WriteMajorTypeHeader(cbg.MajNegativeInt, uint64(-t.Amount-1)); err != nil {
+			return err
+		}
+	}
+
 	// t.OrderID (orders.OrderHash) (string)
 	if len("OrderID") > cbg.MaxLength {
 		return xerrors.Errorf("Value in field \"OrderID\" was too long")
@@ -374,6 +396,32 @@ func (t *OrderInfo) UnmarshalCBOR(r io.Reader) (err error) {
 
 				t.VpsID = int64(extraI)
 			}
+			// t.Amount (int64) (int64)
+		case "Amount":
+			{
+				maj, extra, err := cr.ReadHeader()
+				var extraI int64
+				if err != nil {
+					return err
+				}
+				switch maj {
+				case cbg.MajUnsignedInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 positive overflow")
+					}
+				case cbg.MajNegativeInt:
+					extraI = int64(extra)
+					if extraI < 0 {
+						return fmt.Errorf("int64 negative overflow")
+					}
+					extraI = -1 - extraI
+				default:
+					return fmt.Errorf("wrong type for int64 field: %d", maj)
+				}
+
+				t.Amount = int64(extraI)
+			}
 			// t.OrderID (orders.OrderHash) (string)
 		case "OrderID":
 
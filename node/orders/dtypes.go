@@ -28,6 +28,7 @@ type OrderInfo struct {
 	VpsID     int64
 	Msg       string
 	CycleTime string
+	Amount    int64
 
 	*GoodsInfo
 }
@@ -44,6 +45,7 @@ func (state *OrderInfo) ToOrderRecord() *types.OrderRecord {
 		Msg:       state.Msg,
 		CycleTime: state.CycleTime,
 		OrderType: types.OrderType(state.OrderType),
+		Amount:    state.Amount,
 	}
 }
 
@@ -59,6 +61,7 @@ func orderInfoFrom(info *types.OrderRecord) *OrderInfo {
 		User:      info.UserID,
 		CycleTime: info.CycleTime,
 		OrderType: int64(info.OrderType),
+		Amount:    info.Amount,
 	}
 	return cInfo
 }
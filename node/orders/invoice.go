@@ -0,0 +1,40 @@
+package orders
+
+import (
+	"encoding/json"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"golang.org/x/xerrors"
+)
+
+// invoiceConsumer is the outbox consumer of the order events creating the invoices of the orders
+const invoiceConsumer = "order_invoice"
+
+// handleOrderEvent creates the invoice of an order that is done successfully, the invoice is created once.
+func (m *Manager) handleOrderEvent(info *types.OutboxEvent) error {
+	event := &types.OrderEvent{}
+	err := json.Unmarshal([]byte(info.Payload), event)
+	if err != nil {
+		return xerrors.Errorf("decode order event %d err:%s", info.ID, err.Error())
+	}
+
+	if event.ToState != OrderStateDone.String() || event.FromState == event.ToState {
+		return nil
+	}
+
+	order, err := m.LoadOrderRecord(event.OrderID, 0)
+	if err != nil {
+		return xerrors.Errorf("%s LoadOrderRecord err:%s", event.OrderID, err.Error())
+	}
+
+	// the order is saved after the event of its transition, the event is handed again until it is saved
+	if order.State != types.Done {
+		return xerrors.Errorf("order %s is not saved as done", event.OrderID)
+	}
+
+	if order.DoneState != types.OrderDoneStateSuccess {
+		return nil
+	}
+
+	return m.invoiceMgr.CreateOrderInvoice(order)
+}
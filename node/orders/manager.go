@@ -11,11 +11,11 @@ import (
 	"github.com/LMF709268224/titan-vps/lib/filecoinbridge"
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/LMF709268224/titan-vps/node/invoice"
 	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
-	"github.com/LMF709268224/titan-vps/node/transaction"
+	"github.com/LMF709268224/titan-vps/node/outbox"
 	"github.com/LMF709268224/titan-vps/node/vps"
 	"github.com/filecoin-project/go-statemachine"
-	"github.com/filecoin-project/pubsub"
 	"github.com/ipfs/go-datastore"
 
 	logging "github.com/ipfs/go-log/v2"
@@ -27,6 +27,11 @@ const (
 	checkOrderInterval = 10 * time.Second
 	orderTimeoutMinute = 10
 	orderTimeoutTime   = orderTimeoutMinute * time.Minute
+	// systemActor is the actor of order events that are not triggered by a user
+	systemActor = "system"
+	// the results of the requests made with an idempotency key are kept for idempotencyRetention
+	idempotencyRetention     = 24 * time.Hour
+	cleanIdempotencyInterval = time.Hour
 )
 
 // Manager manages order processing.
@@ -35,28 +40,25 @@ type Manager struct {
 	orderStateMachines *statemachine.StateGroup
 	*db.SQLDB
 
-	notification *pubsub.PubSub
-
 	activeOrders sync.Map // map[string]*types.OrderRecord
 
-	cfg    config.MallCfg
-	txMgr  *transaction.Manager
-	vpsMgr *vps.Manager
+	cfg        config.MallCfg
+	vpsMgr     *vps.Manager
+	invoiceMgr *invoice.Manager
 }
 
 // NewManager creates a new order manager instance.
-func NewManager(ds datastore.Batching, sdb *db.SQLDB, pb *pubsub.PubSub, getCfg dtypes.GetMallConfigFunc, fm *transaction.Manager, vm *vps.Manager) (*Manager, error) {
+func NewManager(ds datastore.Batching, sdb *db.SQLDB, getCfg dtypes.GetMallConfigFunc, vm *vps.Manager, im *invoice.Manager) (*Manager, error) {
 	cfg, err := getCfg()
 	if err != nil {
 		return nil, err
 	}
 
 	m := &Manager{
-		SQLDB:        sdb,
-		notification: pb,
-		cfg:          cfg,
-		txMgr:        fm,
-		vpsMgr:       vm,
+		SQLDB:      sdb,
+		cfg:        cfg,
+		vpsMgr:     vm,
+		invoiceMgr: im,
 	}
 
 	// state machine initialization
@@ -73,7 +75,25 @@ func (m *Manager) Start(ctx context.Context) {
 	}
 
 	// go m.subscribeEvents()
+	outbox.NewDispatcher(m.SQLDB, invoiceConsumer, outbox.LocalSource(m.SQLDB, types.OutboxTopicOrder), m.handleOrderEvent).Start()
+
 	go m.checkOrdersTimeout()
+	go m.cleanIdempotencyRecords()
+}
+
+// cleanIdempotencyRecords deletes the expired idempotency records periodically.
+func (m *Manager) cleanIdempotencyRecords() {
+	ticker := time.NewTicker(cleanIdempotencyInterval)
+	defer ticker.Stop()
+
+	for {
+		err := m.DeleteExpiredIdempotencyRecords(time.Now().Add(-idempotencyRetention))
+		if err != nil {
+			log.Errorf("DeleteExpiredIdempotencyRecords err:%s", err.Error())
+		}
+
+		<-ticker.C
+	}
 }
 
 func (m *Manager) checkOrdersTimeout() {
@@ -125,7 +145,7 @@ func (m *Manager) CancelOrder(orderID, userID string) error {
 		return &api.ErrWeb{Code: terrors.UserMismatch.Int(), Message: terrors.UserMismatch.String()}
 	}
 
-	err = m.orderStateMachines.Send(OrderHash(orderID), OrderCancel{})
+	err = m.orderStateMachines.Send(OrderHash(orderID), OrderCancel{Actor: userID})
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.StateMachinesError.Int(), Message: err.Error()}
 	}
@@ -144,7 +164,7 @@ func (m *Manager) PaymentCompleted(orderID, userID string) error {
 		return &api.ErrWeb{Code: terrors.UserMismatch.Int(), Message: terrors.UserMismatch.String()}
 	}
 
-	err = m.orderStateMachines.Send(OrderHash(orderID), PaymentResult{})
+	err = m.orderStateMachines.Send(OrderHash(orderID), PaymentResult{Actor: userID})
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.StateMachinesError.Int(), Message: err.Error()}
 	}
@@ -152,14 +172,23 @@ func (m *Manager) PaymentCompleted(orderID, userID string) error {
 	return nil
 }
 
-// CreatedOrder creates a new VPS order.
-func (m *Manager) CreatedOrder(req *types.OrderRecord) error {
+// CreatedOrder creates a new VPS order with its items, a new instance is saved for every item unless instance is nil.
+func (m *Manager) CreatedOrder(req *types.OrderRecord, instance *types.InstanceDetails, items []*types.OrderItem) error {
 	m.stateMachineWait.Wait()
 
+	err := m.SaveOrderItems(instance, items)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if instance != nil {
+		req.VpsID = items[0].VpsID
+	}
+
 	m.addOrder(req)
 
 	// create order task
-	err := m.orderStateMachines.Send(OrderHash(req.OrderID), CreateOrder{orderInfoFrom(req)})
+	err = m.orderStateMachines.Send(OrderHash(req.OrderID), CreateOrder{orderInfoFrom(req)})
 	if err != nil {
 		return &api.ErrWeb{Code: terrors.StateMachinesError.Int(), Message: err.Error()}
 	}
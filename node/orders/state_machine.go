@@ -4,6 +4,7 @@ import (
 	"context"
 	"reflect"
 
+	"github.com/LMF709268224/titan-vps/api/types"
 	"github.com/filecoin-project/go-statemachine"
 	"golang.org/x/xerrors"
 )
@@ -37,6 +38,7 @@ var planners = map[OrderState]func(events []statemachine.Event, state *OrderInfo
 		on(OrderCancel{}, OrderStateDone),
 		on(PaymentSucceed{}, OrderStateBuyGoods),
 		apply(PaymentResult{}),
+		apply(PaymentFailed{}),
 	),
 	OrderStateBuyGoods: planOne(
 		on(BuyFailed{}, OrderStateDone),
@@ -61,7 +63,9 @@ func (m *Manager) plan(events []statemachine.Event, state *OrderInfo) (func(stat
 		}
 	}
 
+	from := state.State
 	processed, err := p(events, state)
+	m.saveOrderEvents(events[:processed], from, state, err)
 	if err != nil {
 		return nil, processed, xerrors.Errorf("running planner for state %s failed: %w", state.State, err)
 	}
@@ -73,6 +77,15 @@ func (m *Manager) plan(events []statemachine.Event, state *OrderInfo) (func(stat
 	case OrderStateCreated:
 		return m.handleOrderCreated, processed, nil
 	case OrderStateWaitingPayment:
+		if processed > 0 {
+			if evt, ok := events[processed-1].User.(PaymentFailed); ok {
+				if !evt.Retry {
+					// the user pays again once the balance is enough
+					return nil, processed, nil
+				}
+				return m.handlePaymentRetry, processed, nil
+			}
+		}
 		return m.handleWaitingForPayment, processed, nil
 	case OrderStateBuyGoods:
 		return m.handleBuyGoods, processed, nil
@@ -86,6 +99,35 @@ func (m *Manager) plan(events []statemachine.Event, state *OrderInfo) (func(stat
 	return nil, processed, nil
 }
 
+// saveOrderEvents records the processed events of an order so that its timeline can be inspected
+func (m *Manager) saveOrderEvents(events []statemachine.Event, from OrderState, state *OrderInfo, pErr error) {
+	for _, event := range events {
+		info := &types.OrderEvent{
+			OrderID:   state.OrderID.String(),
+			Event:     reflect.TypeOf(event.User).Name(),
+			FromState: from.String(),
+			ToState:   state.State.String(),
+			Actor:     systemActor,
+		}
+
+		if ae, ok := event.User.(actorEvent); ok && ae.actor() != "" {
+			info.Actor = ae.actor()
+		}
+
+		if me, ok := event.User.(messageEvent); ok {
+			info.Msg = me.message()
+		}
+
+		if pErr != nil {
+			info.Msg = pErr.Error()
+		}
+
+		if err := m.SaveOrderEvent(info); err != nil {
+			log.Errorf("SaveOrderEvent %s err:%s", info.OrderID, err.Error())
+		}
+	}
+}
+
 // prepares a single plan for a given order state, allowing for one event at a time
 func planOne(ts ...func() (mut mutator, next func(info *OrderInfo) (more bool, err error))) func(events []statemachine.Event, state *OrderInfo) (uint64, error) {
 	return func(events []statemachine.Event, state *OrderInfo) (uint64, error) {
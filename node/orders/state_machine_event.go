@@ -16,6 +16,16 @@ type Ignorable interface {
 	Ignore()
 }
 
+// actorEvent is an event triggered by a user or an admin, other events are triggered by the system
+type actorEvent interface {
+	actor() string
+}
+
+// messageEvent is an event carrying an error message
+type messageEvent interface {
+	message() string
+}
+
 // Global events
 
 // OrderRestart restarts incomplete orders
@@ -26,7 +36,13 @@ func (evt OrderRestart) applyGlobal(state *OrderInfo) bool {
 }
 
 // PaymentResult represents the result of a user payment.
-type PaymentResult struct{}
+type PaymentResult struct {
+	Actor string
+}
+
+func (evt PaymentResult) actor() string {
+	return evt.Actor
+}
 
 func (evt PaymentResult) apply(state *OrderInfo) {
 }
@@ -49,10 +65,15 @@ func (evt CreateOrder) applyGlobal(state *OrderInfo) bool {
 	state.DoneState = evt.DoneState
 	state.VpsID = evt.VpsID
 	state.CycleTime = evt.CycleTime
+	state.Amount = evt.Amount
 
 	return true
 }
 
+func (evt CreateOrder) actor() string {
+	return evt.User
+}
+
 // WaitingPaymentSent indicates that the order is waiting for the user to make a payment.
 type WaitingPaymentSent struct{}
 
@@ -66,7 +87,13 @@ func (evt OrderTimeOut) apply(state *OrderInfo) {
 }
 
 // OrderCancel represents an order cancellation event.
-type OrderCancel struct{}
+type OrderCancel struct {
+	Actor string
+}
+
+func (evt OrderCancel) actor() string {
+	return evt.Actor
+}
 
 func (evt OrderCancel) apply(state *OrderInfo) {
 	state.DoneState = OrderDoneStateCancel
@@ -80,6 +107,21 @@ func (evt PaymentSucceed) apply(state *OrderInfo) {
 	// state.TxHash = evt.TxHash
 }
 
+// PaymentFailed indicates that the order value could not be held on the user balance,
+// the order waits for another payment of the user or is retried after a cool down if Retry is set.
+type PaymentFailed struct {
+	Msg   string
+	Retry bool
+}
+
+func (evt PaymentFailed) apply(state *OrderInfo) {
+	state.Msg = evt.Msg
+}
+
+func (evt PaymentFailed) message() string {
+	return evt.Msg
+}
+
 // BuySucceed represents a successful purchase event.
 type BuySucceed struct {
 	*GoodsInfo
@@ -99,3 +141,7 @@ func (evt BuyFailed) apply(state *OrderInfo) {
 	state.DoneState = OrderDoneStatePurchaseFailed
 	state.Msg = evt.Msg
 }
+
+func (evt BuyFailed) message() string {
+	return evt.Msg
+}
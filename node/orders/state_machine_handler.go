@@ -1,12 +1,15 @@
 package orders
 
 import (
+	"database/sql"
 	"encoding/json"
 	"time"
 
 	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/LMF709268224/titan-vps/node/utils"
 	"github.com/filecoin-project/go-statemachine"
+	"golang.org/x/xerrors"
 )
 
 var (
@@ -43,43 +46,110 @@ func (m *Manager) handleOrderCreated(ctx statemachine.Context, info OrderInfo) e
 func (m *Manager) handleWaitingForPayment(ctx statemachine.Context, info OrderInfo) error {
 	log.Debugf("handle wait payment, %s ", info.OrderID)
 
-	original, err := m.LoadUserBalance(info.User)
+	// the order waits for another payment attempt if the value can not be held on the user balance,
+	// the hold is retried after a cool down if it failed for another reason
+	err := m.PlaceHold(types.LedgerRefOrder, info.OrderID.String(), info.User, info.Value)
 	if err != nil {
-		log.Errorf("handleWaitingForPayment LoadUserBalance err:%s", err.Error())
-		return nil
+		log.Errorf("handleWaitingForPayment %s PlaceHold err:%s", info.OrderID, err.Error())
+		return ctx.Send(PaymentFailed{Msg: err.Error(), Retry: !xerrors.Is(err, db.ErrInsufficientBalance)})
 	}
 
-	newValue, err := utils.ReduceBigInt(original, info.Value)
-	if err != nil {
-		log.Errorf("handleWaitingForPayment BigIntReduce err:%s", err.Error())
-		return nil
-	}
+	return ctx.Send(PaymentSucceed{})
+}
 
-	err = m.UpdateUserBalance(info.User, newValue, original)
+// handlePaymentRetry holds the order value again after a cool down
+func (m *Manager) handlePaymentRetry(ctx statemachine.Context, info OrderInfo) error {
+	err := failedCoolDown(ctx, info)
 	if err != nil {
-		log.Errorf("handleWaitingForPayment UpdateUserBalance err:%s", err.Error())
-		return nil
+		return err
 	}
 
-	return ctx.Send(PaymentSucceed{})
+	return m.handleWaitingForPayment(ctx, info)
 }
 
 // handleBuyGoods handles the order to buy goods
 func (m *Manager) handleBuyGoods(ctx statemachine.Context, info OrderInfo) error {
 	log.Debugf("handle buy goods: %s", info.OrderID)
 
-	// Buy Vps
-	vInfo, err := m.LoadInstanceInfoByID(info.VpsID)
+	items, err := m.loadOrderItems(info)
 	if err != nil {
 		return ctx.Send(BuyFailed{Msg: err.Error()})
 	}
 
+	succeeded := 0
+	failed := 0
+	msg := ""
+	for _, item := range items {
+		switch item.State {
+		case types.OrderItemSucceeded:
+			succeeded++
+			continue
+		case types.OrderItemFailed:
+			failed++
+			msg = item.Msg
+			continue
+		}
+
+		instanceID, err := m.buyOrderItem(info, item)
+		if err != nil {
+			log.Errorf("handleBuyGoods order %s item %d err:%s", info.OrderID, item.ItemIndex, err.Error())
+			item.State = types.OrderItemFailed
+			item.Msg = err.Error()
+			msg = item.Msg
+			failed++
+		} else {
+			item.State = types.OrderItemSucceeded
+			item.InstanceID = instanceID
+			succeeded++
+		}
+
+		err = m.UpdateOrderItem(item)
+		if err != nil {
+			log.Errorf("handleBuyGoods UpdateOrderItem err:%s", err.Error())
+		}
+	}
+
+	if succeeded == 0 {
+		return ctx.Send(BuyFailed{Msg: msg})
+	}
+
+	if failed > 0 {
+		// partial failure, the items that could not be fulfilled are released when the order is done
+		log.Infof("order %s %d/%d items failed", info.OrderID, failed, len(items))
+
+		m.deleteUnfulfilledInstances(info, items)
+	}
+
+	return ctx.Send(BuySucceed{GoodsInfo: &GoodsInfo{ID: "vps_id", Password: "abc"}})
+}
+
+// loadOrderItems loads the items of the order,
+// orders created before items were introduced are treated as a single item.
+func (m *Manager) loadOrderItems(info OrderInfo) ([]*types.OrderItem, error) {
+	items, err := m.LoadOrderItems(info.OrderID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		items = append(items, &types.OrderItem{OrderID: info.OrderID.String(), VpsID: info.VpsID, Value: info.Value})
+	}
+
+	return items, nil
+}
+
+// buyOrderItem buys or renews the instance of an order item and returns its instance id
+func (m *Manager) buyOrderItem(info OrderInfo, item *types.OrderItem) (string, error) {
+	vInfo, err := m.LoadInstanceInfoByID(item.VpsID)
+	if err != nil {
+		return "", err
+	}
+
 	vInfo.UserID = info.User
-	// vInfo.OrderID = info.OrderID.String()
 
 	if vInfo.DataDiskString != "" {
 		if err := json.Unmarshal([]byte(vInfo.DataDiskString), &vInfo.DataDisk); err != nil {
-			return ctx.Send(BuyFailed{Msg: err.Error()})
+			return "", err
 		}
 	}
 
@@ -96,11 +166,12 @@ func (m *Manager) handleBuyGoods(ctx statemachine.Context, info OrderInfo) error
 			SystemDiskCategory:      vInfo.SystemDiskCategory,
 			InternetMaxBandwidthOut: vInfo.BandwidthOut,
 			DataDisk:                vInfo.DataDisk,
+			UserData:                vInfo.UserData,
 		}
 
 		result, err := m.vpsMgr.CreateAliYunInstance(vInfo.ID, createInfo)
 		if err != nil {
-			return ctx.Send(BuyFailed{Msg: err.Error()})
+			return "", err
 		}
 		vInfo.InstanceId = result.InstanceID
 	} else if info.OrderType == int64(types.RenewVPS) {
@@ -111,7 +182,7 @@ func (m *Manager) handleBuyGoods(ctx statemachine.Context, info OrderInfo) error
 			Period:     vInfo.Period,
 		})
 		if err != nil {
-			return ctx.Send(BuyFailed{Msg: err.Error()})
+			return "", err
 		}
 	}
 
@@ -129,13 +200,22 @@ func (m *Manager) handleBuyGoods(ctx statemachine.Context, info OrderInfo) error
 			log.Errorf("ModifyInstanceRenew err: %v", err)
 		}
 	}
-	//// Save To DB
-	//err = m.SaveVpsInstanceDevice(rsp)
-	//if err != nil {
-	//	log.Errorf("SaveVpsInstanceDevice err:%s", err.Error())
-	//}
 
-	return ctx.Send(BuySucceed{GoodsInfo: &GoodsInfo{ID: "vps_id", Password: "abc"}})
+	return vInfo.InstanceId, nil
+}
+
+// deleteUnfulfilledInstances deletes the instance records of the items that were not fulfilled
+func (m *Manager) deleteUnfulfilledInstances(info OrderInfo, items []*types.OrderItem) {
+	for _, item := range items {
+		if item.State == types.OrderItemSucceeded {
+			continue
+		}
+
+		err := m.DeleteInstanceInfo(item.VpsID)
+		if err != nil {
+			log.Errorf("order %s DeleteInstanceInfo %d err:%s", info.OrderID, item.VpsID, err.Error())
+		}
+	}
 }
 
 // handleOrderDone handles the order completion
@@ -144,32 +224,68 @@ func (m *Manager) handleOrderDone(ctx statemachine.Context, info OrderInfo) erro
 
 	m.removeOrder(info.OrderID.String())
 
-	if info.DoneState == OrderDoneStatePurchaseFailed {
-		original, err := m.LoadUserBalance(info.User)
-		if err != nil {
-			log.Errorf("handleOrderDone LoadUserBalance err:%s", err.Error())
-			return nil
-		}
+	items, err := m.LoadOrderItems(info.OrderID.String())
+	if err != nil {
+		log.Errorf("handleOrderDone LoadOrderItems err:%s", err.Error())
+		return nil
+	}
 
-		newValue, err := utils.AddBigInt(original, info.Value)
-		if err != nil {
-			log.Errorf("handleOrderDone BigIntAdd err:%s", err.Error())
-			return nil
+	err = m.settleOrderHold(info, items)
+	if err != nil {
+		log.Errorf("handleOrderDone settleOrderHold err:%s", err.Error())
+		return nil
+	}
+
+	// the invoice is created by the consumer of the order events
+	if info.DoneState == OrderDoneStateSuccess {
+		return nil
+	}
+
+	if len(items) == 0 {
+		items = append(items, &types.OrderItem{VpsID: info.VpsID})
+	}
+
+	m.deleteUnfulfilledInstances(info, items)
+
+	return nil
+}
+
+// settleOrderHold captures the hold of a done order for its fulfilled items and releases the rest to the user,
+// the hold of an order done again after a restart is settled already and left as it is.
+func (m *Manager) settleOrderHold(info OrderInfo, items []*types.OrderItem) error {
+	captured := "0"
+	if info.DoneState == OrderDoneStateSuccess {
+		captured = info.Value
+		if len(items) > 0 {
+			captured = "0"
 		}
 
-		err = m.UpdateUserBalance(info.User, newValue, original)
-		if err != nil {
-			log.Errorf("handleOrderDone UpdateUserBalance err:%s", err.Error())
-			return nil
+		for _, item := range items {
+			if item.State != types.OrderItemSucceeded {
+				continue
+			}
+
+			v, err := utils.AddBigInt(captured, item.Value)
+			if err != nil {
+				return err
+			}
+			captured = v
 		}
 	}
 
-	if info.DoneState != OrderDoneStateSuccess {
-		err := m.DeleteInstanceInfo(info.VpsID)
-		if err != nil {
-			log.Errorf("handleOrderDone DeleteInstanceInfo err:%s", err.Error())
-			return nil
-		}
+	err := m.SettleOrderHold(info.OrderID.String(), captured)
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	// orders without a hold were either never paid or charged before holds were introduced,
+	// the charged ones refund the items that were not fulfilled
+	switch {
+	case info.DoneState == OrderDoneStatePurchaseFailed && len(items) == 0:
+		return m.RefundOrder(info.OrderID.String(), info.User, info.Value)
+	case info.DoneState == OrderDoneStatePurchaseFailed || info.DoneState == OrderDoneStateSuccess:
+		_, err = m.RefundOrderItems(info.OrderID.String(), info.User)
+		return err
 	}
 
 	return nil
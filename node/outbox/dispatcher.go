@@ -0,0 +1,243 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/db"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("outbox")
+
+const (
+	dispatchInterval = 3 * time.Second
+	dispatchLimit    = 500
+
+	// settleTime is how long an event waits in the outbox before it is dispatched. The ids are taken when the events
+	// are saved and not when they are committed, the transactions saving the events with lower ids are over by then
+	// so the offset never passes an event that is committed late.
+	settleTime = time.Minute
+
+	// maxAttempts is the number of times an event is handed to a consumer before it is dead-lettered
+	maxAttempts = 8
+	// retryBackoff is doubled on every attempt up to maxRetryBackoff
+	retryBackoff    = 30 * time.Second
+	maxRetryBackoff = time.Hour
+
+	maxErrLength = 2048
+)
+
+// Source lists the events of an outbox from an id in the order they were saved.
+type Source func(ctx context.Context, from, limit int64) (*types.OutboxEventsResponse, error)
+
+// Handler handles an event for a consumer. An event is handed again if its handler fails
+// or the consumer stops before its offset is saved, so the handlers are idempotent.
+type Handler func(event *types.OutboxEvent) error
+
+// Store saves the offsets and the failed deliveries of the consumers.
+type Store interface {
+	LoadOutboxOffset(consumer string) (int64, error)
+	SaveOutboxOffset(consumer string, next int64) error
+	SaveOutboxDelivery(info *types.OutboxDelivery) error
+	LoadDueOutboxDeliveries(consumer string, limit int64) ([]*types.OutboxDelivery, error)
+}
+
+// LocalSource lists the events of the topics in the outbox of a database.
+func LocalSource(sdb *db.SQLDB, topics ...string) Source {
+	return func(ctx context.Context, from, limit int64) (*types.OutboxEventsResponse, error) {
+		return sdb.LoadOutboxEvents(topics, from, limit)
+	}
+}
+
+// Dispatcher hands the events of an outbox to a consumer at least once. The consumer offset is saved
+// after its events are handled, an event the consumer fails to handle is retried with a backoff
+// until it is dead-lettered for an admin.
+type Dispatcher struct {
+	Store
+
+	consumer string
+	source   Source
+	handler  Handler
+	next     int64
+}
+
+// NewDispatcher creates a dispatcher of the events of a source to a consumer, the offsets and the
+// failed deliveries of the consumer are saved in its store.
+func NewDispatcher(store Store, consumer string, source Source, handler Handler) *Dispatcher {
+	return &Dispatcher{
+		Store:    store,
+		consumer: consumer,
+		source:   source,
+		handler:  handler,
+	}
+}
+
+// Start starts dispatching the events.
+func (d *Dispatcher) Start() {
+	go d.run()
+}
+
+func (d *Dispatcher) run() {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	loaded := false
+
+	for {
+		<-ticker.C
+
+		// the events are not dispatched from the start because the offset failed to load
+		if !loaded {
+			next, err := d.LoadOutboxOffset(d.consumer)
+			if err != nil {
+				log.Errorf("%s LoadOutboxOffset err:%s", d.consumer, err.Error())
+				continue
+			}
+
+			d.next = next
+			loaded = true
+		}
+
+		d.retryDeliveries()
+		d.dispatchEvents()
+	}
+}
+
+// dispatchEvents hands the settled events to the consumer and saves its offset after every page,
+// the offset stops at the first event that is not settled.
+func (d *Dispatcher) dispatchEvents() {
+	for {
+		rsp, err := d.source(context.Background(), d.next, dispatchLimit)
+		if err != nil {
+			log.Errorf("%s list events err:%s", d.consumer, err.Error())
+			return
+		}
+
+		next := d.next
+		settled := true
+		for _, event := range rsp.List {
+			if rsp.Now.Sub(event.CreatedTime) < settleTime {
+				// an event with a lower id may still be committed
+				settled = false
+				break
+			}
+
+			err = d.deliver(event)
+			if err != nil {
+				// the event is handed again from the saved offset
+				log.Errorf("%s deliver event %d err:%s", d.consumer, event.ID, err.Error())
+				break
+			}
+
+			next = event.ID + 1
+		}
+
+		if err == nil && settled {
+			next = rsp.Next
+		}
+
+		if next != d.next {
+			if sErr := d.SaveOutboxOffset(d.consumer, next); sErr != nil {
+				log.Errorf("%s SaveOutboxOffset err:%s", d.consumer, sErr.Error())
+				return
+			}
+			d.next = next
+		}
+
+		if err != nil || !settled || len(rsp.List) < dispatchLimit {
+			return
+		}
+	}
+}
+
+// deliver hands an event to the consumer, an error is returned only if its failure could not be saved.
+func (d *Dispatcher) deliver(event *types.OutboxEvent) error {
+	err := d.handle(event)
+	if err == nil {
+		return nil
+	}
+
+	log.Warnf("%s handle %s event %d err:%s", d.consumer, event.Topic, event.ID, err.Error())
+
+	return d.SaveOutboxDelivery(&types.OutboxDelivery{
+		Consumer: d.consumer,
+		EventID:  event.ID,
+		Topic:    event.Topic,
+		Payload:  event.Payload,
+		State:    types.OutboxDeliveryRetry,
+		Attempts: 1,
+		LastErr:  truncateErr(err),
+		NextTime: time.Now().Add(backoff(1)),
+	})
+}
+
+// retryDeliveries hands the failed events whose backoff has passed to the consumer again.
+func (d *Dispatcher) retryDeliveries() {
+	list, err := d.LoadDueOutboxDeliveries(d.consumer, dispatchLimit)
+	if err != nil {
+		log.Errorf("%s LoadDueOutboxDeliveries err:%s", d.consumer, err.Error())
+		return
+	}
+
+	for _, info := range list {
+		event := &types.OutboxEvent{ID: info.EventID, Topic: info.Topic, Payload: info.Payload}
+
+		err = d.handle(event)
+		if err == nil {
+			info.State = types.OutboxDeliveryDone
+			info.LastErr = ""
+		} else {
+			info.Attempts++
+			info.LastErr = truncateErr(err)
+			info.NextTime = time.Now().Add(backoff(info.Attempts))
+
+			if info.Attempts >= maxAttempts {
+				log.Errorf("%s %s event %d is dead-lettered after %d attempts: %s", d.consumer, info.Topic, info.EventID, info.Attempts, info.LastErr)
+				info.State = types.OutboxDeliveryDead
+			}
+		}
+
+		err = d.SaveOutboxDelivery(info)
+		if err != nil {
+			log.Errorf("%s SaveOutboxDelivery %d err:%s", d.consumer, info.EventID, err.Error())
+		}
+	}
+}
+
+// handle calls the handler of the consumer, a panic is returned as an error.
+func (d *Dispatcher) handle(event *types.OutboxEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("handler panic: %v", r)
+		}
+	}()
+
+	return d.handler(event)
+}
+
+// backoff returns the time an event waits before its next attempt.
+func backoff(attempts int64) time.Duration {
+	wait := retryBackoff
+	for i := int64(1); i < attempts && wait < maxRetryBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > maxRetryBackoff {
+		wait = maxRetryBackoff
+	}
+
+	return wait
+}
+
+func truncateErr(err error) string {
+	msg := err.Error()
+	if len(msg) > maxErrLength {
+		msg = fmt.Sprintf("%s...", msg[:maxErrLength-3])
+	}
+
+	return msg
+}
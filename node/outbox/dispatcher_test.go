@@ -0,0 +1,182 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int64
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, c := range cases {
+		if got := backoff(c.attempts); got != c.want {
+			t.Errorf("backoff(%d) = %s, want %s", c.attempts, got, c.want)
+		}
+	}
+}
+
+// memStore keeps the offsets and the deliveries in memory, all the retries are due.
+type memStore struct {
+	offsets    map[string]int64
+	deliveries map[int64]*types.OutboxDelivery
+}
+
+func newMemStore() *memStore {
+	return &memStore{offsets: map[string]int64{}, deliveries: map[int64]*types.OutboxDelivery{}}
+}
+
+func (s *memStore) LoadOutboxOffset(consumer string) (int64, error) {
+	return s.offsets[consumer], nil
+}
+
+func (s *memStore) SaveOutboxOffset(consumer string, next int64) error {
+	s.offsets[consumer] = next
+	return nil
+}
+
+func (s *memStore) SaveOutboxDelivery(info *types.OutboxDelivery) error {
+	saved := *info
+	s.deliveries[info.EventID] = &saved
+	return nil
+}
+
+func (s *memStore) LoadDueOutboxDeliveries(consumer string, limit int64) ([]*types.OutboxDelivery, error) {
+	var out []*types.OutboxDelivery
+	for _, info := range s.deliveries {
+		if info.Consumer == consumer && info.State == types.OutboxDeliveryRetry {
+			saved := *info
+			out = append(out, &saved)
+		}
+	}
+	return out, nil
+}
+
+// memOutbox lists the committed events like the outbox table, the ids are taken before the events are committed.
+type memOutbox struct {
+	now    time.Time
+	events []*types.OutboxEvent
+}
+
+func (o *memOutbox) commit(id int64, created time.Time) {
+	o.events = append(o.events, &types.OutboxEvent{ID: id, Topic: "test", CreatedTime: created})
+}
+
+func (o *memOutbox) source(ctx context.Context, from, limit int64) (*types.OutboxEventsResponse, error) {
+	sort.Slice(o.events, func(i, j int) bool { return o.events[i].ID < o.events[j].ID })
+
+	out := &types.OutboxEventsResponse{Next: from, Now: o.now}
+	for _, event := range o.events {
+		if event.ID < from || int64(len(out.List)) >= limit {
+			continue
+		}
+
+		out.List = append(out.List, event)
+		out.Next = event.ID + 1
+	}
+	return out, nil
+}
+
+func TestDispatchOutOfOrderCommit(t *testing.T) {
+	start := time.Now()
+	ob := &memOutbox{now: start}
+	store := newMemStore()
+
+	var handled []int64
+	d := NewDispatcher(store, "test", ob.source, func(event *types.OutboxEvent) error {
+		handled = append(handled, event.ID)
+		return nil
+	})
+
+	// event 2 is saved before event 3 and committed after it
+	ob.commit(1, start)
+	ob.commit(3, start.Add(time.Second))
+	ob.now = start.Add(settleTime)
+	d.dispatchEvents()
+
+	if len(handled) != 1 || handled[0] != 1 {
+		t.Fatalf("handled %v before the events settled, want [1]", handled)
+	}
+	if store.offsets["test"] != 2 {
+		t.Fatalf("offset is %d, want 2", store.offsets["test"])
+	}
+
+	ob.commit(2, start.Add(time.Second))
+	ob.now = start.Add(settleTime + time.Second)
+	d.dispatchEvents()
+
+	if len(handled) != 3 || handled[1] != 2 || handled[2] != 3 {
+		t.Fatalf("handled %v, want [1 2 3]", handled)
+	}
+	if store.offsets["test"] != 4 {
+		t.Fatalf("offset is %d, want 4", store.offsets["test"])
+	}
+}
+
+func TestDispatchRetryAndDeadLetter(t *testing.T) {
+	start := time.Now()
+	ob := &memOutbox{now: start.Add(settleTime)}
+	ob.commit(1, start)
+	ob.commit(2, start)
+	store := newMemStore()
+
+	fail := map[int64]bool{1: true, 2: true}
+	var handled []int64
+	d := NewDispatcher(store, "test", ob.source, func(event *types.OutboxEvent) error {
+		if fail[event.ID] {
+			return errors.New("handler failed")
+		}
+		handled = append(handled, event.ID)
+		return nil
+	})
+
+	d.dispatchEvents()
+
+	// a failed event is kept for a retry and does not stop the events after it
+	if store.offsets["test"] != 3 {
+		t.Fatalf("offset is %d, want 3", store.offsets["test"])
+	}
+	for id := int64(1); id <= 2; id++ {
+		info := store.deliveries[id]
+		if info == nil || info.State != types.OutboxDeliveryRetry || info.Attempts != 1 {
+			t.Fatalf("delivery %d is %+v, want a first retry", id, info)
+		}
+	}
+
+	// event 1 is handled by its retry, event 2 keeps failing
+	fail[1] = false
+	d.retryDeliveries()
+
+	if info := store.deliveries[1]; info.State != types.OutboxDeliveryDone {
+		t.Fatalf("delivery 1 is %s, want Done", info.State)
+	}
+	if len(handled) != 1 || handled[0] != 1 {
+		t.Fatalf("handled %v, want [1]", handled)
+	}
+
+	for i := 0; i < maxAttempts; i++ {
+		d.retryDeliveries()
+	}
+
+	info := store.deliveries[2]
+	if info.State != types.OutboxDeliveryDead || info.Attempts != maxAttempts {
+		t.Fatalf("delivery 2 is %s after %d attempts, want Dead after %d", info.State, info.Attempts, maxAttempts)
+	}
+	if info.LastErr != "handler failed" {
+		t.Fatalf("delivery 2 last error is %q", info.LastErr)
+	}
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/LMF709268224/titan-vps/node/handler"
+	"github.com/LMF709268224/titan-vps/node/invoice"
+	"github.com/LMF709268224/titan-vps/node/statement"
 	"github.com/xuri/excelize/v2"
 
 	"github.com/filecoin-project/go-jsonrpc"
@@ -85,6 +87,7 @@ func TransactionHandler(a api.Transaction, permissioned bool, opts ...jsonrpc.Se
 	}
 
 	serveRpc("/rpc/v0", fnapi)
+	m.Handle("/debug/metrics", metrics.Exporter())
 	m.PathPrefix("/").Handler(http.DefaultServeMux) // pprof
 
 	return m, nil
@@ -115,6 +118,9 @@ func MallHandler(a api.Mall, permissioned bool, cfg *config.MallCfg, opts ...jso
 	serveRPC("/rpc/v0", wapi)
 	m.HandleFunc("/rpc/index", homePage)
 	m.HandleFunc("/rpc/download/withdraw", downloadWithdrawFile)
+	m.Handle("/rpc/download/invoice", handler.New(a.AuthVerify, downloadInvoiceFile(a)))
+	m.Handle("/rpc/download/statement", handler.New(a.AuthVerify, downloadStatementFile(a)))
+	m.Handle("/debug/metrics", metrics.Exporter())
 	m.PathPrefix("/").Handler(http.DefaultServeMux) // pprof
 
 	return m, nil
@@ -140,7 +146,8 @@ func downloadWithdrawFile(w http.ResponseWriter, r *http.Request) {
 
 	statuses := make([]types.WithdrawState, 0)
 	if state == "" {
-		statuses = []types.WithdrawState{types.WithdrawCreate, types.WithdrawDone, types.WithdrawRefund}
+		statuses = []types.WithdrawState{types.WithdrawCreate, types.WithdrawDone, types.WithdrawRefund,
+			types.WithdrawApproved, types.WithdrawBroadcast, types.WithdrawFailed}
 	} else {
 		s2, err := strconv.Atoi(state)
 		if err != nil {
@@ -164,13 +171,8 @@ func downloadWithdrawFile(w http.ResponseWriter, r *http.Request) {
 	}
 	defer rows.Close()
 
-	file := excelize.NewFile()
-	columns := []string{"OrderID", "UserID", "Value", "WithdrawAddr", "WithdrawHash", "CreatedTime", "State"}
-	for i, colName := range columns {
-		file.SetCellValue("Sheet1", string(rune('A'+i))+"1", colName)
-	}
-
-	rowIdx := 2
+	columns := []string{"OrderID", "UserID", "Value", "Fee", "NetValue", "WithdrawAddr", "WithdrawHash", "CreatedTime", "State"}
+	values := make([][]string, 0)
 	for rows.Next() {
 		info := &types.WithdrawRecord{}
 		err = rows.StructScan(info)
@@ -179,11 +181,7 @@ func downloadWithdrawFile(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		values := []string{info.OrderID, info.UserID, info.Value, info.WithdrawAddr, info.WithdrawHash, info.CreatedTime.String(), strconv.Itoa(int(info.State))}
-		for i, value := range values {
-			file.SetCellValue("Sheet1", string(rune('A'+i))+strconv.Itoa(rowIdx), value)
-		}
-		rowIdx++
+		values = append(values, []string{info.OrderID, info.UserID, info.Value, info.Fee, info.PayoutValue(), info.WithdrawAddr, info.WithdrawHash, info.CreatedTime.String(), strconv.Itoa(int(info.State))})
 	}
 
 	if rows.Err() != nil {
@@ -191,11 +189,108 @@ func downloadWithdrawFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	writeExcelFile(w, "Withdraw.xlsx", columns, values)
+}
+
+// writeExcelFile writes the rows as an excel file attachment
+func writeExcelFile(w http.ResponseWriter, fileName string, columns []string, rows [][]string) {
+	file := excelize.NewFile()
+	for i, colName := range columns {
+		file.SetCellValue("Sheet1", string(rune('A'+i))+"1", colName)
+	}
+
+	for rowIdx, values := range rows {
+		for i, value := range values {
+			file.SetCellValue("Sheet1", string(rune('A'+i))+strconv.Itoa(rowIdx+2), value)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	w.Header().Set("Content-Disposition", "attachment; filename=Withdraw.xlsx")
-	err = file.Write(w)
+	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+	err := file.Write(w)
 	if err != nil {
 		http.Error(w, "Error writing excel file", http.StatusInternalServerError)
 		return
 	}
 }
+
+// downloadInvoiceFile returns a handler rendering an invoice of the token owner,
+// the invoice number and format are passed as query parameters.
+func downloadInvoiceFile(a api.Mall) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		no, err := strconv.ParseInt(r.URL.Query().Get("no"), 10, 64)
+		if err != nil {
+			http.Error(w, "invalid invoice number", http.StatusBadRequest)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = invoice.FormatPDF
+		}
+
+		out, err := a.RenderInvoice(r.Context(), no, format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if format == invoice.FormatHTML {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=invoice-%d.pdf", no))
+		}
+
+		_, err = w.Write(out)
+		if err != nil {
+			log.Errorf("write invoice err: %s", err.Error())
+		}
+	}
+}
+
+// downloadStatementFile returns a handler exporting the account statement of the token owner,
+// the from and to dates and the csv or xlsx format are passed as query parameters.
+func downloadStatementFile(a api.Mall) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from := r.URL.Query().Get("from")
+		to := r.URL.Query().Get("to")
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = statement.FormatCSV
+		}
+
+		info, err := a.GetAccountStatement(r.Context(), from, to, 0)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for cursor := info.NextCursor; cursor > 0; {
+			page, err := a.GetAccountStatement(r.Context(), from, to, cursor)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			info.List = append(info.List, page.List...)
+			cursor = page.NextCursor
+		}
+
+		fileName := fmt.Sprintf("statement-%s-%s.%s", info.From, info.To, format)
+		switch format {
+		case statement.FormatCSV:
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
+			err = statement.WriteCSV(w, info)
+			if err != nil {
+				log.Errorf("write statement err: %s", err.Error())
+			}
+		case statement.FormatXLSX:
+			writeExcelFile(w, fileName, statement.Columns, statement.Rows(info))
+		default:
+			http.Error(w, "unsupported statement format", http.StatusBadRequest)
+		}
+	}
+}
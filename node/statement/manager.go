@@ -0,0 +1,300 @@
+package statement
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/lib/email"
+	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
+	"github.com/LMF709268224/titan-vps/node/utils"
+	logging "github.com/ipfs/go-log/v2"
+	"golang.org/x/xerrors"
+)
+
+var log = logging.Logger("statement")
+
+const (
+	// dateLayout is the layout of the statement dates
+	dateLayout = "2006-01-02"
+	// periodLayout is the layout of the monthly statement periods
+	periodLayout = "2006-01"
+	// pageSize is the number of entries of a statement page
+	pageSize = 100
+
+	mailStatementsInterval = 6 * time.Hour
+)
+
+// Manager builds the account statements of the users and mails the monthly statements.
+type Manager struct {
+	*db.SQLDB
+	getCfg dtypes.GetMallConfigFunc
+}
+
+// NewManager creates a new statement manager instance.
+func NewManager(sdb *db.SQLDB, getCfg dtypes.GetMallConfigFunc) *Manager {
+	m := &Manager{
+		SQLDB:  sdb,
+		getCfg: getCfg,
+	}
+
+	go m.cronMailStatements()
+
+	return m
+}
+
+// Statement builds a page of the account statement of a user, from and to are dates formatted as 2006-01-02
+// and both are included. The statement of the current month is built if they are empty.
+func (m *Manager) Statement(userID, from, to string, cursor int64) (*types.AccountStatement, error) {
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &types.AccountStatement{
+		UserID: userID,
+		From:   start.Format(dateLayout),
+		To:     end.AddDate(0, 0, -1).Format(dateLayout),
+		List:   make([]*types.StatementEntry, 0),
+	}
+
+	out.OpeningBalance, err = m.LoadLedgerBalanceAt(userID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	out.ClosingBalance, err = m.LoadLedgerBalanceAt(userID, end)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := m.LoadLedgerEntries(userID, start, end, cursor, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		return out, nil
+	}
+
+	balance := out.OpeningBalance
+	if cursor > 0 {
+		balance, err = m.LoadLedgerBalanceBefore(userID, entries[0].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out.List, err = statementEntries(entries, balance)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == pageSize {
+		out.NextCursor = entries[len(entries)-1].ID
+	}
+
+	return out, nil
+}
+
+// FullStatement builds the account statement of a user with the entries of every page.
+func (m *Manager) FullStatement(userID, from, to string) (*types.AccountStatement, error) {
+	out, err := m.Statement(userID, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for cursor := out.NextCursor; cursor > 0; {
+		page, err := m.Statement(userID, from, to, cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		out.List = append(out.List, page.List...)
+		cursor = page.NextCursor
+	}
+	out.NextCursor = 0
+
+	return out, nil
+}
+
+// statementEntries converts ledger entries to statement entries with the balance after each entry.
+func statementEntries(entries []*types.LedgerEntry, balance string) ([]*types.StatementEntry, error) {
+	out := make([]*types.StatementEntry, 0, len(entries))
+	for _, entry := range entries {
+		var err error
+		balance, err = utils.AddBigInt(balance, entry.Amount)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, &types.StatementEntry{
+			ID:      entry.ID,
+			Time:    entry.CreatedTime,
+			Type:    entryType(entry),
+			RefType: entry.RefType,
+			RefID:   entry.RefID,
+			Amount:  entry.Amount,
+			Balance: balance,
+		})
+	}
+
+	return out, nil
+}
+
+// entryType describes the business operation of a ledger entry.
+func entryType(entry *types.LedgerEntry) string {
+	debit := strings.HasPrefix(entry.Amount, "-")
+
+	switch entry.RefType {
+	case types.LedgerRefRecharge:
+		return "Deposit"
+	case types.LedgerRefWithdraw:
+		if debit {
+			return "Withdrawal"
+		}
+		return "Withdrawal released"
+	case types.LedgerRefWithdrawReject:
+		return "Withdrawal released"
+	case types.LedgerRefOrder:
+		if debit {
+			return "Order payment"
+		}
+		return "Order payment released"
+	case types.LedgerRefOrderRefund, types.LedgerRefInstanceRefund:
+		return "Refund"
+	case types.LedgerRefOpening:
+		return "Adjustment"
+	}
+
+	return string(entry.RefType)
+}
+
+// parseRange returns the time range [start, end) of the statement dates.
+func parseRange(from, to string) (time.Time, time.Time, error) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	var err error
+	if from != "" {
+		start, err = time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			return start, end, err
+		}
+	}
+
+	if to != "" {
+		end, err = time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return start, end, err
+		}
+	}
+
+	end = end.AddDate(0, 0, 1)
+	if !start.Before(end) {
+		return start, end, xerrors.Errorf("statement range %s - %s is empty", from, to)
+	}
+
+	return start, end, nil
+}
+
+func (m *Manager) cronMailStatements() {
+	ticker := time.NewTicker(mailStatementsInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		m.mailStatements(time.Now())
+	}
+}
+
+// mailStatements mails the statement of the previous month to the users whose balance changed in it.
+func (m *Manager) mailStatements(now time.Time) {
+	cfg, err := m.getCfg()
+	if err != nil {
+		log.Errorf("get config err:%s", err.Error())
+		return
+	}
+
+	if cfg.Email.SMTPHost == "" {
+		return
+	}
+
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	start := end.AddDate(0, -1, 0)
+	period := start.Format(periodLayout)
+
+	users, err := m.LoadLedgerUsers(start, end)
+	if err != nil {
+		log.Errorf("LoadLedgerUsers err:%s", err.Error())
+		return
+	}
+
+	for _, userID := range users {
+		exist, err := m.StatementMailExists(userID, period)
+		if err != nil {
+			log.Errorf("StatementMailExists err:%s", err.Error())
+			continue
+		}
+
+		if exist {
+			continue
+		}
+
+		addr := m.mailAddress(userID)
+		if addr == "" {
+			continue
+		}
+
+		info, err := m.FullStatement(userID, start.Format(dateLayout), end.AddDate(0, 0, -1).Format(dateLayout))
+		if err != nil {
+			log.Errorf("%s FullStatement err:%s", userID, err.Error())
+			continue
+		}
+
+		content, err := RenderHTML(info)
+		if err != nil {
+			log.Errorf("%s RenderHTML err:%s", userID, err.Error())
+			continue
+		}
+
+		err = email.SendEmail(cfg.Email, email.Data{
+			SendTo:  addr,
+			Subject: "【Titan VPS】Account statement " + period,
+			Tittle:  "Account statement " + period,
+			Content: string(content),
+		})
+		if err != nil {
+			log.Errorf("%s SendEmail err:%s", userID, err.Error())
+			continue
+		}
+
+		err = m.SaveStatementMail(userID, period, addr)
+		if err != nil {
+			log.Errorf("%s SaveStatementMail err:%s", userID, err.Error())
+		}
+	}
+}
+
+// mailAddress returns the billing e-mail of a user, users signed in with an e-mail use it by default.
+func (m *Manager) mailAddress(userID string) string {
+	billing, err := m.LoadBillingInfo(userID)
+	if err != nil && err != sql.ErrNoRows {
+		log.Errorf("LoadBillingInfo err:%s", err.Error())
+		return ""
+	}
+
+	if billing != nil && billing.Email != "" {
+		return billing.Email
+	}
+
+	if strings.Contains(userID, "@") {
+		return userID
+	}
+
+	return ""
+}
@@ -0,0 +1,59 @@
+package statement
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+)
+
+func TestStatementEntries(t *testing.T) {
+	entries := []*types.LedgerEntry{
+		{ID: 1, Amount: "5000000", RefType: types.LedgerRefRecharge, RefID: "tx"},
+		{ID: 2, Amount: "-2000000", RefType: types.LedgerRefOrder, RefID: "order"},
+		{ID: 3, Amount: "500000", RefType: types.LedgerRefOrder, RefID: "order"},
+		{ID: 4, Amount: "-1000000", RefType: types.LedgerRefWithdraw, RefID: "withdraw"},
+	}
+
+	list, err := statementEntries(entries, "1000000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ typ, balance string }{
+		{"Deposit", "6000000"},
+		{"Order payment", "4000000"},
+		{"Order payment released", "4500000"},
+		{"Withdrawal", "3500000"},
+	}
+	for i, w := range want {
+		if list[i].Type != w.typ || list[i].Balance != w.balance {
+			t.Errorf("entry %d = %s %s, want %s %s", i, list[i].Type, list[i].Balance, w.typ, w.balance)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteCSV(buf, &types.AccountStatement{List: list}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), "Order payment,order,-2.000000 USD,4.000000 USD") {
+		t.Errorf("unexpected csv statement: %s", buf.String())
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	start, end, err := parseRange("2023-09-01", "2023-09-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if start.Format(dateLayout) != "2023-09-01" || end.Format(dateLayout) != "2023-10-01" {
+		t.Errorf("unexpected range %s - %s", start, end)
+	}
+
+	if _, _, err = parseRange("2023-09-30", "2023-09-01"); err == nil {
+		t.Errorf("parseRange should fail with an empty range")
+	}
+}
@@ -0,0 +1,73 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"html/template"
+	"io"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/invoice"
+)
+
+const (
+	// FormatCSV exports the statement as a csv file
+	FormatCSV = "csv"
+	// FormatXLSX exports the statement as an excel file
+	FormatXLSX = "xlsx"
+)
+
+// Columns are the column names of an exported statement
+var Columns = []string{"Time", "Type", "Reference", "Amount", "Balance"}
+
+var htmlTemplate = template.Must(template.New("statement").Funcs(template.FuncMap{
+	"amount": invoice.FormatAmount,
+}).Parse(`<h2>Account statement {{.From}} - {{.To}}</h2>
+<p>Account: {{.UserID}}</p>
+<p>Opening balance: {{amount .OpeningBalance}}</p>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>Time</th><th>Type</th><th>Reference</th><th>Amount</th><th>Balance</th></tr>
+{{range .List}}<tr><td>{{.Time.Format "2006-01-02 15:04:05"}}</td><td>{{.Type}}</td><td>{{.RefID}}</td><td>{{amount .Amount}}</td><td>{{amount .Balance}}</td></tr>
+{{end}}</table>
+<p>Closing balance: {{amount .ClosingBalance}}</p>
+`))
+
+// Rows returns the rows of an exported statement
+func Rows(info *types.AccountStatement) [][]string {
+	rows := make([][]string, 0, len(info.List))
+	for _, entry := range info.List {
+		rows = append(rows, []string{
+			entry.Time.Format("2006-01-02 15:04:05"),
+			entry.Type,
+			entry.RefID,
+			invoice.FormatAmount(entry.Amount),
+			invoice.FormatAmount(entry.Balance),
+		})
+	}
+
+	return rows
+}
+
+// WriteCSV writes a statement as a csv file
+func WriteCSV(w io.Writer, info *types.AccountStatement) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(Columns); err != nil {
+		return err
+	}
+
+	if err := cw.WriteAll(Rows(info)); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
+
+// RenderHTML renders a statement as an html page
+func RenderHTML(info *types.AccountStatement) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := htmlTemplate.Execute(buf, info); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -1,5 +1,12 @@
 package transaction
 
+import (
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/smirkcat/hdwallet"
+	"golang.org/x/xerrors"
+)
+
 func (m *Manager) initTronAddress(as []string) {
 	err := m.SaveRechargeAddresses(as)
 	if err != nil {
@@ -19,3 +26,62 @@ func (m *Manager) initTronAddress(as []string) {
 func (m *Manager) addTronAddr(addr, userID string) {
 	m.tronAddrs.Store(addr, userID)
 }
+
+// parseTronXpub parses the extended public key the deposit addresses are derived from,
+// the private key must not be configured on the mall.
+func parseTronXpub(xpub string) (*hdkeychain.ExtendedKey, error) {
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, xerrors.Errorf("parse TrxXpub err:%s", err.Error())
+	}
+
+	if key.IsPrivate() {
+		return nil, xerrors.New("TrxXpub must be an extended public key")
+	}
+
+	return key, nil
+}
+
+// deriveTronAddress derives the Tron address of a non-hardened child of an extended public key.
+func deriveTronAddress(xpub *hdkeychain.ExtendedKey, index int64) (string, error) {
+	if index < 0 || index >= hdkeychain.HardenedKeyStart {
+		return "", xerrors.Errorf("invalid derive index %d", index)
+	}
+
+	child, err := xpub.Derive(uint32(index))
+	if err != nil {
+		return "", err
+	}
+
+	pub, err := child.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+
+	return hdwallet.PubkeyToAddressTron(*pub.ToECDSA()), nil
+}
+
+func (m *Manager) initFvmAddress(as []string) {
+	addrs := make([]string, 0, len(as))
+	for _, addr := range as {
+		addrs = append(addrs, common.HexToAddress(addr).Hex())
+	}
+
+	err := m.SavePaymentAddresses(addrs)
+	if err != nil {
+		log.Errorf("SavePaymentAddresses err:%s", err.Error())
+	}
+
+	list, err := m.LoadUsedPaymentAddresses()
+	if err != nil {
+		log.Errorf("LoadUsedPaymentAddresses err:%s", err.Error())
+	}
+
+	for _, addr := range list {
+		m.addFvmAddr(addr.Addr, addr.UserID)
+	}
+}
+
+func (m *Manager) addFvmAddr(addr, userID string) {
+	m.fvmAddrs.Store(common.HexToAddress(addr).Hex(), userID)
+}
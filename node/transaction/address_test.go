@@ -0,0 +1,65 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/smirkcat/hdwallet"
+)
+
+func TestDeriveTronAddress(t *testing.T) {
+	seed := make([]byte, hdkeychain.RecommendedSeedLen)
+	master, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// m/44'/195'/0'/0
+	account := master
+	for _, i := range []uint32{hdkeychain.HardenedKeyStart + 44, hdkeychain.HardenedKeyStart + 195, hdkeychain.HardenedKeyStart, 0} {
+		account, err = account.Derive(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	neutered, err := account.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseTronXpub(account.String()); err == nil {
+		t.Errorf("parseTronXpub should fail with an extended private key")
+	}
+
+	xpub, err := parseTronXpub(neutered.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, index := range []int64{0, 1, 7} {
+		child, err := account.Derive(uint32(index))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		priv, err := child.ECPrivKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		addr, err := deriveTronAddress(xpub, index)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := hdwallet.PrikeyToAddressTron(priv.ToECDSA()); addr != want {
+			t.Errorf("address %d is %s, want %s", index, addr, want)
+		}
+	}
+
+	if _, err := deriveTronAddress(xpub, hdkeychain.HardenedKeyStart); err == nil {
+		t.Errorf("deriveTronAddress should fail with a hardened index")
+	}
+}
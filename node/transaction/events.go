@@ -0,0 +1,39 @@
+package transaction
+
+import (
+	"fmt"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/db"
+)
+
+// chainTopics are the outbox topics of the chain events
+var chainTopics = []string{
+	string(types.ChainEventTronTransfer),
+	string(types.ChainEventTronDeposit),
+	string(types.ChainEventFvmTransfer),
+	string(types.ChainEventEvmTransfer),
+	string(types.ChainEventPayout),
+}
+
+// newChainEvent creates the outbox event of a chain event, the key identifies what the event reports
+// so that it is saved once however often it is found.
+func newChainEvent(event *types.ChainEvent, key string) (*types.OutboxEvent, error) {
+	topic := string(event.Type)
+	return db.NewOutboxEvent(topic, fmt.Sprintf("%s-%s", topic, key), event)
+}
+
+// publishChainEvent saves a chain event that is not found by a watcher in the outbox.
+func (m *Manager) publishChainEvent(event *types.ChainEvent, key string) error {
+	info, err := newChainEvent(event, key)
+	if err != nil {
+		return err
+	}
+
+	return m.SaveOutboxEvents(info)
+}
+
+// ChainEvents returns the chain events of the outbox from an id.
+func (m *Manager) ChainEvents(from, limit int64) (*types.OutboxEventsResponse, error) {
+	return m.LoadOutboxEvents(chainTopics, from, limit)
+}
@@ -0,0 +1,301 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/node/config"
+	"github.com/LMF709268224/titan-vps/node/db"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	etypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"golang.org/x/xerrors"
+)
+
+const (
+	checkEvmInterval     = 10 * time.Second
+	evmReconnectInterval = 30 * time.Second
+	// evmBlockLimit is the number of blocks filtered at a time
+	evmBlockLimit = 500
+)
+
+// transferTopic is the topic of the ERC-20 Transfer(address,address,uint256) event
+var transferTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+// evmChain is the EVM node the transfer logs are filtered from
+type evmChain interface {
+	bind.ContractFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*etypes.Header, error)
+}
+
+// evmWatcher watches the ERC-20 deposits of an EVM chain
+type evmWatcher struct {
+	cfg    config.EvmChainCfg
+	tokens map[common.Address]config.TokenCfg
+	// deposit address to user
+	addrs sync.Map
+}
+
+// confirmedHead returns the height of the last block with enough confirmations, it is 0 if the chain is shorter.
+func confirmedHead(chain evmChain, confirmations int64) (uint64, error) {
+	head, err := chain.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return 0, xerrors.Errorf("HeaderByNumber err:%s", err.Error())
+	}
+
+	if confirmations < 0 {
+		confirmations = 0
+	}
+
+	if head.Number.Uint64() < uint64(confirmations) {
+		return 0, nil
+	}
+
+	return head.Number.Uint64() - uint64(confirmations), nil
+}
+
+// initEvmChains loads the deposit addresses and starts watching the configured EVM chains.
+func (m *Manager) initEvmChains(chains []config.EvmChainCfg) {
+	m.evmChains = make(map[string]*evmWatcher)
+
+	for _, c := range chains {
+		if c.Name == "" || c.Name == types.ChainTron || c.Name == types.ChainFvm {
+			log.Errorf("invalid evm chain name %s", c.Name)
+			continue
+		}
+
+		w := &evmWatcher{cfg: c, tokens: make(map[common.Address]config.TokenCfg)}
+		for _, token := range c.Tokens {
+			if token.Enabled {
+				w.tokens[common.HexToAddress(token.ContractAddr)] = token
+			}
+		}
+
+		addrs := make([]string, 0, len(c.Addresses))
+		for _, addr := range c.Addresses {
+			addrs = append(addrs, common.HexToAddress(addr).Hex())
+		}
+
+		err := m.SaveEvmAddresses(c.Name, addrs)
+		if err != nil {
+			log.Errorf("SaveEvmAddresses err:%s", err.Error())
+		}
+
+		list, err := m.LoadUsedEvmAddresses(c.Name)
+		if err != nil {
+			log.Errorf("LoadUsedEvmAddresses err:%s", err.Error())
+		}
+
+		for _, addr := range list {
+			w.addAddr(addr.Addr, addr.UserID)
+		}
+
+		m.evmChains[c.Name] = w
+
+		if len(w.tokens) > 0 {
+			go m.watchEvmTransactions(w)
+		}
+	}
+}
+
+func (w *evmWatcher) addAddr(addr, userID string) {
+	w.addrs.Store(common.HexToAddress(addr).Hex(), userID)
+}
+
+// EvmChainExists checks if an EVM chain is configured.
+func (m *Manager) EvmChainExists(chain string) bool {
+	_, exist := m.evmChains[chain]
+	return exist
+}
+
+// AllocateEvmAddress allocates a deposit address of an EVM chain for a user.
+func (m *Manager) AllocateEvmAddress(chain, userID string) (string, error) {
+	w, exist := m.evmChains[chain]
+	if !exist {
+		return "", &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: "unknown chain " + chain}
+	}
+
+	addr, err := m.LoadUnusedEvmAddress(chain)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+	if addr == "" {
+		return "", &api.ErrWeb{Code: terrors.NotFoundAddress.Int(), Message: terrors.NotFoundAddress.String()}
+	}
+
+	err = m.AssignUserToEvmAddress(chain, addr, userID)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+	w.addAddr(addr, userID)
+
+	return addr, nil
+}
+
+// watchEvmTransactions continuously filters the ERC-20 transfers to the deposit addresses of an EVM chain,
+// the node is dialed again if it fails.
+func (m *Manager) watchEvmTransactions(w *evmWatcher) {
+	for {
+		err := m.filterEvmTransactions(w)
+		if err != nil {
+			log.Errorf("%s filterEvmTransactions err:%s", w.cfg.Name, err.Error())
+		}
+
+		time.Sleep(evmReconnectInterval)
+	}
+}
+
+// filterEvmTransactions filters the ERC-20 transfers of an EVM chain from the persisted height until the node fails.
+func (m *Manager) filterEvmTransactions(w *evmWatcher) error {
+	client, err := ethclient.Dial(w.cfg.RPCAddr)
+	if err != nil {
+		return xerrors.Errorf("Dial err:%s", err.Error())
+	}
+	defer client.Close()
+
+	key := db.EvmHeightConfig(w.cfg.Name)
+	height := uint64(0)
+	heightStr := ""
+
+	err = m.LoadConfigValue(key, &heightStr)
+	if err == nil {
+		i, err := strconv.ParseUint(heightStr, 10, 64)
+		if err == nil {
+			height = i
+		}
+	}
+
+	ticker := time.NewTicker(checkEvmInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		next, events, err := m.handleEvmBlocks(client, w, height)
+		if err != nil {
+			return err
+		}
+
+		if next == height {
+			continue
+		}
+
+		// the blocks are filtered again if their deposits are not saved
+		err = m.SaveOutboxEventsWithConfig(events, key, strconv.FormatUint(next, 10))
+		if err != nil {
+			log.Errorf("SaveOutboxEventsWithConfig err:%s", err.Error())
+			continue
+		}
+		height = next
+	}
+}
+
+// handleEvmBlocks handles the ERC-20 transfers of the confirmed blocks after a height
+// and returns the height the next blocks are filtered from with the events of the deposits.
+func (m *Manager) handleEvmBlocks(chain evmChain, w *evmWatcher, height uint64) (uint64, []*types.OutboxEvent, error) {
+	confirmed, err := confirmedHead(chain, w.cfg.Confirmations)
+	if err != nil {
+		return height, nil, err
+	}
+
+	if height == 0 {
+		return confirmed, nil, nil
+	}
+
+	if confirmed <= height {
+		return height, nil, nil
+	}
+
+	end := height + evmBlockLimit
+	if end > confirmed {
+		end = confirmed
+	}
+
+	tokens := make([]common.Address, 0, len(w.tokens))
+	for addr := range w.tokens {
+		tokens = append(tokens, addr)
+	}
+
+	logs, err := chain.FilterLogs(context.Background(), ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(height + 1),
+		ToBlock:   new(big.Int).SetUint64(end),
+		Addresses: tokens,
+		Topics:    [][]common.Hash{{transferTopic}},
+	})
+	if err != nil {
+		return height, nil, xerrors.Errorf("FilterLogs err:%s", err.Error())
+	}
+
+	var events []*types.OutboxEvent
+	for _, l := range logs {
+		if event := m.handleEvmLog(w, l); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return end, events, nil
+}
+
+// handleEvmLog returns the event of an ERC-20 transfer to a deposit address.
+func (m *Manager) handleEvmLog(w *evmWatcher, l etypes.Log) *types.OutboxEvent {
+	// Transfer has indexed from and to addresses and the value in data
+	if l.Removed || len(l.Topics) != 3 || len(l.Data) != 32 {
+		return nil
+	}
+
+	to := common.BytesToAddress(l.Topics[2].Bytes())
+
+	userI, exist := w.addrs.Load(to.Hex())
+	if !exist || userI == nil {
+		return nil
+	}
+
+	token, exist := w.tokens[l.Address]
+	if !exist {
+		return nil
+	}
+
+	txHash := l.TxHash.Hex()
+	amount := new(big.Int).SetBytes(l.Data).String()
+
+	if belowMinDeposit(token, amount) {
+		log.Warnf("%s deposit %s of %s %s is below the minimum deposit %s", w.cfg.Name, txHash, amount, token.Symbol, token.MinDeposit)
+		return nil
+	}
+
+	value, err := normalizeAmount(amount, token.Decimals)
+	if err != nil {
+		log.Errorf("%s normalizeAmount err:%s", txHash, err.Error())
+		return nil
+	}
+
+	event, err := newChainEvent(&types.ChainEvent{Type: types.ChainEventEvmTransfer, EvmTransfer: &types.EvmTransferWatch{
+		Chain:       w.cfg.Name,
+		TxHash:      txHash,
+		LogIndex:    l.Index,
+		From:        common.BytesToAddress(l.Topics[1].Bytes()).Hex(),
+		To:          to.Hex(),
+		Value:       value,
+		UserID:      userI.(string),
+		Token:       token.Symbol,
+		RawValue:    amount,
+		BlockNumber: int64(l.BlockNumber),
+		BlockHash:   l.BlockHash.Hex(),
+	}}, fmt.Sprintf("%s-%s-%d", w.cfg.Name, txHash, l.Index))
+	if err != nil {
+		log.Errorf("%s newChainEvent err:%s", txHash, err.Error())
+		return nil
+	}
+
+	return event
+}
@@ -0,0 +1,120 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/lib/filecoinbridge"
+	"github.com/LMF709268224/titan-vps/node/config"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestHandleEvmBlocks(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(1e18)}}, 10000000)
+	defer backend.Close()
+
+	parsed, err := filecoinbridge.FvmMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deploy := func() (common.Address, *bind.BoundContract) {
+		addr, _, contract, err := bind.DeployContract(auth, *parsed, transferEmitterCode, backend)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+
+		return addr, contract
+	}
+
+	usdtAddr, usdt := deploy()
+	daiAddr, dai := deploy()
+	_, unknown := deploy()
+
+	deposit := common.HexToAddress("0x2000000000000000000000000000000000000001")
+
+	m := &Manager{}
+	w := &evmWatcher{
+		cfg: config.EvmChainCfg{Name: "ethereum", Confirmations: 1},
+		tokens: map[common.Address]config.TokenCfg{
+			usdtAddr: {ContractAddr: usdtAddr.Hex(), Symbol: "USDT", Decimals: 6, Enabled: true},
+			daiAddr:  {ContractAddr: daiAddr.Hex(), Symbol: "DAI", Decimals: 18, Enabled: true},
+		},
+	}
+	w.addAddr(deposit.Hex(), "user1")
+
+	transfer := func(contract *bind.BoundContract, to common.Address, value *big.Int) {
+		data := append(common.LeftPadBytes(to.Bytes(), 32), common.LeftPadBytes(value.Bytes(), 32)...)
+		_, err := contract.RawTransact(auth, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+	}
+
+	height, _, err := m.handleEvmBlocks(backend, w, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer(usdt, deposit, big.NewInt(2500000))
+	transfer(unknown, deposit, big.NewInt(2500000))
+	transfer(dai, deposit, new(big.Int).Mul(big.NewInt(3), big.NewInt(1e18)))
+	backend.Commit()
+
+	next, list, err := m.handleEvmBlocks(backend, w, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next != height+4 {
+		t.Fatalf("filtered to %d, want %d", next, height+4)
+	}
+
+	want := []struct {
+		token string
+		value string
+	}{{"USDT", "2500000"}, {"DAI", "3000000"}}
+
+	events := decodeChainEvents(t, list)
+	if len(events) != len(want) {
+		t.Fatalf("published %d transfers, want %d", len(events), len(want))
+	}
+
+	for i, v := range want {
+		tr := events[i].EvmTransfer
+		if tr == nil || tr.Chain != "ethereum" || tr.UserID != "user1" || tr.Token != v.token || tr.Value != v.value {
+			t.Errorf("unexpected transfer %+v", events[i])
+		}
+	}
+}
+
+func decodeChainEvents(t *testing.T, list []*types.OutboxEvent) []*types.ChainEvent {
+	events := make([]*types.ChainEvent, 0, len(list))
+	for _, info := range list {
+		event := &types.ChainEvent{}
+		if err := json.Unmarshal([]byte(info.Payload), event); err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Topic != string(event.Type) {
+			t.Errorf("event %s saved in topic %s", event.Type, info.Topic)
+		}
+		events = append(events, event)
+	}
+
+	return events
+}
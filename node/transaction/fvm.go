@@ -3,8 +3,13 @@ package transaction
 import (
 	"context"
 	"crypto/ecdsa"
+	"fmt"
+	"strconv"
+	"time"
 
+	"github.com/LMF709268224/titan-vps/api/types"
 	"github.com/LMF709268224/titan-vps/lib/filecoinbridge"
+	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	etypes "github.com/ethereum/go-ethereum/core/types"
@@ -13,49 +18,175 @@ import (
 	"golang.org/x/xerrors"
 )
 
-// func (m *Manager) watchFvmTransactions() error {
-// 	client, err := ethclient.Dial(m.cfg.LotusWsAddr)
-// 	if err != nil {
-// 		return xerrors.Errorf("Dial err:%s", err.Error())
-// 	}
-
-// 	tokenAddress := common.HexToAddress(m.cfg.TitanContractorAddr)
-
-// 	fAbi, err := filecoinbridge.NewFvm(tokenAddress, client)
-// 	if err != nil {
-// 		return xerrors.Errorf("NewAbi err:%s", err.Error())
-// 	}
-
-// 	sink := make(chan *filecoinbridge.AbiTransfer)
-
-// 	sub, err := fAbi.WatchTransfer(nil, sink, nil, nil)
-// 	if err != nil {
-// 		return xerrors.Errorf("WatchTransfer err:%s", err.Error())
-// 	}
-
-// 	for {
-// 		select {
-// 		case err := <-sub.Err():
-// 			if err != nil {
-// 				log.Debugln(time.Now().Format("2006-01-02 15:04:05"), " err:", err)
-// 				sub, err = fAbi.WatchTransfer(nil, sink, nil, nil)
-// 				if err != nil {
-// 					return xerrors.Errorf("WatchTransfer err:%s", err.Error())
-// 				}
-// 			}
-// 		case tr := <-sink:
-// 			log.Debugf("from:%s,to:%s,value:%d, RawTxHash:%s,RawBlockNumber:%d, Removed:%v \n", tr.From.String(), tr.To.Hex(), tr.Value, tr.Raw.TxHash.String(), tr.Raw.BlockNumber, tr.Raw.Removed)
-// 			if !tr.Raw.Removed {
-// 				m.notification.Pub(&types.FvmTransferWatch{
-// 					TxHash: tr.Raw.TxHash.Hex(),
-// 					From:   tr.From.Hex(),
-// 					To:     tr.To.Hex(),
-// 					Value:  tr.Value.String(),
-// 				}, types.EventFvmTransferWatch.String())
-// 			}
-// 		}
-// 	}
-// }
+const (
+	checkFvmInterval     = 10 * time.Second
+	fvmReconnectInterval = 30 * time.Second
+	// fvmBlockLimit is the number of blocks filtered at a time
+	fvmBlockLimit = 500
+)
+
+// fvmDepositEnabled checks if the FEVM token deposits are configured.
+func (m *Manager) fvmDepositEnabled() bool {
+	return m.cfg.FvmToken.Enabled && m.cfg.LotusWsAddr != "" && m.fvmTokenAddr() != ""
+}
+
+// fvmTokenAddr returns the contract address of the FEVM deposit token.
+func (m *Manager) fvmTokenAddr() string {
+	if m.cfg.FvmToken.ContractAddr != "" {
+		return m.cfg.FvmToken.ContractAddr
+	}
+
+	return m.cfg.TitanContractorAddr
+}
+
+// watchFvmTransactions continuously filters the FEVM token transfers to the payment addresses,
+// the node is dialed again if it fails.
+func (m *Manager) watchFvmTransactions() {
+	for {
+		err := m.filterFvmTransactions()
+		if err != nil {
+			log.Errorf("filterFvmTransactions err:%s", err.Error())
+		}
+
+		time.Sleep(fvmReconnectInterval)
+	}
+}
+
+// filterFvmTransactions filters the FEVM token transfers from the persisted height until the node fails.
+func (m *Manager) filterFvmTransactions() error {
+	client, err := ethclient.Dial(m.cfg.LotusWsAddr)
+	if err != nil {
+		return xerrors.Errorf("Dial err:%s", err.Error())
+	}
+	defer client.Close()
+
+	filterer, err := filecoinbridge.NewFvmFilterer(common.HexToAddress(m.fvmTokenAddr()), client)
+	if err != nil {
+		return xerrors.Errorf("NewFvmFilterer err:%s", err.Error())
+	}
+
+	height := uint64(0)
+	heightStr := ""
+
+	err = m.LoadConfigValue(db.ConfigFvmHeight, &heightStr)
+	if err == nil {
+		i, err := strconv.ParseUint(heightStr, 10, 64)
+		if err == nil {
+			height = i
+		}
+	}
+
+	ticker := time.NewTicker(checkFvmInterval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		next, events, err := m.handleFvmBlocks(client, filterer, height)
+		if err != nil {
+			return err
+		}
+
+		if next == height {
+			continue
+		}
+
+		// the blocks are filtered again if their deposits are not saved
+		err = m.SaveOutboxEventsWithConfig(events, db.ConfigFvmHeight, strconv.FormatUint(next, 10))
+		if err != nil {
+			log.Errorf("SaveOutboxEventsWithConfig err:%s", err.Error())
+			continue
+		}
+		height = next
+	}
+}
+
+// handleFvmBlocks handles the token transfers of the confirmed blocks after a height
+// and returns the height the next blocks are filtered from with the events of the deposits.
+func (m *Manager) handleFvmBlocks(chain evmChain, filterer *filecoinbridge.FvmFilterer, height uint64) (uint64, []*types.OutboxEvent, error) {
+	confirmed, err := confirmedHead(chain, m.cfg.FvmConfirmations)
+	if err != nil {
+		return height, nil, err
+	}
+
+	if height == 0 {
+		return confirmed, nil, nil
+	}
+
+	if confirmed <= height {
+		return height, nil, nil
+	}
+
+	end := height + fvmBlockLimit
+	if end > confirmed {
+		end = confirmed
+	}
+
+	iter, err := filterer.FilterTransfer(&bind.FilterOpts{Start: height + 1, End: &end}, nil, nil)
+	if err != nil {
+		return height, nil, xerrors.Errorf("FilterTransfer err:%s", err.Error())
+	}
+	defer iter.Close()
+
+	var events []*types.OutboxEvent
+	for iter.Next() {
+		if event := m.handleFvmTransfer(iter.Event); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	if iter.Error() != nil {
+		return height, nil, xerrors.Errorf("FilterTransfer err:%s", iter.Error().Error())
+	}
+
+	return end, events, nil
+}
+
+// handleFvmTransfer returns the event of a token transfer to a payment address.
+func (m *Manager) handleFvmTransfer(tr *filecoinbridge.FvmTransfer) *types.OutboxEvent {
+	if tr.Raw.Removed {
+		return nil
+	}
+
+	userI, exist := m.fvmAddrs.Load(tr.To.Hex())
+	if !exist || userI == nil {
+		return nil
+	}
+
+	txHash := tr.Raw.TxHash.Hex()
+	token := m.cfg.FvmToken
+	amount := tr.Value.String()
+
+	if belowMinDeposit(token, amount) {
+		log.Warnf("deposit %s of %s %s is below the minimum deposit %s", txHash, amount, token.Symbol, token.MinDeposit)
+		return nil
+	}
+
+	value, err := normalizeAmount(amount, token.Decimals)
+	if err != nil {
+		log.Errorf("%s normalizeAmount err:%s", txHash, err.Error())
+		return nil
+	}
+
+	event, err := newChainEvent(&types.ChainEvent{Type: types.ChainEventFvmTransfer, FvmTransfer: &types.FvmTransferWatch{
+		TxHash:      txHash,
+		LogIndex:    tr.Raw.Index,
+		From:        tr.From.Hex(),
+		To:          tr.To.Hex(),
+		Value:       value,
+		UserID:      userI.(string),
+		Token:       token.Symbol,
+		RawValue:    amount,
+		BlockNumber: int64(tr.Raw.BlockNumber),
+		BlockHash:   tr.Raw.BlockHash.Hex(),
+	}}, fmt.Sprintf("%s-%d", txHash, tr.Raw.Index))
+	if err != nil {
+		log.Errorf("%s newChainEvent err:%s", txHash, err.Error())
+		return nil
+	}
+
+	return event
+}
 
 func (m *Manager) SendMsg(info filecoinbridge.IpcOrderInfo) error {
 	client, err := ethclient.Dial(m.cfg.LotusWsAddr)
@@ -0,0 +1,111 @@
+package transaction
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/lib/filecoinbridge"
+	"github.com/LMF709268224/titan-vps/node/config"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// transferEmitterCode deploys a contract which emits Transfer(msg.sender, to, value) for the call data (to, value)
+var transferEmitterCode = common.FromHex("0x6032600c60003960326000f360206020600037600035337fddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef60206000a300")
+
+func TestHandleFvmBlocks(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(1e18)}}, 10000000)
+	defer backend.Close()
+
+	parsed, err := filecoinbridge.FvmMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenAddr, _, contract, err := bind.DeployContract(auth, *parsed, transferEmitterCode, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend.Commit()
+
+	filterer, err := filecoinbridge.NewFvmFilterer(tokenAddr, backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payment := common.HexToAddress("0x1000000000000000000000000000000000000001")
+	other := common.HexToAddress("0x1000000000000000000000000000000000000002")
+
+	m := &Manager{
+		cfg: config.ChainCfg{
+			FvmToken:         config.TokenCfg{Symbol: "TFIL", Decimals: 18, MinDeposit: "1000000000000", Enabled: true},
+			FvmConfirmations: 2,
+		},
+	}
+	m.addFvmAddr(payment.Hex(), "user1")
+
+	transfer := func(to common.Address, value *big.Int) {
+		data := append(common.LeftPadBytes(to.Bytes(), 32), common.LeftPadBytes(value.Bytes(), 32)...)
+		_, err := contract.RawTransact(auth, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend.Commit()
+	}
+
+	for i := 0; i < 3; i++ {
+		backend.Commit()
+	}
+
+	// the first filter starts at the confirmed head
+	height, _, err := m.handleFvmBlocks(backend, filterer, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer(payment, big.NewInt(1500000000000000000))
+	transfer(other, big.NewInt(2000000000000000000))
+	transfer(payment, big.NewInt(10))
+
+	// only the blocks with enough confirmations are filtered
+	next, list, err := m.handleFvmBlocks(backend, filterer, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next != height+3 {
+		t.Fatalf("filtered to %d, want %d", next, height+3)
+	}
+
+	for i := 0; i < 2; i++ {
+		backend.Commit()
+	}
+
+	last, more, err := m.handleFvmBlocks(backend, filterer, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if last != height+5 {
+		t.Fatalf("filtered to %d, want %d", last, height+5)
+	}
+
+	events := decodeChainEvents(t, append(list, more...))
+	if len(events) != 1 {
+		t.Fatalf("published %d transfers, want 1", len(events))
+	}
+
+	tr := events[0].FvmTransfer
+	if tr == nil || tr.UserID != "user1" || tr.Value != "1500000" || tr.RawValue != "1500000000000000000" || tr.From != auth.From.Hex() {
+		t.Errorf("unexpected transfer %+v", events[0])
+	}
+}
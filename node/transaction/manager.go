@@ -1,14 +1,17 @@
 package transaction
 
 import (
+	"crypto/ecdsa"
+	"sort"
 	"sync"
 
 	"github.com/LMF709268224/titan-vps/api"
 	"github.com/LMF709268224/titan-vps/api/terrors"
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/lib/trxbridge"
 	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/db"
-	"github.com/LMF709268224/titan-vps/node/modules/dtypes"
-	"github.com/filecoin-project/pubsub"
+	"github.com/btcsuite/btcutil/hdkeychain"
 	logging "github.com/ipfs/go-log/v2"
 )
 
@@ -16,36 +19,121 @@ var log = logging.Logger("transaction")
 
 // Manager is responsible for managing tron addresses.
 type Manager struct {
-	notification *pubsub.PubSub
 	*db.SQLDB
 
-	cfg config.MallCfg
+	cfg config.ChainCfg
 
-	tronAddrs sync.Map
+	tronPool     *trxbridge.Pool
+	tronAddrs    sync.Map
+	tronXpub     *hdkeychain.ExtendedKey
+	sweepXprv    *hdkeychain.ExtendedKey
+	sweepFeeKey  *ecdsa.PrivateKey
+	sweepLk      sync.Mutex
+	hotWalletKey *ecdsa.PrivateKey
+	fvmAddrs     sync.Map
+	evmChains    map[string]*evmWatcher
+	trc20Tokens  map[string]config.TokenCfg
+	trxPrice     trxRateCache
+	// the height a pending tron deposit was first found in no block, used by the watcher only
+	tronMissing map[string]int64
+	// the platform addresses paying the deposit addresses, their transfers are not deposits
+	platformAddrs sync.Map
+	// id of the database shared with the mall
+	databaseID string
 }
 
-// NewManager creates a new instance of the transaction manager
-func NewManager(pb *pubsub.PubSub, getCfg dtypes.GetMallConfigFunc, db *db.SQLDB) (*Manager, error) {
-	cfg, err := getCfg()
+// NewManager creates a new instance of the transaction manager, nothing is watched on a stand-in chain
+func NewManager(cfg config.ChainCfg, db *db.SQLDB) (*Manager, error) {
+	manager := &Manager{
+		cfg:         cfg,
+		SQLDB:       db,
+		tronMissing: make(map[string]int64),
+	}
+
+	id, err := db.DatabaseID()
 	if err != nil {
 		return nil, err
 	}
+	manager.databaseID = id
 
-	manager := &Manager{
-		notification: pb,
-		cfg:          cfg,
-		SQLDB:        db,
+	if cfg.StandIn {
+		log.Warn("the chain is a local stand-in, deposits are simulated")
+
+		manager.initTronAddress(cfg.RechargeAddresses)
+		manager.initFvmAddress(cfg.PaymentAddresses)
+		return manager, nil
+	}
+
+	err = manager.initTronPool()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.TrxXpub != "" {
+		manager.tronXpub, err = parseTronXpub(cfg.TrxXpub)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = manager.initSweeper()
+	if err != nil {
+		return nil, err
 	}
 
 	manager.initTronAddress(cfg.RechargeAddresses)
+	manager.initTrc20Tokens(cfg.TrxTokens)
+
+	err = manager.initWithdrawExecutor()
+	if err != nil {
+		return nil, err
+	}
 
 	go manager.watchTronTransactions()
 
+	manager.initEvmChains(cfg.EvmChains)
+
+	if manager.fvmDepositEnabled() {
+		manager.initFvmAddress(cfg.PaymentAddresses)
+
+		go manager.watchFvmTransactions()
+	}
+
 	return manager, nil
 }
 
-// AllocateTronAddress allocates a Tron address for a user.
+// ChainInfo describes the chains served by the manager.
+func (m *Manager) ChainInfo() *types.ChainInfo {
+	chains := make([]string, 0, len(m.evmChains))
+	for name := range m.evmChains {
+		chains = append(chains, name)
+	}
+	sort.Strings(chains)
+
+	return &types.ChainInfo{
+		StandIn:          m.cfg.StandIn,
+		EvmChains:        chains,
+		WithdrawEnabled:  m.WithdrawEnabled(),
+		WithdrawContract: m.WithdrawContract(),
+		DatabaseID:       m.databaseID,
+	}
+}
+
+// AllocateTronAddress allocates a Tron address for a user, it is derived from the configured extended public key
+// or taken from the address pool.
 func (m *Manager) AllocateTronAddress(userID string) (string, error) {
+	if m.tronXpub != nil {
+		addr, err := m.AssignDerivedRechargeAddress(userID, func(index int64) (string, error) {
+			return deriveTronAddress(m.tronXpub, index)
+		})
+		if err != nil {
+			return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+		}
+		m.addTronAddr(addr, userID)
+
+		return addr, nil
+	}
+
 	addr, err := m.LoadUnusedRechargeAddress()
 	if err != nil {
 		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
@@ -62,3 +150,22 @@ func (m *Manager) AllocateTronAddress(userID string) (string, error) {
 
 	return addr, nil
 }
+
+// AllocateFvmAddress allocates a FEVM payment address for a user.
+func (m *Manager) AllocateFvmAddress(userID string) (string, error) {
+	addr, err := m.LoadUnusedPaymentAddress()
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+	if addr == "" {
+		return "", &api.ErrWeb{Code: terrors.NotFoundAddress.Int(), Message: terrors.NotFoundAddress.String()}
+	}
+
+	err = m.AssignUserToPaymentAddress(addr, userID)
+	if err != nil {
+		return "", &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+	m.addFvmAddr(addr, userID)
+
+	return addr, nil
+}
@@ -0,0 +1,38 @@
+package transaction
+
+import (
+	"math/big"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// SimulateDeposit publishes a confirmed deposit of a user on the stand-in chain and returns its transaction hash,
+// the value is in the settlement currency.
+func (m *Manager) SimulateDeposit(userID, value string) (string, error) {
+	if !m.cfg.StandIn {
+		return "", xerrors.New("deposits are only simulated on a stand-in chain")
+	}
+
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok || v.Sign() <= 0 {
+		return "", xerrors.Errorf("invalid value %s", value)
+	}
+
+	txHash := uuid.NewString()
+	err := m.publishChainEvent(&types.ChainEvent{Type: types.ChainEventEvmTransfer, EvmTransfer: &types.EvmTransferWatch{
+		Chain:    types.ChainStandIn,
+		TxHash:   txHash,
+		From:     types.ChainStandIn,
+		Value:    v.String(),
+		UserID:   userID,
+		Token:    types.ChainStandIn,
+		RawValue: v.String(),
+	}}, txHash)
+	if err != nil {
+		return "", err
+	}
+
+	return txHash, nil
+}
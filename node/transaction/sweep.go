@@ -0,0 +1,372 @@
+package transaction
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/lib/trxbridge"
+	"github.com/LMF709268224/titan-vps/lib/trxbridge/core"
+	"github.com/btcsuite/btcutil/hdkeychain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/smirkcat/hdwallet"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// trc20 balanceOf(address) and transfer(address,uint256) selectors
+	balanceOfSelector = "70a08231"
+	transferSelector  = "a9059cbb"
+)
+
+// initSweeper loads the keys of the sweeper, the extended private key must match the extended public key
+// the deposit addresses are derived from.
+func (m *Manager) initSweeper() error {
+	cfg := m.cfg.TrxSweep
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if m.tronXpub == nil {
+		return xerrors.New("TrxSweep needs the deposit addresses derived from TrxXpub")
+	}
+
+	xprv, err := hdkeychain.NewKeyFromString(cfg.Xprv)
+	if err != nil {
+		return xerrors.Errorf("parse TrxSweep.Xprv err:%s", err.Error())
+	}
+
+	xpub, err := xprv.Neuter()
+	if err != nil {
+		return err
+	}
+
+	if !xprv.IsPrivate() || xpub.String() != m.tronXpub.String() {
+		return xerrors.New("TrxSweep.Xprv does not match TrxXpub")
+	}
+
+	feeKey, err := crypto.HexToECDSA(cfg.FeeKey)
+	if err != nil {
+		return xerrors.Errorf("parse TrxSweep.FeeKey err:%s", err.Error())
+	}
+
+	if _, err := hdwallet.DecodeCheck(cfg.CollectionAddr); err != nil {
+		return xerrors.Errorf("invalid TrxSweep.CollectionAddr %s", cfg.CollectionAddr)
+	}
+
+	if _, ok := new(big.Int).SetString(cfg.Threshold, 10); !ok {
+		return xerrors.Errorf("invalid TrxSweep.Threshold %s", cfg.Threshold)
+	}
+
+	m.sweepXprv = xprv
+	m.sweepFeeKey = feeKey
+	// the fee top-ups paid to the deposit addresses
+	m.platformAddrs.Store(hdwallet.PubkeyToAddressTron(feeKey.PublicKey), struct{}{})
+
+	go m.cronSweepDepositAddresses()
+
+	return nil
+}
+
+// sweepContract returns the contract address of the swept token.
+func (m *Manager) sweepContract() string {
+	if m.cfg.TrxSweep.ContractAddr != "" {
+		return m.cfg.TrxSweep.ContractAddr
+	}
+
+	return m.cfg.TrxContractorAddr
+}
+
+func (m *Manager) cronSweepDepositAddresses() {
+	interval := time.Duration(m.cfg.TrxSweep.Interval)
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		if !m.sweepLk.TryLock() {
+			continue
+		}
+
+		m.sweepDepositAddresses()
+		m.sweepLk.Unlock()
+	}
+}
+
+// SweepDepositAddresses starts sweeping the deposit addresses, it fails if a sweep is running.
+func (m *Manager) SweepDepositAddresses() error {
+	if m.sweepXprv == nil {
+		return xerrors.New("sweeping is not configured")
+	}
+
+	if !m.sweepLk.TryLock() {
+		return xerrors.New("a sweep is running")
+	}
+
+	go func() {
+		defer m.sweepLk.Unlock()
+		m.sweepDepositAddresses()
+	}()
+
+	return nil
+}
+
+// sweepDepositAddresses tracks the sent sweeps and sweeps the deposit addresses holding at least the threshold.
+func (m *Manager) sweepDepositAddresses() {
+	client, err := m.TronPool()
+	if err != nil {
+		log.Errorf("TronPool err:%s", err.Error())
+		return
+	}
+
+	block, err := client.GetNowBlock()
+	if err != nil {
+		log.Errorf("GetNowBlock err:%s", err.Error())
+		return
+	}
+	nowHeight := block.BlockHeader.RawData.Number
+
+	addrs, err := m.LoadDerivedRechargeAddresses()
+	if err != nil {
+		log.Errorf("LoadDerivedRechargeAddresses err:%s", err.Error())
+		return
+	}
+
+	indexes := make(map[string]int64, len(addrs))
+	for _, addr := range addrs {
+		indexes[addr.Addr] = addr.Index
+	}
+
+	open, err := m.LoadOpenSweepRecords()
+	if err != nil {
+		log.Errorf("LoadOpenSweepRecords err:%s", err.Error())
+		return
+	}
+
+	busy := make(map[string]bool, len(open))
+	for _, info := range open {
+		busy[info.Addr] = true
+
+		m.trackSweep(client, info, indexes, nowHeight)
+	}
+
+	for _, addr := range addrs {
+		if busy[addr.Addr] {
+			continue
+		}
+
+		m.sweepDepositAddress(client, addr)
+	}
+}
+
+// sweepDepositAddress tops up a deposit address holding at least the threshold or transfers its tokens to the collection address.
+func (m *Manager) sweepDepositAddress(client *trxbridge.Pool, addr *types.DerivedAddress) {
+	cfg := m.cfg.TrxSweep
+	contract := m.sweepContract()
+
+	key, err := m.sweepKey(addr.Addr, addr.Index)
+	if err != nil {
+		log.Errorf("%s sweepKey err:%s", addr.Addr, err.Error())
+		return
+	}
+
+	balance, err := trc20Balance(client, key, contract, addr.Addr)
+	if err != nil {
+		log.Errorf("%s trc20Balance err:%s", addr.Addr, err.Error())
+		return
+	}
+
+	threshold, _ := new(big.Int).SetString(cfg.Threshold, 10)
+	if balance.Sign() == 0 || balance.Cmp(threshold) < 0 {
+		return
+	}
+
+	info := &types.SweepRecord{Addr: addr.Addr, ContractAddr: contract, Amount: balance.String()}
+
+	trx := int64(0)
+	account, err := client.GetAccount(addr.Addr)
+	if err == nil {
+		trx = account.GetBalance()
+	}
+
+	if trx < cfg.TopUp {
+		info.State = types.SweepTopUp
+		info.TopUpHash, err = client.Transfer(m.sweepFeeKey, addr.Addr, cfg.TopUp-trx)
+	} else {
+		info.State = types.SweepSent
+		info.TxHash, err = transferTrc20(client, key, contract, cfg.CollectionAddr, balance, cfg.FeeLimit)
+	}
+
+	if err != nil {
+		log.Errorf("%s sweep err:%s", addr.Addr, err.Error())
+		info.State = types.SweepFailed
+		info.Msg = err.Error()
+	}
+
+	_, err = m.SaveSweepRecord(info)
+	if err != nil {
+		log.Errorf("%s SaveSweepRecord err:%s", addr.Addr, err.Error())
+	}
+}
+
+// trackSweep sends the token transfer of a confirmed top up and completes a confirmed token transfer.
+func (m *Manager) trackSweep(client *trxbridge.Pool, info *types.SweepRecord, indexes map[string]int64, nowHeight int64) {
+	hash := info.TxHash
+	if info.State == types.SweepTopUp {
+		hash = info.TopUpHash
+	}
+
+	confirmed, success, err := m.tronTransactionResult(client, hash, nowHeight)
+	if err != nil {
+		log.Errorf("%s tronTransactionResult err:%s", hash, err.Error())
+		return
+	}
+
+	if !confirmed {
+		return
+	}
+
+	if !success {
+		err = m.UpdateSweepRecord(info.ID, types.SweepFailed, info.State, "", "transaction "+hash+" failed")
+		if err != nil {
+			log.Errorf("%d UpdateSweepRecord err:%s", info.ID, err.Error())
+		}
+		return
+	}
+
+	if info.State == types.SweepSent {
+		err = m.UpdateSweepRecord(info.ID, types.SweepDone, types.SweepSent, "", "")
+		if err != nil {
+			log.Errorf("%d UpdateSweepRecord err:%s", info.ID, err.Error())
+		}
+		return
+	}
+
+	state, txHash, msg := types.SweepSent, "", ""
+
+	index, exist := indexes[info.Addr]
+	key, err := m.sweepKey(info.Addr, index)
+	if !exist || err != nil {
+		state, msg = types.SweepFailed, "deposit address key not found"
+	} else {
+		amount, _ := new(big.Int).SetString(info.Amount, 10)
+		txHash, err = transferTrc20(client, key, info.ContractAddr, m.cfg.TrxSweep.CollectionAddr, amount, m.cfg.TrxSweep.FeeLimit)
+		if err != nil {
+			state, msg = types.SweepFailed, err.Error()
+		}
+	}
+
+	err = m.UpdateSweepRecord(info.ID, state, types.SweepTopUp, txHash, msg)
+	if err != nil {
+		log.Errorf("%d UpdateSweepRecord err:%s", info.ID, err.Error())
+	}
+}
+
+// sweepKey derives the private key of a deposit address.
+func (m *Manager) sweepKey(addr string, index int64) (*ecdsa.PrivateKey, error) {
+	if index < 0 || index >= hdkeychain.HardenedKeyStart {
+		return nil, xerrors.Errorf("invalid derive index %d", index)
+	}
+
+	child, err := m.sweepXprv.Derive(uint32(index))
+	if err != nil {
+		return nil, err
+	}
+
+	priv, err := child.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := priv.ToECDSA()
+	if hdwallet.PrikeyToAddressTron(key) != addr {
+		return nil, xerrors.Errorf("key of index %d does not match %s", index, addr)
+	}
+
+	return key, nil
+}
+
+// tronTransactionResult checks if a transaction has enough confirmations and succeeded.
+func (m *Manager) tronTransactionResult(client *trxbridge.Pool, txID string, nowHeight int64) (bool, bool, error) {
+	info, err := client.GetTransactionInfoByID(txID)
+	if err != nil {
+		return false, false, err
+	}
+
+	if info.GetBlockNumber() == 0 || nowHeight-info.GetBlockNumber() < m.tronConfirmations() {
+		return false, false, nil
+	}
+
+	return true, tronTransactionSucceeded(info), nil
+}
+
+// tronConfirmations returns the number of blocks after which a tron transaction is confirmed.
+func (m *Manager) tronConfirmations() int64 {
+	if m.cfg.TrxConfirmations <= 0 {
+		return defaultTronConfirmations
+	}
+
+	return m.cfg.TrxConfirmations
+}
+
+// tronTransactionSucceeded checks if an included transaction and its contract call succeeded.
+func tronTransactionSucceeded(info *core.TransactionInfo) bool {
+	receipt := info.GetReceipt().GetResult()
+	return info.GetResult() == core.TransactionInfo_SUCESS &&
+		(receipt == core.Transaction_Result_DEFAULT || receipt == core.Transaction_Result_SUCCESS)
+}
+
+// trc20Balance returns the token balance of an address.
+func trc20Balance(client *trxbridge.Pool, key *ecdsa.PrivateKey, contract, addr string) (*big.Int, error) {
+	owner, err := hdwallet.DecodeCheck(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	data := append(common.FromHex(balanceOfSelector), common.LeftPadBytes(owner[1:], 32)...)
+	result, err := client.GetConstantResultOfContract(key, contract, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, xerrors.Errorf("balanceOf of %s returned no result", contract)
+	}
+
+	return new(big.Int).SetBytes(result[0]), nil
+}
+
+// transferTrc20 transfers tokens to an address and returns the transaction id.
+func transferTrc20(client *trxbridge.Pool, key *ecdsa.PrivateKey, contract, to string, amount *big.Int, feeLimit int64) (string, error) {
+	data, err := trc20TransferData(to, amount)
+	if err != nil {
+		return "", err
+	}
+
+	return client.TransferContract(key, contract, data, feeLimit)
+}
+
+// trc20TransferData encodes the call data of a token transfer.
+func trc20TransferData(to string, amount *big.Int) ([]byte, error) {
+	addr, err := hdwallet.DecodeCheck(to)
+	if err != nil {
+		return nil, err
+	}
+
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, xerrors.New("invalid transfer amount")
+	}
+
+	data := common.FromHex(transferSelector)
+	data = append(data, common.LeftPadBytes(addr[1:], 32)...)
+	data = append(data, common.LeftPadBytes(amount.Bytes(), 32)...)
+
+	return data, nil
+}
@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/lib/trxbridge/core"
+	"github.com/LMF709268224/titan-vps/node/config"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/smirkcat/hdwallet"
+)
+
+func TestTrc20TransferData(t *testing.T) {
+	data, err := trc20TransferData("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", big.NewInt(1000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "a9059cbb" +
+		"000000000000000000000000a614f803b6fd780986a42c78ec9c7f77e6ded13c" +
+		"00000000000000000000000000000000000000000000000000000000000f4240"
+	if got := hex.EncodeToString(data); got != want {
+		t.Errorf("transfer data is %s, want %s", got, want)
+	}
+
+	if _, err := trc20TransferData("TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", big.NewInt(0)); err == nil {
+		t.Errorf("trc20TransferData should fail with a zero amount")
+	}
+
+	if _, err := trc20TransferData("invalid", big.NewInt(1)); err == nil {
+		t.Errorf("trc20TransferData should fail with an invalid address")
+	}
+}
+
+func TestTopUpIsNotDeposit(t *testing.T) {
+	feeKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	feeAddr := hdwallet.PubkeyToAddressTron(feeKey.PublicKey)
+
+	depositAddr := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+
+	m := &Manager{}
+	m.tronAddrs.Store(depositAddr, "user1")
+	m.platformAddrs.Store(feeAddr, struct{}{})
+
+	usdt := config.TokenCfg{ContractAddr: "TXLAQ63Xg1NAzckPwKHvzw7CSEmLMEqcdj", Symbol: "USDT", Decimals: 6}
+
+	if tr := m.handleTransfer("top-up", feeAddr, depositAddr, trxToken, "1000000", core.Transaction_Result_SUCCESS, 1, "0x01"); tr != nil {
+		t.Errorf("the fee top-up is handled as a deposit of %s", tr.Value)
+	}
+
+	if tr := m.handleTransfer("payout", feeAddr, depositAddr, usdt, "1000000", core.Transaction_Result_SUCCESS, 1, "0x01"); tr != nil {
+		t.Errorf("the platform transfer is handled as a deposit of %s", tr.Value)
+	}
+
+	tr := m.handleTransfer("deposit", "TJRabPrwbZy45sbavfcjinPJC18kjpRTv8", depositAddr, usdt, "1000000", core.Transaction_Result_SUCCESS, 1, "0x01")
+	if tr == nil || tr.UserID != "user1" {
+		t.Fatalf("the user transfer is not handled as a deposit")
+	}
+}
@@ -0,0 +1,86 @@
+package transaction
+
+import (
+	"math/big"
+
+	"github.com/LMF709268224/titan-vps/node/config"
+	"golang.org/x/xerrors"
+)
+
+// settlementDecimals is the number of decimals of the balance values
+const settlementDecimals = 6
+
+// initTrc20Tokens loads the enabled deposit tokens,
+// the configured contract is accepted as USDT if no token is configured.
+func (m *Manager) initTrc20Tokens(tokens []config.TokenCfg) {
+	if len(tokens) == 0 && m.cfg.TrxContractorAddr != "" {
+		tokens = []config.TokenCfg{{
+			ContractAddr: m.cfg.TrxContractorAddr,
+			Symbol:       "USDT",
+			Decimals:     settlementDecimals,
+			Enabled:      true,
+		}}
+	}
+
+	m.trc20Tokens = make(map[string]config.TokenCfg)
+	for _, token := range tokens {
+		if !token.Enabled {
+			continue
+		}
+
+		m.trc20Tokens[token.ContractAddr] = token
+	}
+}
+
+// normalizeAmount converts a raw token amount to the settlement currency,
+// the digits beyond the settlement decimals are dropped.
+func normalizeAmount(raw string, decimals int64) (string, error) {
+	v, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return "0", xerrors.Errorf("invalid token amount %s", raw)
+	}
+
+	if decimals > settlementDecimals {
+		v.Quo(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(decimals-settlementDecimals), nil))
+	} else if decimals < settlementDecimals {
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(settlementDecimals-decimals), nil))
+	}
+
+	return v.String(), nil
+}
+
+// belowMinDeposit checks if a raw token amount is below the minimum deposit of the token.
+func belowMinDeposit(token config.TokenCfg, raw string) bool {
+	if token.MinDeposit == "" {
+		return false
+	}
+
+	min, ok := new(big.Int).SetString(token.MinDeposit, 10)
+	if !ok {
+		return false
+	}
+
+	v, ok := new(big.Int).SetString(raw, 10)
+	return !ok || v.Cmp(min) < 0
+}
+
+// denormalizeAmount converts a settlement amount to a raw token amount.
+func denormalizeAmount(value string, decimals int64) (*big.Int, error) {
+	v, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, xerrors.Errorf("invalid amount %s", value)
+	}
+
+	if decimals > settlementDecimals {
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(decimals-settlementDecimals), nil))
+	} else if decimals < settlementDecimals {
+		unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(settlementDecimals-decimals), nil)
+		if new(big.Int).Rem(v, unit).Sign() != 0 {
+			return nil, xerrors.Errorf("amount %s has more than %d decimals", value, decimals)
+		}
+
+		v.Quo(v, unit)
+	}
+
+	return v, nil
+}
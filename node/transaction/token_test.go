@@ -0,0 +1,85 @@
+package transaction
+
+import (
+	"testing"
+
+	"github.com/LMF709268224/titan-vps/node/config"
+)
+
+func TestNormalizeAmount(t *testing.T) {
+	cases := []struct {
+		raw      string
+		decimals int64
+		want     string
+	}{
+		{"1500000", 6, "1500000"},
+		{"1500000000000000000", 18, "1500000"},
+		{"1999999999999", 18, "1"},
+		{"15", 1, "1500000"},
+	}
+
+	for _, c := range cases {
+		got, err := normalizeAmount(c.raw, c.decimals)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got != c.want {
+			t.Errorf("normalizeAmount(%s, %d) = %s, want %s", c.raw, c.decimals, got, c.want)
+		}
+	}
+
+	if _, err := normalizeAmount("1.5", 6); err == nil {
+		t.Errorf("normalizeAmount should fail with an invalid amount")
+	}
+}
+
+func TestDenormalizeAmount(t *testing.T) {
+	cases := []struct {
+		value    string
+		decimals int64
+		want     string
+	}{
+		{"1500000", 6, "1500000"},
+		{"1500000", 18, "1500000000000000000"},
+		{"1500000", 1, "15"},
+	}
+
+	for _, c := range cases {
+		got, err := denormalizeAmount(c.value, c.decimals)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.String() != c.want {
+			t.Errorf("denormalizeAmount(%s, %d) = %s, want %s", c.value, c.decimals, got, c.want)
+		}
+	}
+
+	if _, err := denormalizeAmount("1500001", 1); err == nil {
+		t.Errorf("denormalizeAmount should fail with an amount it cannot pay exactly")
+	}
+}
+
+func TestBelowMinDeposit(t *testing.T) {
+	token := config.TokenCfg{MinDeposit: "1000000"}
+
+	if !belowMinDeposit(token, "999999") || belowMinDeposit(token, "1000000") {
+		t.Errorf("unexpected minimum deposit check")
+	}
+}
+
+func TestConvertAmount(t *testing.T) {
+	got, err := convertAmount("12500000", "0.0812")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "1015000" {
+		t.Errorf("convertAmount = %s, want 1015000", got)
+	}
+
+	if _, err := convertAmount("12500000", "0"); err == nil {
+		t.Errorf("convertAmount should fail with a zero rate")
+	}
+}
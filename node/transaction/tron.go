@@ -1,6 +1,8 @@
 package transaction
 
 import (
+	"context"
+	"fmt"
 	"math/big"
 	"strconv"
 	"time"
@@ -10,24 +12,109 @@ import (
 	"github.com/LMF709268224/titan-vps/lib/trxbridge/api"
 	"github.com/LMF709268224/titan-vps/lib/trxbridge/core"
 	"github.com/LMF709268224/titan-vps/lib/trxbridge/hexutil"
+	"github.com/LMF709268224/titan-vps/metrics"
+	"github.com/LMF709268224/titan-vps/node/config"
 	"github.com/LMF709268224/titan-vps/node/db"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/smirkcat/hdwallet"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
 	"golang.org/x/xerrors"
 	"google.golang.org/protobuf/proto"
 )
 
-const checkBlockInterval = 3 * time.Second
+const (
+	checkBlockInterval = 3 * time.Second
 
-// getGrpcClient returns a new gRPC client for Tron communication.
-func (m *Manager) getGrpcClient() (*trxbridge.GrpcClient, error) {
-	node := trxbridge.NewGrpcClient(m.cfg.TrxHTTPSAddr)
-	err := node.Start()
+	// defaultTronConfirmations is the number of blocks after which a tron block is solidified
+	defaultTronConfirmations = 19
+	// defaultTronOrphanBlocks is the number of blocks a pending deposit stays out of the chain before it is orphaned
+	defaultTronOrphanBlocks = 200
+)
+
+// initTronPool connects to the tron nodes shared by the tron consumers, TrxHTTPSAddr is the only full node if TrxNodes is empty.
+func (m *Manager) initTronPool() error {
+	nodes := m.cfg.TrxNodes
+	if len(nodes) == 0 && m.cfg.TrxHTTPSAddr != "" {
+		nodes = []string{m.cfg.TrxHTTPSAddr}
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	cfg := m.cfg.TrxPool
+	pool, err := trxbridge.NewPool(trxbridge.PoolConfig{
+		FullNodes:     nodes,
+		SolidityNodes: m.cfg.TrxSolidityNodes,
+		MaxLag:        cfg.MaxLag,
+		CheckInterval: time.Duration(cfg.CheckInterval),
+		Timeout:       time.Duration(cfg.Timeout),
+		Retries:       cfg.Retries,
+		Backoff:       time.Duration(cfg.Backoff),
+		Report:        recordTronPoolStatus,
+	})
 	if err != nil {
-		return nil, err
+		return xerrors.Errorf("NewPool err:%s", err.Error())
+	}
+
+	m.tronPool = pool
+	return nil
+}
+
+// TronPool returns the shared pool of tron nodes.
+func (m *Manager) TronPool() (*trxbridge.Pool, error) {
+	if m.tronPool == nil {
+		return nil, xerrors.New("no tron node is configured")
+	}
+
+	return m.tronPool, nil
+}
+
+// CheckTronAddress checks if a tron address exists on chain, only its checksum is checked on a stand-in chain.
+func (m *Manager) CheckTronAddress(addr string) error {
+	if m.cfg.StandIn {
+		_, err := hdwallet.DecodeCheck(addr)
+		return err
+	}
+
+	client, err := m.TronPool()
+	if err != nil {
+		return err
 	}
 
-	return node, nil
+	_, err = client.GetAccount(addr)
+	return err
+}
+
+// recordTronPoolStatus records the health of the tron nodes in the metrics.
+func recordTronPoolStatus(list []trxbridge.NodeStatus) {
+	for _, status := range list {
+		if !status.Healthy {
+			log.Warnf("tron %s node %s is unhealthy, height:%d lag:%d err:%s", status.Kind, status.Address, status.Height, status.Lag, status.Err)
+		}
+
+		ctx, err := tag.New(context.Background(),
+			tag.Upsert(metrics.Endpoint, status.Address),
+			tag.Upsert(metrics.TronNodeKind, string(status.Kind)),
+		)
+		if err != nil {
+			log.Errorf("tag.New err:%s", err.Error())
+			continue
+		}
+
+		healthy := int64(0)
+		if status.Healthy {
+			healthy = 1
+		}
+
+		stats.Record(ctx,
+			metrics.TronNodeHealthy.M(healthy),
+			metrics.TronNodeHeight.M(status.Height),
+			metrics.TronNodeLag.M(status.Lag),
+			metrics.TronNodeFailures.M(status.Failures),
+		)
+	}
 }
 
 // watchTronTransactions continuously monitors Tron transactions.
@@ -35,9 +122,9 @@ func (m *Manager) watchTronTransactions() {
 	ticker := time.NewTicker(checkBlockInterval)
 	defer ticker.Stop()
 
-	client, err := m.getGrpcClient()
+	client, err := m.TronPool()
 	if err != nil {
-		log.Errorln("getGrpcClient err :", err.Error())
+		log.Errorln("TronPool err :", err.Error())
 		return
 	}
 
@@ -82,33 +169,45 @@ func (m *Manager) watchTronTransactions() {
 			log.Errorf("GetBlockByLimitNext err:%s \n", err.Error())
 			continue
 		}
-		m.handleBlocks(blockInfo)
+		deposits, events := newTronDeposits(m.handleBlocks(blockInfo))
 
-		startHeight = endHeight
-		str := strconv.FormatInt(startHeight, 10)
-		err = m.SaveConfigValue(db.ConfigTronHeight, str)
+		// the blocks are scanned again if their deposits are not saved
+		str := strconv.FormatInt(endHeight, 10)
+		err = m.SaveTronDepositsWithConfig(deposits, events, db.ConfigTronHeight, str)
 		if err != nil {
-			log.Errorf("SaveConfigValue err:%s \n", err.Error())
+			log.Errorf("SaveTronDepositsWithConfig err:%s \n", err.Error())
+			continue
 		}
+		startHeight = endHeight
+
+		m.confirmTronDeposits(client, nowHeight)
 	}
 }
 
-// handleBlocks processes blocks in the Tron blockchain.
-func (m *Manager) handleBlocks(blockInfo *api.BlockListExtention) {
+// handleBlocks processes blocks in the Tron blockchain and returns the transfers of their deposits.
+func (m *Manager) handleBlocks(blockInfo *api.BlockListExtention) []*types.TronTransferWatch {
+	var transfers []*types.TronTransferWatch
 	for _, v := range blockInfo.Block {
-		err := m.handleBlock(v)
+		list, err := m.handleBlock(v)
 		if err != nil {
 			log.Errorln(" handleBlock err :", err.Error())
 		}
+		transfers = append(transfers, list...)
 	}
+
+	return transfers
 }
 
 // handleBlock processes an individual block in the Tron blockchain.
-func (m *Manager) handleBlock(blockExtention *api.BlockExtention) error {
+func (m *Manager) handleBlock(blockExtention *api.BlockExtention) ([]*types.TronTransferWatch, error) {
 	if blockExtention == nil || blockExtention.BlockHeader == nil {
-		return xerrors.New("block is nil")
+		return nil, xerrors.New("block is nil")
 	}
 
+	blockNum := blockExtention.BlockHeader.RawData.Number
+	blockHash := hexutil.Encode(blockExtention.Blockid)
+
+	var transfers []*types.TronTransferWatch
 	for _, te := range blockExtention.Transactions {
 		if len(te.Transaction.GetRet()) == 0 {
 			continue
@@ -120,29 +219,48 @@ func (m *Manager) handleBlock(blockExtention *api.BlockExtention) error {
 		// userAddr := string(te.Transaction.RawData.Data)
 
 		for _, contract := range te.Transaction.RawData.Contract {
-			m.filterTransaction(contract, txID, state)
+			if tr := m.filterTransaction(contract, txID, state, blockNum, blockHash); tr != nil {
+				transfers = append(transfers, tr)
+			}
 		}
 	}
 
-	return nil
+	return transfers, nil
 }
 
-// filterTransaction filters and processes Tron transactions.
-func (m *Manager) filterTransaction(contract *core.Transaction_Contract, txID string, state core.Transaction_ResultContractResult) {
+// filterTransaction filters and processes Tron transactions, the transfer of a deposit is returned.
+func (m *Manager) filterTransaction(contract *core.Transaction_Contract, txID string, state core.Transaction_ResultContractResult, blockNum int64, blockHash string) *types.TronTransferWatch {
+	if contract.Type == core.Transaction_Contract_TransferContract {
+		// trx
+		unObj := &core.TransferContract{}
+		err := proto.Unmarshal(contract.Parameter.GetValue(), unObj)
+		if err != nil {
+			// log.Errorf("parse trx err: %s", err.Error())
+			return nil
+		}
+
+		from := hdwallet.EncodeCheck(unObj.GetOwnerAddress())
+		to := hdwallet.EncodeCheck(unObj.GetToAddress())
+		amount := strconv.FormatInt(unObj.GetAmount(), 10)
+
+		return m.handleTransfer(txID, from, to, trxToken, amount, state, blockNum, blockHash)
+	}
+
 	if contract.Type == core.Transaction_Contract_TriggerSmartContract {
 		// trc20
 		unObj := &core.TriggerSmartContract{}
 		err := proto.Unmarshal(contract.Parameter.GetValue(), unObj)
 		if err != nil {
 			// log.Errorf("parse trc20 err: %s", err.Error())
-			return
+			return nil
 		}
 
 		contractAddress := hdwallet.EncodeCheck(unObj.GetContractAddress())
 
-		if contractAddress != m.cfg.TrxContractorAddr {
+		token, exist := m.trc20Tokens[contractAddress]
+		if !exist {
 			// log.Errorf("contractAddress err: %s", contractAddress)
-			return
+			return nil
 		}
 
 		from := hdwallet.EncodeCheck(unObj.GetOwnerAddress())
@@ -151,11 +269,13 @@ func (m *Manager) filterTransaction(contract *core.Transaction_Contract, txID st
 		to, amount, isOk := m.decodeData(data)
 		if !isOk {
 			// log.Errorf("decodeData err: %s", txID)
-			return
+			return nil
 		}
 
-		m.handleTransfer(txID, from, to, amount, state)
+		return m.handleTransfer(txID, from, to, token, amount, state, blockNum, blockHash)
 	}
+
+	return nil
 }
 
 // decodeData decodes Tron transaction data for TRC20 tokens.
@@ -176,48 +296,267 @@ func (m *Manager) decodeData(trc20 []byte) (to string, amount string, flag bool)
 	return
 }
 
-// handleTransfer handles Tron token transfers.
-func (m *Manager) handleTransfer(txID, from, to string, amount string, state core.Transaction_ResultContractResult) {
+// handleTransfer handles Tron token transfers and returns the transfer of a deposit, the amount is normalized
+// to the settlement currency and native TRX is converted at the TRX rate. The transfers from the platform
+// addresses, e.g. the sweep fee top-ups, are not deposits.
+func (m *Manager) handleTransfer(txID, from, to string, token config.TokenCfg, amount string, state core.Transaction_ResultContractResult, blockNum int64, blockHash string) *types.TronTransferWatch {
 	// log.Debugf("Transfer :%s,%s,%s,%s,%s", txID, to, from, amount, state)
 
 	userI, exist := m.tronAddrs.Load(to)
-	if exist && userI != nil {
-		userID := userI.(string)
+	if !exist || userI == nil {
+		return nil
+	}
+	userID := userI.(string)
+
+	if _, ok := m.platformAddrs.Load(from); ok {
+		log.Debugf("transfer %s from the platform address %s to %s is not a deposit", txID, from, to)
+		return nil
+	}
+
+	if belowMinDeposit(token, amount) {
+		log.Warnf("deposit %s of %s %s is below the minimum deposit %s", txID, amount, token.Symbol, token.MinDeposit)
+		return nil
+	}
+
+	value, rate, err := m.settlementValue(token, amount)
+	if err != nil {
+		log.Errorf("%s settlementValue err:%s", txID, err.Error())
+		return nil
+	}
+
+	return &types.TronTransferWatch{
+		TxHash:      txID,
+		From:        from,
+		To:          to,
+		Value:       value,
+		State:       state,
+		UserID:      userID,
+		Token:       token.Symbol,
+		RawValue:    amount,
+		Rate:        rate,
+		BlockNumber: blockNum,
+		BlockHash:   blockHash,
+	}
+}
+
+// newTronDeposits creates the deposits to track until their block is confirmed and the outbox events of the
+// transfers of deposits, a failed transfer is published but not tracked.
+func newTronDeposits(transfers []*types.TronTransferWatch) ([]*types.TronDeposit, []*types.OutboxEvent) {
+	var deposits []*types.TronDeposit
+	var events []*types.OutboxEvent
+	for _, tr := range transfers {
+		event, err := newChainEvent(&types.ChainEvent{Type: types.ChainEventTronTransfer, TronTransfer: tr}, tr.TxHash+"-"+tr.To)
+		if err != nil {
+			log.Errorf("%s newChainEvent err:%s", tr.TxHash, err.Error())
+			continue
+		}
+		events = append(events, event)
+
+		if tr.State != core.Transaction_Result_SUCCESS {
+			continue
+		}
+
+		deposits = append(deposits, &types.TronDeposit{
+			TxHash:      tr.TxHash,
+			Token:       tr.Token,
+			BlockNumber: tr.BlockNumber,
+			BlockHash:   tr.BlockHash,
+			State:       types.RechargeCreate,
+		})
+	}
 
-		m.notification.Pub(&types.TronTransferWatch{
-			TxHash: txID,
-			From:   from,
-			To:     to,
-			Value:  amount,
-			State:  state,
-			UserID: userID,
-		}, types.EventTronTransferWatch.String())
+	return deposits, events
+}
+
+// settlementValue returns the value of a token amount in the settlement currency and the rate it is converted at.
+func (m *Manager) settlementValue(token config.TokenCfg, amount string) (string, string, error) {
+	if token.Symbol != trxSymbol || token.ContractAddr != "" {
+		value, err := normalizeAmount(amount, token.Decimals)
+		return value, "1", err
+	}
+
+	rate, err := m.trxRate()
+	if err != nil {
+		return "", "", err
 	}
+
+	value, err := convertAmount(amount, rate)
+	return value, rate, err
 }
 
 // SupplementOrder supplements Tron orders.
 func (m *Manager) SupplementOrder(hash string) error {
-	client, err := m.getGrpcClient()
+	info, err := m.TronDeposits(hash)
 	if err != nil {
-		log.Errorln("getGrpcClient err :", err.Error())
 		return err
 	}
 
+	if !info.Found {
+		return xerrors.New("GetRet is nil")
+	}
+
+	deposits, events := newTronDeposits(info.List)
+
+	// a deposit published before is handed to the mall again, its handler credits it once
+	return m.RepublishTronDeposits(deposits, events)
+}
+
+// TronDeposits returns the transfers of a Tron transaction to the deposit addresses, nothing is published.
+func (m *Manager) TronDeposits(hash string) (*types.TronDeposits, error) {
+	client, err := m.TronPool()
+	if err != nil {
+		log.Errorln("TronPool err :", err.Error())
+		return nil, err
+	}
+
 	info, err := client.GetTransactionByID(hash)
 	if err != nil {
 		log.Errorln("GetTransactionByID err :", err.Error())
-		return err
+		return nil, err
 	}
 
+	out := &types.TronDeposits{List: make([]*types.TronTransferWatch, 0)}
 	if len(info.GetRet()) == 0 {
-		return xerrors.New("GetRet is nil")
+		return out, nil
 	}
 
 	state := info.GetRet()[0].ContractRet
+	out.Found = true
+	out.Success = state == core.Transaction_Result_SUCCESS
+
+	// the block is resolved when the deposit is confirmed if the transaction is not in a block yet
+	blockNum, blockHash, err := m.tronTransactionBlock(client, hash)
+	if err != nil {
+		log.Errorf("tronTransactionBlock err:%s", err.Error())
+	}
 
 	for _, contract := range info.RawData.Contract {
-		m.filterTransaction(contract, hash, state)
+		if tr := m.filterTransaction(contract, hash, state, blockNum, blockHash); tr != nil {
+			out.List = append(out.List, tr)
+		}
 	}
 
-	return nil
+	return out, nil
+}
+
+// confirmTronDeposits reports the tracked deposits whose block has enough confirmations to be credited by the mall,
+// a deposit whose block has been replaced is moved to the block including it now,
+// it is orphaned if no block includes it for TrxOrphanBlocks.
+func (m *Manager) confirmTronDeposits(client *trxbridge.Pool, nowHeight int64) {
+	confirmations := m.cfg.TrxConfirmations
+	if confirmations <= 0 {
+		confirmations = defaultTronConfirmations
+	}
+
+	orphanBlocks := m.cfg.TrxOrphanBlocks
+	if orphanBlocks <= 0 {
+		orphanBlocks = defaultTronOrphanBlocks
+	}
+
+	list, err := m.LoadTronDeposits(types.RechargeCreate)
+	if err != nil {
+		log.Errorf("LoadTronDeposits err:%s", err.Error())
+		return
+	}
+
+	for _, info := range list {
+		if info.BlockNumber > 0 && nowHeight-info.BlockNumber < confirmations {
+			continue
+		}
+
+		if info.BlockNumber > 0 {
+			block, err := client.GetBlockByNum(info.BlockNumber)
+			if err != nil {
+				log.Errorf("GetBlockByNum %d err:%s", info.BlockNumber, err.Error())
+				continue
+			}
+
+			if hexutil.Encode(block.Blockid) == info.BlockHash {
+				m.confirmTronDeposit(info)
+				continue
+			}
+
+			log.Warnf("block %d of deposit %s has been replaced", info.BlockNumber, info.TxHash)
+		}
+
+		blockNum, blockHash, err := m.tronTransactionBlock(client, info.TxHash)
+		if err != nil {
+			log.Errorf("%s tronTransactionBlock err:%s", info.TxHash, err.Error())
+			continue
+		}
+
+		if blockNum == 0 {
+			// a supplemented transaction may not be in a block yet and a replaced one may be included again
+			since, ok := m.tronMissing[info.TxHash]
+			if !ok {
+				m.tronMissing[info.TxHash] = nowHeight
+				continue
+			}
+
+			if nowHeight-since < orphanBlocks {
+				continue
+			}
+
+			log.Warnf("deposit %s is in no block since %d", info.TxHash, since)
+			info.State = types.RechargeOrphaned
+			if m.updateTronDeposit(info) {
+				delete(m.tronMissing, info.TxHash)
+			}
+			continue
+		}
+
+		delete(m.tronMissing, info.TxHash)
+
+		info.BlockNumber = blockNum
+		info.BlockHash = blockHash
+		m.updateTronDeposit(info)
+	}
+}
+
+// confirmTronDeposit reports a deposit whose block is confirmed, native TRX deposits are parked for review if configured.
+func (m *Manager) confirmTronDeposit(info *types.TronDeposit) {
+	info.State = types.RechargeDone
+	if info.Token == trxSymbol && m.cfg.TrxReview {
+		info.State = types.RechargeReview
+	}
+
+	m.updateTronDeposit(info)
+}
+
+// updateTronDeposit saves the new block or state of a pending deposit together with the event reporting it to the mall.
+func (m *Manager) updateTronDeposit(info *types.TronDeposit) bool {
+	key := fmt.Sprintf("%s-%d-%s", info.TxHash, info.State, info.BlockHash)
+	event, err := newChainEvent(&types.ChainEvent{Type: types.ChainEventTronDeposit, TronDeposit: info}, key)
+	if err != nil {
+		log.Errorf("%s newChainEvent err:%s", info.TxHash, err.Error())
+		return false
+	}
+
+	err = m.UpdateTronDeposit(info, types.RechargeCreate, event)
+	if err != nil {
+		log.Errorf("%s UpdateTronDeposit err:%s", info.TxHash, err.Error())
+		return false
+	}
+
+	return true
+}
+
+// tronTransactionBlock returns the number and hash of the block including a transaction,
+// the number is 0 if the transaction is not in a block.
+func (m *Manager) tronTransactionBlock(client *trxbridge.Pool, txID string) (int64, string, error) {
+	info, err := client.GetTransactionInfoByID(txID)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if info.GetBlockNumber() == 0 {
+		return 0, "", nil
+	}
+
+	block, err := client.GetBlockByNum(info.GetBlockNumber())
+	if err != nil {
+		return 0, "", err
+	}
+
+	return info.GetBlockNumber(), hexutil.Encode(block.Blockid), nil
 }
@@ -0,0 +1,109 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/node/config"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// trxSymbol is the token of the native TRX deposits
+	trxSymbol = "TRX"
+	// trxDecimals is the number of decimals of TRX, the amounts are in sun
+	trxDecimals = 6
+
+	trxRateInterval = 10 * time.Minute
+	trxRateTimeout  = 10 * time.Second
+)
+
+// trxToken is the token config of the native TRX deposits, it has no contract
+var trxToken = config.TokenCfg{Symbol: trxSymbol, Decimals: trxDecimals, Enabled: true}
+
+// trxRateResponse is the price response of the rate source, e.g. https://api.binance.com/api/v3/ticker/price?symbol=TRXUSDT
+type trxRateResponse struct {
+	Price string `json:"price"`
+}
+
+// trxRateCache caches the TRX price fetched from the rate source
+type trxRateCache struct {
+	lk   sync.Mutex
+	rate string
+	et   time.Time
+}
+
+// trxRate returns the TRX price in the settlement currency, the configured rate is used if the rate source fails.
+func (m *Manager) trxRate() (string, error) {
+	m.trxPrice.lk.Lock()
+	defer m.trxPrice.lk.Unlock()
+
+	if m.trxPrice.rate != "" && time.Now().Before(m.trxPrice.et) {
+		return m.trxPrice.rate, nil
+	}
+
+	if m.cfg.TrxRateURL != "" {
+		rate, err := fetchTrxRate(m.cfg.TrxRateURL)
+		if err == nil {
+			m.trxPrice.rate = rate
+			m.trxPrice.et = time.Now().Add(trxRateInterval)
+			return rate, nil
+		}
+
+		log.Errorf("fetchTrxRate err:%s", err.Error())
+	}
+
+	if _, ok := new(big.Rat).SetString(m.cfg.TrxRate); !ok {
+		return "", xerrors.New("no TRX rate is available")
+	}
+
+	return m.cfg.TrxRate, nil
+}
+
+// fetchTrxRate fetches the TRX price from the rate source.
+func fetchTrxRate(url string) (string, error) {
+	client := &http.Client{Timeout: trxRateTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", xerrors.Errorf("rate source status %s", resp.Status)
+	}
+
+	var out trxRateResponse
+	err = json.NewDecoder(resp.Body).Decode(&out)
+	if err != nil {
+		return "", err
+	}
+
+	r, ok := new(big.Rat).SetString(out.Price)
+	if !ok || r.Sign() <= 0 {
+		return "", xerrors.Errorf("invalid TRX price %s", out.Price)
+	}
+
+	return out.Price, nil
+}
+
+// convertAmount converts a raw amount of sun to the settlement currency at a rate,
+// the digits beyond the settlement decimals are dropped.
+func convertAmount(raw, rate string) (string, error) {
+	v, ok := new(big.Rat).SetString(raw)
+	if !ok {
+		return "0", xerrors.Errorf("invalid TRX amount %s", raw)
+	}
+
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return "0", xerrors.Errorf("invalid TRX rate %s", rate)
+	}
+
+	v.Mul(v, r)
+	// TRX and the settlement currency both have 6 decimals
+	return new(big.Int).Quo(v.Num(), v.Denom()).String(), nil
+}
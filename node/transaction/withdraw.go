@@ -0,0 +1,300 @@
+package transaction
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/LMF709268224/titan-vps/api/types"
+	"github.com/LMF709268224/titan-vps/lib/trxbridge"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/smirkcat/hdwallet"
+	"golang.org/x/xerrors"
+)
+
+// withdrawDropMargin is how long a payout transaction is looked for after it expired before it is treated as dropped
+const withdrawDropMargin = 5 * time.Minute
+
+// initWithdrawExecutor loads the hot wallet key and starts paying out the approved withdrawals.
+func (m *Manager) initWithdrawExecutor() error {
+	cfg := m.cfg.TrxWithdraw
+	if !cfg.Enabled {
+		return nil
+	}
+
+	key, err := crypto.HexToECDSA(cfg.HotWalletKey)
+	if err != nil {
+		return xerrors.Errorf("parse TrxWithdraw.HotWalletKey err:%s", err.Error())
+	}
+
+	if m.WithdrawContract() == "" {
+		return xerrors.New("TrxWithdraw needs a token contract")
+	}
+
+	m.hotWalletKey = key
+	// a payout to a deposit address is not a deposit
+	m.platformAddrs.Store(hdwallet.PubkeyToAddressTron(key.PublicKey), struct{}{})
+
+	go m.cronExecuteWithdrawals()
+
+	return nil
+}
+
+// WithdrawEnabled checks if the approved withdrawals are paid out by the executor.
+func (m *Manager) WithdrawEnabled() bool {
+	return m.hotWalletKey != nil
+}
+
+// WithdrawContract returns the contract address of the paid token.
+func (m *Manager) WithdrawContract() string {
+	if m.cfg.TrxWithdraw.ContractAddr != "" {
+		return m.cfg.TrxWithdraw.ContractAddr
+	}
+
+	return m.cfg.TrxContractorAddr
+}
+
+// withdrawDecimals returns the decimals of the paid token, the accepted deposit tokens are looked up first.
+func (m *Manager) withdrawDecimals() int64 {
+	if token, exist := m.trc20Tokens[m.WithdrawContract()]; exist {
+		return token.Decimals
+	}
+
+	return settlementDecimals
+}
+
+func (m *Manager) cronExecuteWithdrawals() {
+	interval := time.Duration(m.cfg.TrxWithdraw.Interval)
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		<-ticker.C
+
+		m.executeWithdrawals()
+	}
+}
+
+// executeWithdrawals pays out the approved withdrawals and tracks the broadcast payouts.
+func (m *Manager) executeWithdrawals() {
+	list, err := m.LoadExecutablePayouts()
+	if err != nil {
+		log.Errorf("LoadExecutablePayouts err:%s", err.Error())
+		return
+	}
+
+	if len(list) == 0 {
+		return
+	}
+
+	client, err := m.TronPool()
+	if err != nil {
+		log.Errorf("TronPool err:%s", err.Error())
+		return
+	}
+
+	block, err := client.GetNowBlock()
+	if err != nil {
+		log.Errorf("GetNowBlock err:%s", err.Error())
+		return
+	}
+	nowHeight := block.BlockHeader.RawData.Number
+
+	for _, info := range list {
+		switch info.State {
+		case types.WithdrawApproved:
+			m.payWithdrawal(client, info)
+		case types.WithdrawBroadcast:
+			m.trackWithdrawal(client, info, nowHeight)
+		}
+	}
+}
+
+// payWithdrawal signs the payout of an approved withdrawal, saves its transaction id and broadcasts it.
+func (m *Manager) payWithdrawal(client *trxbridge.Pool, info *types.Payout) {
+	amount, err := denormalizeAmount(info.Value, m.withdrawDecimals())
+	if err != nil {
+		m.failWithdrawal(info, err.Error())
+		return
+	}
+
+	data, err := trc20TransferData(info.WithdrawAddr, amount)
+	if err != nil {
+		m.failWithdrawal(info, err.Error())
+		return
+	}
+
+	tx, txID, err := client.SignTransferContract(m.hotWalletKey, m.WithdrawContract(), data, m.cfg.TrxWithdraw.FeeLimit)
+	if err != nil {
+		log.Errorf("%s SignTransferContract err:%s", info.OrderID, err.Error())
+		return
+	}
+
+	broadcast := *info
+	broadcast.TxHash = txID
+	broadcast.TxExpiration = tx.GetRawData().GetExpiration()
+	broadcast.Attempts++
+
+	event, err := newPayoutEvent(&broadcast, types.WithdrawBroadcast, "", txID)
+	if err != nil {
+		log.Errorf("%s newPayoutEvent err:%s", info.OrderID, err.Error())
+		return
+	}
+
+	// the transaction id is saved first, a payout is never broadcast without being tracked
+	err = m.BroadcastPayout(info.OrderID, txID, broadcast.TxExpiration, event)
+	if err != nil {
+		log.Errorf("%s BroadcastPayout err:%s", info.OrderID, err.Error())
+		return
+	}
+
+	err = client.Broadcast(tx)
+	if err != nil {
+		// it is retried once the transaction has expired without being included
+		log.Errorf("%s Broadcast %s err:%s", info.OrderID, txID, err.Error())
+	}
+}
+
+// trackWithdrawal reports a confirmed payout to be settled by the mall and retries a failed or dropped one.
+func (m *Manager) trackWithdrawal(client *trxbridge.Pool, info *types.Payout, nowHeight int64) {
+	txInfo, err := client.GetTransactionInfoByID(info.TxHash)
+	if err != nil {
+		log.Errorf("%s GetTransactionInfoByID err:%s", info.TxHash, err.Error())
+		return
+	}
+
+	if txInfo.GetBlockNumber() == 0 {
+		expiration := time.UnixMilli(info.TxExpiration).Add(withdrawDropMargin)
+		if time.Now().Before(expiration) {
+			return
+		}
+
+		// the node asked may lag or miss the transaction, it is paid again only once the drop is confirmed
+		dropped, err := client.TransactionDropped(info.TxHash, expiration)
+		if err != nil {
+			log.Errorf("%s TransactionDropped err:%s", info.TxHash, err.Error())
+		}
+		if dropped {
+			m.retryWithdrawal(info, "transaction "+info.TxHash+" dropped")
+		}
+		return
+	}
+
+	if nowHeight-txInfo.GetBlockNumber() < m.tronConfirmations() {
+		return
+	}
+
+	if !tronTransactionSucceeded(txInfo) {
+		m.retryWithdrawal(info, "transaction "+info.TxHash+" failed "+string(txInfo.GetResMessage()))
+		return
+	}
+
+	event, err := newPayoutEvent(info, types.WithdrawDone, "", info.TxHash)
+	if err != nil {
+		log.Errorf("%s newPayoutEvent err:%s", info.OrderID, err.Error())
+		return
+	}
+
+	err = m.UpdatePayoutState(info.OrderID, types.WithdrawDone, info.State, "", event)
+	if err != nil {
+		log.Errorf("%s UpdatePayoutState err:%s", info.OrderID, err.Error())
+	}
+}
+
+// TronPayoutFailed checks if a payout transaction paid nothing, it failed on chain or its drop is confirmed.
+// A transaction that is pending, not yet confirmed or not found by enough nodes is not failed.
+func (m *Manager) TronPayoutFailed(hash string, expiration int64) (bool, error) {
+	client, err := m.TronPool()
+	if err != nil {
+		return false, err
+	}
+
+	txInfo, err := client.GetTransactionInfoByID(hash)
+	if err != nil {
+		return false, err
+	}
+
+	if txInfo.GetBlockNumber() == 0 {
+		deadline := time.UnixMilli(expiration).Add(withdrawDropMargin)
+		if time.Now().Before(deadline) {
+			return false, nil
+		}
+
+		return client.TransactionDropped(hash, deadline)
+	}
+
+	block, err := client.GetNowBlock()
+	if err != nil {
+		return false, err
+	}
+
+	if block.BlockHeader.RawData.Number-txInfo.GetBlockNumber() < m.tronConfirmations() {
+		return false, nil
+	}
+
+	return !tronTransactionSucceeded(txInfo), nil
+}
+
+// retryWithdrawal approves a failed payout again or marks it failed once it used all the attempts.
+func (m *Manager) retryWithdrawal(info *types.Payout, msg string) {
+	// the attempts made before an admin retried the payout are not counted
+	if info.Attempts-info.BaseAttempts >= m.cfg.TrxWithdraw.MaxAttempts {
+		m.failWithdrawal(info, msg)
+		return
+	}
+
+	err := m.UpdatePayoutState(info.OrderID, types.WithdrawApproved, info.State, msg, nil)
+	if err != nil {
+		log.Errorf("%s UpdatePayoutState err:%s", info.OrderID, err.Error())
+	}
+}
+
+// failWithdrawal marks a withdrawal for manual handling.
+func (m *Manager) failWithdrawal(info *types.Payout, msg string) {
+	log.Warnf("withdraw %s failed: %s", info.OrderID, msg)
+
+	// a withdrawal retried by an admin can fail again with the same transaction
+	event, err := newPayoutEvent(info, types.WithdrawFailed, msg, uuid.NewString())
+	if err != nil {
+		log.Errorf("%s newPayoutEvent err:%s", info.OrderID, err.Error())
+		return
+	}
+
+	err = m.UpdatePayoutState(info.OrderID, types.WithdrawFailed, info.State, msg, event)
+	if err != nil {
+		log.Errorf("%s UpdatePayoutState err:%s", info.OrderID, err.Error())
+	}
+}
+
+// SubmitPayout queues the payout of an approved withdrawal, a payout submitted before is kept.
+func (m *Manager) SubmitPayout(orderID, withdrawAddr, value string) error {
+	if !m.WithdrawEnabled() {
+		return xerrors.New("the withdraw executor is not enabled")
+	}
+
+	return m.SavePayout(&types.Payout{OrderID: orderID, WithdrawAddr: withdrawAddr, Value: value, State: types.WithdrawApproved})
+}
+
+// RetryPayout pays a failed payout again with all its attempts.
+func (m *Manager) RetryPayout(orderID string) error {
+	return m.UpdatePayoutState(orderID, types.WithdrawApproved, types.WithdrawFailed, "", nil)
+}
+
+// newPayoutEvent creates the event of the state of the payout of a withdrawal, it is saved once for a key.
+func newPayoutEvent(info *types.Payout, state types.WithdrawState, msg, key string) (*types.OutboxEvent, error) {
+	return newChainEvent(&types.ChainEvent{
+		Type: types.ChainEventPayout,
+		Payout: &types.WithdrawPayout{
+			OrderID:      info.OrderID,
+			TxHash:       info.TxHash,
+			State:        state,
+			Msg:          msg,
+			Attempts:     info.Attempts,
+			TxExpiration: info.TxExpiration,
+		},
+	}, fmt.Sprintf("%s-%s-%s", info.OrderID, state, key))
+}
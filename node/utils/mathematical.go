@@ -52,3 +52,26 @@ func ReduceBigInt(numstr string, value string) (string, error) {
 	result := new(big.Int).Sub(n, m)
 	return result.String(), nil
 }
+
+// SplitBigInt splits a big integer represented as a string into n parts,
+// the remainder of the division is added to the first part.
+func SplitBigInt(numstr string, n int) ([]string, error) {
+	num, ok := new(big.Int).SetString(numstr, 10)
+	if !ok || num == nil {
+		return nil, &api.ErrWeb{Code: terrors.EncodingError.Int(), Message: fmt.Sprintf("SplitBigInt error: invalid num %s", numstr)}
+	}
+
+	if n <= 0 {
+		return nil, &api.ErrWeb{Code: terrors.ParametersWrong.Int(), Message: fmt.Sprintf("SplitBigInt error: invalid count %d", n)}
+	}
+
+	part, rem := new(big.Int).QuoRem(num, big.NewInt(int64(n)), new(big.Int))
+
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = part.String()
+	}
+	parts[0] = new(big.Int).Add(part, rem).String()
+
+	return parts, nil
+}
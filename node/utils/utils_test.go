@@ -15,3 +15,18 @@ func TestBigIntReduce(t *testing.T) {
 	fmt.Println("BigIntReduce :", s)
 	fmt.Println("BigIntReduce :", e)
 }
+
+func TestSplitBigInt(t *testing.T) {
+	parts, err := SplitBigInt("1000001", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parts) != 3 || parts[0] != "333335" || parts[1] != "333333" || parts[2] != "333333" {
+		t.Errorf("SplitBigInt unexpected parts: %v", parts)
+	}
+
+	if _, err = SplitBigInt("100", 0); err == nil {
+		t.Errorf("SplitBigInt should fail with zero count")
+	}
+}
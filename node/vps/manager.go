@@ -18,7 +18,12 @@ import (
 
 var log = logging.Logger("vps")
 
-const updateInstancesInterval = 30 * time.Minute
+const (
+	updateInstancesInterval = 30 * time.Minute
+
+	reinstallCheckInterval = 10 * time.Second
+	reinstallCheckCount    = 30
+)
 
 // Manager manager order
 type Manager struct {
@@ -40,6 +45,12 @@ func NewManager(sdb *db.SQLDB, getCfg dtypes.GetMallConfigFunc) (*Manager, error
 		cfg:   cfg,
 	}
 
+	// a reinstall is not resumed, the user can start it again
+	err = sdb.FailRunningReinstalls("the reinstall was interrupted")
+	if err != nil {
+		log.Errorf("FailRunningReinstalls err: %s", err.Error())
+	}
+
 	go m.cronUpdateInstanceDefaultInfo()
 	// go m.cronUpdateInstancesInfo()
 
@@ -204,6 +215,139 @@ func (m *Manager) RenewInstance(renewInstanceRequest *types.RenewInstanceRequest
 	return nil
 }
 
+// ReinstallInstance replaces the system disk of an instance and runs its user data again on first boot.
+// The image and user data stored with the instance are used when the request leaves them empty.
+func (m *Manager) ReinstallInstance(info *types.InstanceDetails, req *types.ReinstallInstanceReq) error {
+	userData, err := ValidateUserData(req.UserData)
+	if err != nil {
+		return err
+	}
+
+	if userData == "" {
+		userData = info.UserData
+	}
+
+	imageID := req.ImageID
+	if imageID == "" {
+		imageID = info.ImageID
+	}
+
+	started, err := m.StartInstanceReinstall(info.InstanceId)
+	if err != nil {
+		return &api.ErrWeb{Code: terrors.DatabaseError.Int(), Message: err.Error()}
+	}
+
+	if !started {
+		return &api.ErrWeb{Code: terrors.StatusNotEditable.Int(), Message: "the instance is being reinstalled"}
+	}
+
+	accessKeyID := m.cfg.AliyunAccessKeyID
+	accessKeySecret := m.cfg.AliyunAccessKeySecret
+
+	sErr := aliyun.StopInstance(info.RegionId, accessKeyID, accessKeySecret, info.InstanceId)
+	if sErr != nil {
+		log.Errorf("StopInstance err: %v", sErr)
+		m.finishReinstall(info.InstanceId, xerrors.Errorf("StopInstance err:%s", *sErr.Message))
+		return &api.ErrWeb{Code: terrors.AliApiGetFailed.Int(), Message: *sErr.Message}
+	}
+
+	// the disk is replaced once the instance stops, the result is saved with the instance
+	go func() {
+		err := m.reinstall(info, imageID, userData)
+		m.finishReinstall(info.InstanceId, err)
+
+		m.UpdateInstanceInfo(info, true)
+	}()
+
+	return nil
+}
+
+// reinstall replaces the system disk of a stopping instance and starts it again.
+func (m *Manager) reinstall(info *types.InstanceDetails, imageID, userData string) error {
+	accessKeyID := m.cfg.AliyunAccessKeyID
+	accessKeySecret := m.cfg.AliyunAccessKeySecret
+
+	if !m.waitInstanceStatus(info.RegionId, info.InstanceId, "Stopped") {
+		return xerrors.New("the instance did not stop in time")
+	}
+
+	if userData != "" {
+		sErr := aliyun.ModifyInstanceUserData(info.RegionId, accessKeyID, accessKeySecret, info.InstanceId, userData)
+		if sErr != nil {
+			return xerrors.Errorf("ModifyInstanceUserData err:%s", *sErr.Message)
+		}
+	}
+
+	_, sErr := aliyun.ReplaceSystemDisk(info.RegionId, accessKeyID, accessKeySecret, info.InstanceId, imageID)
+	if sErr != nil {
+		return xerrors.Errorf("ReplaceSystemDisk err:%s", *sErr.Message)
+	}
+
+	err := m.UpdateInstanceImageAndUserData(info.InstanceId, imageID, userData)
+	if err != nil {
+		log.Errorf("UpdateInstanceImageAndUserData err: %s", err.Error())
+	}
+
+	if !m.waitInstanceStatus(info.RegionId, info.InstanceId, "Stopped") {
+		return xerrors.New("the system disk was not replaced in time")
+	}
+
+	sErr = aliyun.StartInstance(info.RegionId, accessKeyID, accessKeySecret, info.InstanceId)
+	if sErr != nil {
+		return xerrors.Errorf("StartInstance err:%s", *sErr.Message)
+	}
+
+	return nil
+}
+
+// finishReinstall saves the result of the reinstall of an instance.
+func (m *Manager) finishReinstall(instanceID string, rErr error) {
+	state := types.ReinstallDone
+	msg := ""
+	if rErr != nil {
+		log.Errorf("ReinstallInstance %s err: %s", instanceID, rErr.Error())
+
+		state = types.ReinstallFailed
+		msg = rErr.Error()
+		if len(msg) > 256 {
+			msg = msg[:256]
+		}
+	}
+
+	err := m.UpdateInstanceReinstall(instanceID, state, msg)
+	if err != nil {
+		log.Errorf("UpdateInstanceReinstall %s err: %s", instanceID, err.Error())
+	}
+}
+
+// waitInstanceStatus polls the instance until it reaches the given status.
+func (m *Manager) waitInstanceStatus(regionID, instanceID, status string) bool {
+	accessKeyID := m.cfg.AliyunAccessKeyID
+	accessKeySecret := m.cfg.AliyunAccessKeySecret
+
+	for i := 0; i < reinstallCheckCount; i++ {
+		time.Sleep(reinstallCheckInterval)
+
+		rsp, sErr := aliyun.DescribeInstanceStatus(regionID, accessKeyID, accessKeySecret, []string{instanceID})
+		if sErr != nil {
+			log.Errorf("DescribeInstanceStatus err: %v", sErr)
+			continue
+		}
+
+		if rsp.Body == nil || rsp.Body.InstanceStatuses == nil {
+			continue
+		}
+
+		for _, s := range rsp.Body.InstanceStatuses.InstanceStatus {
+			if s.Status != nil && *s.Status == status {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // cronFetchInstanceDefaultInfo fetches default instance information periodically.
 func (m *Manager) cronUpdateInstanceDefaultInfo() {
 	now := time.Now()
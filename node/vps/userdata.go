@@ -0,0 +1,64 @@
+package vps
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LMF709268224/titan-vps/api"
+	"github.com/LMF709268224/titan-vps/api/terrors"
+)
+
+// MaxUserDataSize is the maximum size of the raw user data in bytes.
+// Aliyun limits the base64 encoded user data to 32 KB.
+const MaxUserDataSize = 16 * 1024
+
+// userDataHeaders lists the first-line markers accepted by cloud-init and the Aliyun windows agent.
+var userDataHeaders = []string{
+	"#!",
+	"#cloud-config",
+	"#cloud-boothook",
+	"#include",
+	"#upstart-job",
+	"#part-handler",
+	"Content-Type: multipart/",
+	"[bat]",
+	"[powershell]",
+}
+
+// ValidateUserData checks a user-data script or cloud-config document and returns it as plain text.
+// Base64 encoded input is accepted and decoded.
+func ValidateUserData(userData string) (string, error) {
+	if userData == "" {
+		return "", nil
+	}
+
+	if !hasUserDataHeader(userData) {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(userData))
+		if err != nil || !hasUserDataHeader(string(decoded)) {
+			return "", &api.ErrWeb{Code: terrors.UserDataInvalid.Int(), Message: "user data must start with '#!' or a cloud-init header such as '#cloud-config'"}
+		}
+		userData = string(decoded)
+	}
+
+	if len(userData) > MaxUserDataSize {
+		return "", &api.ErrWeb{Code: terrors.UserDataInvalid.Int(), Message: fmt.Sprintf("user data is %d bytes, the limit is %d bytes", len(userData), MaxUserDataSize)}
+	}
+
+	if !utf8.ValidString(userData) || strings.ContainsRune(userData, 0) {
+		return "", &api.ErrWeb{Code: terrors.UserDataInvalid.Int(), Message: "user data must be utf-8 text"}
+	}
+
+	return userData, nil
+}
+
+func hasUserDataHeader(userData string) bool {
+	for _, header := range userDataHeaders {
+		if strings.HasPrefix(userData, header) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package vps
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestValidateUserData(t *testing.T) {
+	script := "#!/bin/bash\necho hello > /tmp/hello\n"
+	cloudConfig := "#cloud-config\npackages:\n  - nginx\n"
+
+	cases := []struct {
+		name    string
+		in      string
+		out     string
+		wantErr bool
+	}{
+		{name: "empty", in: "", out: ""},
+		{name: "script", in: script, out: script},
+		{name: "cloud-config", in: cloudConfig, out: cloudConfig},
+		{name: "base64", in: base64.StdEncoding.EncodeToString([]byte(script)), out: script},
+		{name: "no header", in: "echo hello", wantErr: true},
+		{name: "too large", in: "#!/bin/sh\n" + strings.Repeat("a", MaxUserDataSize), wantErr: true},
+		{name: "binary", in: "#!/bin/sh\n\x00\xff", wantErr: true},
+	}
+
+	for _, c := range cases {
+		out, err := ValidateUserData(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error", c.name)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %s", c.name, err.Error())
+			continue
+		}
+
+		if out != c.out {
+			t.Errorf("%s: got %q, want %q", c.name, out, c.out)
+		}
+	}
+}